@@ -0,0 +1,236 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/tconnellan/macro-tracker-backend/internal/data"
+	"github.com/tconnellan/macro-tracker-backend/internal/validator"
+)
+
+func (app *application) listCollections(w http.ResponseWriter, r *http.Request) {
+	collections, err := app.models.Collections.GetAllByUserID(app.contextGetUser(r).ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"collections": collections}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// getCollection returns a collection along with the current version of each recipe in it
+func (app *application) getCollection(w http.ResponseWriter, r *http.Request) {
+	collectionID, err := app.readIDParam(r)
+	if err != nil || collectionID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	userID := app.contextGetUser(r).ID
+
+	collection, err := app.models.Collections.Get(collectionID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	recipes, err := app.models.Collections.GetRecipes(collectionID, userID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"collection": collection, "recipes": recipes}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) createCollection(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	collection := &data.Collection{
+		UserID:      app.contextGetUser(r).ID,
+		Name:        input.Name,
+		Description: input.Description,
+	}
+
+	v := validator.New()
+	data.ValidateCollection(v, collection)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Collections.Insert(collection)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateCollection):
+			v.AddError("name", "a collection with this name already exists")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrReferencedUserDoesNotExist):
+			app.foreignKeyViolationResponse(w, r, err)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"collection": collection}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateCollection(w http.ResponseWriter, r *http.Request) {
+	collectionID, err := app.readIDParam(r)
+	if err != nil || collectionID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	collection := &data.Collection{
+		ID:          collectionID,
+		UserID:      app.contextGetUser(r).ID,
+		Name:        input.Name,
+		Description: input.Description,
+	}
+
+	v := validator.New()
+	data.ValidateCollection(v, collection)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Collections.Update(collection)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrDuplicateCollection):
+			v.AddError("name", "a collection with this name already exists")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"collection": collection}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteCollection(w http.ResponseWriter, r *http.Request) {
+	collectionID, err := app.readIDParam(r)
+	if err != nil || collectionID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Collections.Delete(collectionID, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusNoContent, nil, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// addCollectionRecipe adds the lineage of the recipe in the recipeId parameter to the collection
+func (app *application) addCollectionRecipe(w http.ResponseWriter, r *http.Request) {
+	collectionID, err := app.readIDParam(r)
+	if err != nil || collectionID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+	recipeID, err := app.readRecipeIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Collections.AddRecipe(collectionID, recipeID, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusNoContent, nil, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// removeCollectionRecipe takes the lineage of the recipe in the recipeId parameter out of the collection
+func (app *application) removeCollectionRecipe(w http.ResponseWriter, r *http.Request) {
+	collectionID, err := app.readIDParam(r)
+	if err != nil || collectionID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+	recipeID, err := app.readRecipeIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Collections.RemoveRecipe(collectionID, recipeID, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusNoContent, nil, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
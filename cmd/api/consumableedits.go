@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/tconnellan/macro-tracker-backend/internal/data"
+	"github.com/tconnellan/macro-tracker-backend/internal/validator"
+)
+
+// listConsumableEdits returns the edits to shared consumables waiting for an admin, oldest first
+func (app *application) listConsumableEdits(w http.ResponseWriter, r *http.Request) {
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	filters := data.MetadataFilters{
+		Page:         app.readInt(qs, "page", 1, v),
+		PageSize:     app.readInt(qs, "pagesize", 20, v),
+		Sort:         "created_at",
+		SortSafeList: []string{"created_at"},
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	data.ValidateMetadataFilters(v, filters)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	edits, metadata, err := app.models.ConsumableEdits.GetPending(filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"edits": edits, "metadata": metadata}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// approveConsumableEdit applies a pending edit to its consumable
+func (app *application) approveConsumableEdit(w http.ResponseWriter, r *http.Request) {
+	editID, err := app.readIDParam(r)
+	if err != nil || editID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.ConsumableEdits.Approve(editID, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrDuplicateBarcode):
+			v := validator.New()
+			v.AddError("barcode", "a consumable of this brand already has this barcode")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"id": editID, "status": data.EditApproved}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// rejectConsumableEdit closes a pending edit leaving its consumable unchanged
+func (app *application) rejectConsumableEdit(w http.ResponseWriter, r *http.Request) {
+	editID, err := app.readIDParam(r)
+	if err != nil || editID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.ConsumableEdits.Reject(editID, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"id": editID, "status": data.EditRejected}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/tconnellan/macro-tracker-backend/internal/data"
+	"github.com/tconnellan/macro-tracker-backend/internal/validator"
+)
+
+// maxMergeDuplicates bounds the consumables merged by one request
+const maxMergeDuplicates = 20
+
+// findConsumableDuplicates returns the consumables visible to the user that are likely duplicates of
+// the consumable
+func (app *application) findConsumableDuplicates(w http.ResponseWriter, r *http.Request) {
+	consumableID, err := app.readIDParam(r)
+	if err != nil || consumableID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	consumable, err := app.models.Consumables.GetByID(consumableID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if !consumable.VisibleTo(user.ID) {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	duplicates, err := app.models.Consumables.FindDuplicates(consumable.ID, user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"consumable": consumable, "duplicates": duplicates}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// mergeConsumables folds duplicates into the consumable, whose ID survives. Users merge their own
+// private consumables, merging shared or verified consumables changes other users' entries so needs
+// an admin. Duplicates must convert to the consumable's units so recipes using them keep their nutrition
+func (app *application) mergeConsumables(w http.ResponseWriter, r *http.Request) {
+	consumableID, err := app.readIDParam(r)
+	if err != nil || consumableID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		DuplicateIDs []int64 `json:"duplicate_ids"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	v.Check(len(input.DuplicateIDs) > 0, "duplicate_ids", "must be provided")
+	v.Check(len(input.DuplicateIDs) <= maxMergeDuplicates, "duplicate_ids", fmt.Sprintf("must not contain more than %d consumables", maxMergeDuplicates))
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	consumable, err := app.models.Consumables.GetByID(consumableID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if !consumable.VisibleTo(user.ID) {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	// IDs already merged resolve to the consumable they were merged into, so compare resolved IDs
+	duplicateIDs := []int64{}
+	seen := map[int64]bool{consumable.ID: true}
+
+	for _, duplicateID := range input.DuplicateIDs {
+		duplicate, err := app.models.Consumables.GetByID(duplicateID)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		if !duplicate.VisibleTo(user.ID) {
+			app.notFoundResponse(w, r)
+			return
+		}
+		if seen[duplicate.ID] {
+			v.AddError("duplicate_ids", "must be unique and not include the consumable merged into")
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+		if !user.IsAdmin && (duplicate.CreatorID != user.ID || duplicate.Visibility != data.VisibilityPrivate) {
+			app.forbiddenResourceResponse(w, r, errors.New("only admins can merge shared consumables or those of another user"))
+			return
+		}
+
+		seen[duplicate.ID] = true
+		duplicateIDs = append(duplicateIDs, duplicate.ID)
+	}
+
+	err = app.models.Consumables.Merge(consumable.ID, duplicateIDs, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrIncompatibleMerge):
+			v.AddError("duplicate_ids", "must be measured in units that convert to the consumable's, set a density or unit weight first")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"consumable": consumable, "merged_ids": duplicateIDs}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -10,6 +10,41 @@ import (
 	"github.com/tconnellan/macro-tracker-backend/internal/validator"
 )
 
+// ConsumableResponse is a consumable along with its named serving sizes
+type ConsumableResponse struct {
+	data.Consumable
+	ServingSizes []*data.ServingSize `json:"serving_sizes"`
+	// Score is the relevance of a search result, left out elsewhere
+	Score *float64 `json:"score,omitempty"`
+}
+
+// consumableResponses attaches the serving sizes of each consumable
+func (app *application) consumableResponses(consumables []*data.Consumable) ([]*ConsumableResponse, error) {
+	ids := make([]int64, 0, len(consumables))
+	for _, consumable := range consumables {
+		ids = append(ids, consumable.ID)
+	}
+
+	servingSizes, err := app.models.ServingSizes.GetByConsumableIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*ConsumableResponse, 0, len(consumables))
+	for _, consumable := range consumables {
+		consumableServingSizes, ok := servingSizes[consumable.ID]
+		if !ok {
+			consumableServingSizes = []*data.ServingSize{}
+		}
+		responses = append(responses, &ConsumableResponse{
+			Consumable:   *consumable,
+			ServingSizes: consumableServingSizes,
+		})
+	}
+
+	return responses, nil
+}
+
 func (app *application) getConsumable(w http.ResponseWriter, r *http.Request) {
 	params := httprouter.ParamsFromContext(r.Context())
 	consumableID, err := strconv.Atoi(params.ByName("id"))
@@ -29,7 +64,50 @@ func (app *application) getConsumable(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"consumable": consumable}, nil)
+	responses, err := app.consumableResponses([]*data.Consumable{consumable})
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"consumable": responses[0]}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// getConsumableByBarcode looks up a consumable by a scanned GTIN, EAN or UPC barcode. When none has the
+// barcode the 404 suggests creating one with it
+func (app *application) getConsumableByBarcode(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+	code := params.ByName("code")
+
+	v := validator.New()
+	v.Check(data.ValidGTIN(code), "code", "must be a valid 8, 12, 13 or 14 digit GTIN")
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	consumable, err := app.models.Consumables.GetByBarcode(code, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			message := "no consumable has this barcode, create one by posting it to /api/v1/consumable with the barcode"
+			app.errorResponse(w, r, http.StatusNotFound, message)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	responses, err := app.consumableResponses([]*data.Consumable{consumable})
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"consumable": responses[0]}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
@@ -70,51 +148,80 @@ func (app *application) getUserConsumables(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"consumables": consumables, "metadata": metadata}, nil)
+	responses, err := app.consumableResponses(consumables)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"consumables": responses, "metadata": metadata}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
 }
 
+// searchConsumables finds consumables by ?name= and ?brand=, sorted by relevance unless ?sort= is given.
+// ?visibility= limits results to a comma separated list of visibilities such as verified
 func (app *application) searchConsumables(w http.ResponseWriter, r *http.Request) {
 
 	v := validator.New()
+	qs := r.URL.Query()
 
-	page := app.readInt(r.URL.Query(), "page", 1, v)
-	pagesize := app.readInt(r.URL.Query(), "pagesize", 1000, v)
-	name := app.readString(r.URL.Query(), "name", "")
-	brandName := app.readString(r.URL.Query(), "name", "")
-	bothMatch := app.readBool(r.URL.Query(), "bothmatch", false, v)
+	filters := data.ConsumableFilters{
+		Metadata: data.MetadataFilters{
+			Page:     app.readInt(qs, "page", 1, v),
+			PageSize: app.readInt(qs, "pagesize", 100, v),
+			Sort:     app.readString(qs, "sort", "relevance"),
+			SortSafeList: []string{
+				"id", "name", "relevance",
+				"-id", "-name", "-relevance",
+			},
+		},
+		NameSearch:                   app.readString(qs, "name", ""),
+		BrandNameSearch:              app.readString(qs, "brand", ""),
+		RequireNameAndBrandNameMatch: app.readBool(qs, "bothmatch", false, v),
+	}
+
+	for _, visibility := range app.readCSV(qs, "visibility", []string{}) {
+		filters.Visibilities = append(filters.Visibilities, data.ConsumableVisibility(visibility))
+	}
 
 	if !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
-	filters := data.ConsumableFilters{
-		Metadata: data.MetadataFilters{
-			Page:         page,
-			PageSize:     pagesize,
-			Sort:         "ID",
-			SortSafeList: []string{"ID"},
-		},
-		NameSearch:                   name,
-		BrandNameSearch:              brandName,
-		RequireNameAndBrandNameMatch: bothMatch,
+	for _, visibility := range filters.Visibilities {
+		v.Check(data.IsValidVisibility(visibility), "visibility", "must be private, shared or verified")
+	}
+	data.ValidateMetadataFilters(v, filters.Metadata)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
 	}
 
-	consumables, metadata, err := app.models.Consumables.Search(filters)
+	matches, metadata, err := app.models.Consumables.Search(app.contextGetUser(r).ID, filters)
 	if err != nil {
-		switch {
-		case errors.Is(err, data.ErrRecordNotFound):
-			app.notFoundResponse(w, r)
-		default:
-			app.serverErrorResponse(w, r, err)
-		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	consumables := make([]*data.Consumable, len(matches))
+	for i, match := range matches {
+		consumables[i] = &match.Consumable
+	}
+
+	responses, err := app.consumableResponses(consumables)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"consumables": consumables, "metadata": metadata}, nil)
+	for i, response := range responses {
+		response.Score = &matches[i].Score
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"consumables": responses, "metadata": metadata}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
@@ -128,7 +235,8 @@ func (app *application) createConsumable(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	consumable.CreatorID = app.contextGetUser(r).ID
+	user := app.contextGetUser(r)
+	consumable.CreatorID = user.ID
 
 	v := validator.New()
 	data.ValidateConsumable(v, &consumable)
@@ -137,11 +245,19 @@ func (app *application) createConsumable(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if consumable.Visibility == data.VisibilityVerified && !user.IsAdmin {
+		app.adminRequiredResponse(w, r)
+		return
+	}
+
 	err = app.models.Consumables.Insert(&consumable)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrReferencedUserDoesNotExist):
 			app.foreignKeyViolationResponse(w, r, err)
+		case errors.Is(err, data.ErrDuplicateBarcode):
+			v.AddError("barcode", "a consumable of this brand already has this barcode")
+			app.failedValidationResponse(w, r, v.Errors)
 		default:
 			app.serverErrorResponse(w, r, err)
 		}
@@ -154,14 +270,49 @@ func (app *application) createConsumable(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// updateConsumable edits one of the user's consumables. Private consumables, and those of admins, are
+// changed straight away while edits to shared consumables are queued for an admin to review
 func (app *application) updateConsumable(w http.ResponseWriter, r *http.Request) {
+	consumableID, err := app.readIDParam(r)
+	if err != nil || consumableID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	existing, err := app.models.Consumables.GetByID(consumableID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if !existing.VisibleTo(user.ID) {
+		app.notFoundResponse(w, r)
+		return
+	}
+	if existing.CreatorID != user.ID {
+		app.forbiddenResourceResponse(w, r, errors.New("only the creator can edit the consumable"))
+		return
+	}
+
 	var consumable data.Consumable
-	err := app.readJSON(w, r, consumable)
+	err = app.readJSON(w, r, &consumable)
 	if err != nil {
 		app.badRequestResponse(w, r, err)
 		return
 	}
 
+	consumable.ID = existing.ID
+	consumable.CreatorID = existing.CreatorID
+	consumable.CreatedAt = existing.CreatedAt
+	consumable.Visibility = existing.Visibility
+
 	v := validator.New()
 	data.ValidateConsumable(v, &consumable)
 	if !v.Valid() {
@@ -169,18 +320,129 @@ func (app *application) updateConsumable(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = app.models.Consumables.Update(&consumable)
+	if existing.Visibility != data.VisibilityPrivate && !user.IsAdmin {
+		edit := &data.ConsumableEdit{EditorID: user.ID, Consumable: consumable}
+
+		err = app.models.ConsumableEdits.Insert(edit)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		err = app.writeJSON(w, http.StatusAccepted, envelope{"edit": edit}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.models.Consumables.Update(&consumable, user.ID)
 	if err != nil {
 		switch {
-		case errors.Is(err, data.ErrReferencedUserDoesNotExist):
-			app.foreignKeyViolationResponse(w, r, err)
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrDuplicateBarcode):
+			v.AddError("barcode", "a consumable of this brand already has this barcode")
+			app.failedValidationResponse(w, r, v.Errors)
 		default:
 			app.serverErrorResponse(w, r, err)
 		}
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusCreated, envelope{"consumable": consumable}, nil)
+	err = app.writeJSON(w, http.StatusOK, envelope{"consumable": consumable}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// readVisibleConsumable reads a consumable the client referred to by ID, returning data.ErrRecordNotFound
+// when it does not exist or is private to another user
+func (app *application) readVisibleConsumable(ID int64, userID int64) (*data.Consumable, error) {
+	consumable, err := app.models.Consumables.GetByID(ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !consumable.VisibleTo(userID) {
+		return nil, data.ErrRecordNotFound
+	}
+
+	return consumable, nil
+}
+
+// setConsumableVisibility makes one of the user's consumables private or shared, only admins verify
+// consumables, change those already verified or make a shared consumable private again, as edits to
+// a private consumable skip review and it could then be shared with them
+func (app *application) setConsumableVisibility(w http.ResponseWriter, r *http.Request) {
+	consumableID, err := app.readIDParam(r)
+	if err != nil || consumableID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		Visibility data.ConsumableVisibility `json:"visibility"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	v.Check(data.IsValidVisibility(input.Visibility), "visibility", "must be private, shared or verified")
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	consumable, err := app.models.Consumables.GetByID(consumableID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	switch {
+	case !consumable.VisibleTo(user.ID):
+		app.notFoundResponse(w, r)
+		return
+	case input.Visibility == data.VisibilityVerified || consumable.Visibility == data.VisibilityVerified,
+		input.Visibility == data.VisibilityPrivate && consumable.Visibility != data.VisibilityPrivate:
+		if !user.IsAdmin {
+			app.adminRequiredResponse(w, r)
+			return
+		}
+	case consumable.CreatorID != user.ID:
+		app.forbiddenResourceResponse(w, r, errors.New("only the creator can change the consumable's visibility"))
+		return
+	}
+
+	err = app.models.Consumables.SetVisibility(consumableID, input.Visibility)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"id": consumableID, "visibility": input.Visibility}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
@@ -0,0 +1,440 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/tconnellan/macro-tracker-backend/internal/assert"
+	"github.com/tconnellan/macro-tracker-backend/internal/data"
+	"github.com/tconnellan/macro-tracker-backend/internal/data/mocks"
+	"github.com/tconnellan/macro-tracker-backend/internal/jsonlog"
+)
+
+func TestGetConsumableByBarcode(t *testing.T) {
+
+	tests := []struct {
+		Name       string
+		Code       string
+		StatusCode int
+	}{
+		{
+			Name:       "valid ean-13",
+			Code:       "4006381333931",
+			StatusCode: http.StatusOK,
+		},
+		{
+			Name:       "valid gtin-14",
+			Code:       "04006381333931",
+			StatusCode: http.StatusOK,
+		},
+		{
+			Name:       "not found",
+			Code:       "96385074",
+			StatusCode: http.StatusNotFound,
+		},
+		{
+			Name:       "invalid check digit",
+			Code:       "4006381333932",
+			StatusCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+
+			app := &application{
+				logger: jsonlog.New(os.Stdout, jsonlog.LevelInfo),
+				models: mocks.NewTestModel(),
+			}
+
+			user := &data.User{ID: 1, Username: "test1", Email: "test1@gmail.com"}
+			ctx := app.testContextSetUser(context.Background(), user)
+			ctx = context.WithValue(ctx, httprouter.ParamsKey, httprouter.Params{{Key: "code", Value: tt.Code}})
+
+			rr := httptest.NewRecorder()
+
+			app.getConsumableByBarcode(rr, httptest.NewRequestWithContext(ctx, "GET", "/api/v1/consumable/barcode/"+tt.Code, nil))
+
+			assert.Equal(t, rr.Result().StatusCode, tt.StatusCode)
+		})
+	}
+}
+
+func TestSearchConsumables(t *testing.T) {
+
+	tests := []struct {
+		Name       string
+		Query      string
+		StatusCode int
+	}{
+		{
+			Name:       "default sort",
+			Query:      "?name=bananna",
+			StatusCode: http.StatusOK,
+		},
+		{
+			Name:       "least relevant first",
+			Query:      "?name=milk&brand=pauls&sort=-relevance",
+			StatusCode: http.StatusOK,
+		},
+		{
+			Name:       "unsafe sort",
+			Query:      "?name=milk&sort=carbs",
+			StatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			Name:       "page size too large",
+			Query:      "?name=milk&pagesize=1000",
+			StatusCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+
+			app := &application{
+				logger: jsonlog.New(os.Stdout, jsonlog.LevelInfo),
+				models: mocks.NewTestModel(),
+			}
+
+			user := &data.User{ID: 1, Username: "test1", Email: "test1@gmail.com"}
+			ctx := app.testContextSetUser(context.Background(), user)
+
+			rr := httptest.NewRecorder()
+
+			app.searchConsumables(rr, httptest.NewRequestWithContext(ctx, "GET", "/api/v1/consumable/search"+tt.Query, nil))
+
+			assert.Equal(t, rr.Result().StatusCode, tt.StatusCode)
+		})
+	}
+}
+
+func TestUpdateConsumable(t *testing.T) {
+
+	body := `{"name": "oats", "brand_name": "uncle tobys", "size": 100, "units": "g", "macros": {"carbs": 60}}`
+
+	tests := []struct {
+		Name       string
+		ID         string
+		IsAdmin    bool
+		Body       string
+		StatusCode int
+	}{
+		{
+			Name:       "private updated",
+			ID:         "1",
+			Body:       body,
+			StatusCode: http.StatusOK,
+		},
+		{
+			Name:       "shared edit queued",
+			ID:         "2",
+			Body:       body,
+			StatusCode: http.StatusAccepted,
+		},
+		{
+			Name:       "shared updated by admin",
+			ID:         "2",
+			IsAdmin:    true,
+			Body:       body,
+			StatusCode: http.StatusOK,
+		},
+		{
+			Name:       "not creator",
+			ID:         "3",
+			Body:       body,
+			StatusCode: http.StatusForbidden,
+		},
+		{
+			Name:       "other users private",
+			ID:         "4",
+			Body:       body,
+			StatusCode: http.StatusNotFound,
+		},
+		{
+			Name:       "invalid",
+			ID:         "1",
+			Body:       `{"name": "oats"}`,
+			StatusCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+
+			app := &application{
+				logger: jsonlog.New(os.Stdout, jsonlog.LevelInfo),
+				models: mocks.NewTestModel(),
+			}
+
+			user := &data.User{ID: 1, Username: "test1", Email: "test1@gmail.com", IsAdmin: tt.IsAdmin}
+			ctx := app.testContextSetUser(context.Background(), user)
+			ctx = context.WithValue(ctx, httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: tt.ID}})
+
+			rr := httptest.NewRecorder()
+
+			app.updateConsumable(rr, httptest.NewRequestWithContext(ctx, "PUT", "/api/v1/consumable/"+tt.ID, strings.NewReader(tt.Body)))
+
+			assert.Equal(t, rr.Result().StatusCode, tt.StatusCode)
+		})
+	}
+}
+
+func TestSetConsumableVisibility(t *testing.T) {
+
+	tests := []struct {
+		Name       string
+		ID         string
+		IsAdmin    bool
+		Body       string
+		StatusCode int
+	}{
+		{
+			Name:       "share private",
+			ID:         "1",
+			Body:       `{"visibility": "shared"}`,
+			StatusCode: http.StatusOK,
+		},
+		{
+			Name:       "verify without admin",
+			ID:         "2",
+			Body:       `{"visibility": "verified"}`,
+			StatusCode: http.StatusForbidden,
+		},
+		{
+			Name:       "verify as admin",
+			ID:         "2",
+			IsAdmin:    true,
+			Body:       `{"visibility": "verified"}`,
+			StatusCode: http.StatusOK,
+		},
+		{
+			Name:       "unverify without admin",
+			ID:         "3",
+			Body:       `{"visibility": "shared"}`,
+			StatusCode: http.StatusForbidden,
+		},
+		{
+			Name:       "unshare without admin",
+			ID:         "2",
+			Body:       `{"visibility": "private"}`,
+			StatusCode: http.StatusForbidden,
+		},
+		{
+			Name:       "unshare as admin",
+			ID:         "2",
+			IsAdmin:    true,
+			Body:       `{"visibility": "private"}`,
+			StatusCode: http.StatusOK,
+		},
+		{
+			Name:       "unverify to private without admin",
+			ID:         "3",
+			Body:       `{"visibility": "private"}`,
+			StatusCode: http.StatusForbidden,
+		},
+		{
+			Name:       "other users private",
+			ID:         "4",
+			Body:       `{"visibility": "shared"}`,
+			StatusCode: http.StatusNotFound,
+		},
+		{
+			Name:       "invalid visibility",
+			ID:         "1",
+			Body:       `{"visibility": "public"}`,
+			StatusCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+
+			app := &application{
+				logger: jsonlog.New(os.Stdout, jsonlog.LevelInfo),
+				models: mocks.NewTestModel(),
+			}
+
+			user := &data.User{ID: 1, Username: "test1", Email: "test1@gmail.com", IsAdmin: tt.IsAdmin}
+			ctx := app.testContextSetUser(context.Background(), user)
+			ctx = context.WithValue(ctx, httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: tt.ID}})
+
+			rr := httptest.NewRecorder()
+
+			app.setConsumableVisibility(rr, httptest.NewRequestWithContext(ctx, "PUT", "/api/v1/consumable/"+tt.ID+"/visibility", strings.NewReader(tt.Body)))
+
+			assert.Equal(t, rr.Result().StatusCode, tt.StatusCode)
+		})
+	}
+}
+
+func TestRequireAdmin(t *testing.T) {
+
+	tests := []struct {
+		Name       string
+		IsAdmin    bool
+		StatusCode int
+	}{
+		{
+			Name:       "admin",
+			IsAdmin:    true,
+			StatusCode: http.StatusOK,
+		},
+		{
+			Name:       "not admin",
+			IsAdmin:    false,
+			StatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+
+			app := &application{
+				logger: jsonlog.New(os.Stdout, jsonlog.LevelInfo),
+				models: mocks.NewTestModel(),
+			}
+
+			user := &data.User{ID: 1, Username: "test1", Email: "test1@gmail.com", IsAdmin: tt.IsAdmin}
+			ctx := app.testContextSetUser(context.Background(), user)
+
+			rr := httptest.NewRecorder()
+
+			app.requireAdmin(http.HandlerFunc(app.listConsumableEdits)).ServeHTTP(rr, httptest.NewRequestWithContext(ctx, "GET", "/api/v1/consumableedits", nil))
+
+			assert.Equal(t, rr.Result().StatusCode, tt.StatusCode)
+		})
+	}
+}
+
+func TestFindConsumableDuplicates(t *testing.T) {
+
+	tests := []struct {
+		Name       string
+		ID         string
+		StatusCode int
+	}{
+		{
+			Name:       "own consumable",
+			ID:         "1",
+			StatusCode: http.StatusOK,
+		},
+		{
+			Name:       "other users private",
+			ID:         "4",
+			StatusCode: http.StatusNotFound,
+		},
+		{
+			Name:       "not exist",
+			ID:         "99",
+			StatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+
+			app := &application{
+				logger: jsonlog.New(os.Stdout, jsonlog.LevelInfo),
+				models: mocks.NewTestModel(),
+			}
+
+			user := &data.User{ID: 1, Username: "test1", Email: "test1@gmail.com"}
+			ctx := app.testContextSetUser(context.Background(), user)
+			ctx = context.WithValue(ctx, httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: tt.ID}})
+
+			rr := httptest.NewRecorder()
+
+			app.findConsumableDuplicates(rr, httptest.NewRequestWithContext(ctx, "GET", "/api/v1/consumableduplicates/"+tt.ID, nil))
+
+			assert.Equal(t, rr.Result().StatusCode, tt.StatusCode)
+		})
+	}
+}
+
+func TestMergeConsumables(t *testing.T) {
+
+	tests := []struct {
+		Name       string
+		ID         string
+		IsAdmin    bool
+		Body       string
+		StatusCode int
+	}{
+		{
+			Name:       "own private into shared",
+			ID:         "2",
+			Body:       `{"duplicate_ids": [1]}`,
+			StatusCode: http.StatusOK,
+		},
+		{
+			Name:       "shared without admin",
+			ID:         "1",
+			Body:       `{"duplicate_ids": [2]}`,
+			StatusCode: http.StatusForbidden,
+		},
+		{
+			Name:       "shared as admin",
+			ID:         "3",
+			IsAdmin:    true,
+			Body:       `{"duplicate_ids": [1, 2]}`,
+			StatusCode: http.StatusOK,
+		},
+		{
+			Name:       "other users private",
+			ID:         "2",
+			IsAdmin:    true,
+			Body:       `{"duplicate_ids": [4]}`,
+			StatusCode: http.StatusNotFound,
+		},
+		{
+			Name:       "into itself",
+			ID:         "1",
+			Body:       `{"duplicate_ids": [1]}`,
+			StatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			Name:       "units cannot be converted",
+			ID:         "5",
+			Body:       `{"duplicate_ids": [1]}`,
+			StatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			Name:       "repeated",
+			ID:         "2",
+			Body:       `{"duplicate_ids": [1, 1]}`,
+			StatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			Name:       "no duplicates",
+			ID:         "2",
+			Body:       `{"duplicate_ids": []}`,
+			StatusCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+
+			app := &application{
+				logger: jsonlog.New(os.Stdout, jsonlog.LevelInfo),
+				models: mocks.NewTestModel(),
+			}
+
+			user := &data.User{ID: 1, Username: "test1", Email: "test1@gmail.com", IsAdmin: tt.IsAdmin}
+			ctx := app.testContextSetUser(context.Background(), user)
+			ctx = context.WithValue(ctx, httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: tt.ID}})
+
+			rr := httptest.NewRecorder()
+
+			app.mergeConsumables(rr, httptest.NewRequestWithContext(ctx, "POST", "/api/v1/consumable/"+tt.ID+"/merge", strings.NewReader(tt.Body)))
+
+			assert.Equal(t, rr.Result().StatusCode, tt.StatusCode)
+		})
+	}
+}
@@ -11,19 +11,38 @@ import (
 
 func (app *application) getConsumed(w http.ResponseWriter, r *http.Request) {
 
+	user := app.contextGetUser(r)
+
+	date := app.readString(r.URL.Query(), "date", "")
 	start := app.readString(r.URL.Query(), "start", "")
 	end := app.readString(r.URL.Query(), "end", "")
 
 	var consumed []*data.Consumed
 	var err error
 
-	if start == "" && end == "" {
-		consumed, err = app.models.Consumed.GetAllByUserID(app.contextGetUser(r).ID)
+	switch {
+	case date != "":
+		v := validator.New()
+		day := app.readDate(r.URL.Query(), "date", time.Now().In(user.Location()), v)
+		if !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+
+		startTime, endTime := dayRange(day)
+
+		consumed, err = app.models.Consumed.GetAllByUserIDAndDate(user.ID, startTime, endTime)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	case start == "" && end == "":
+		consumed, err = app.models.Consumed.GetAllByUserID(user.ID)
 		if err != nil {
 			app.serverErrorResponse(w, r, err)
 			return
 		}
-	} else {
+	default:
 		startTime, startErr := time.Parse(time.RFC3339, start)
 		endTime, endErr := time.Parse(time.RFC3339, end)
 		v := validator.New()
@@ -34,7 +53,7 @@ func (app *application) getConsumed(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		consumed, err = app.models.Consumed.GetAllByUserIDAndDate(app.contextGetUser(r).ID, startTime, endTime)
+		consumed, err = app.models.Consumed.GetAllByUserIDAndDate(user.ID, startTime, endTime)
 		if err != nil {
 			app.serverErrorResponse(w, r, err)
 			return
@@ -47,6 +66,142 @@ func (app *application) getConsumed(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (app *application) getConsumedSummary(w http.ResponseWriter, r *http.Request) {
+
+	user := app.contextGetUser(r)
+	loc := user.Location()
+
+	granularity := app.readString(r.URL.Query(), "granularity", "day")
+
+	v := validator.New()
+	data.ValidateSummaryGranularity(v, granularity)
+	startTime := app.readTimeBound(r.URL.Query(), "start", loc, false, v)
+	endTime := app.readTimeBound(r.URL.Query(), "end", loc, true, v)
+	if v.Valid() {
+		v.Check(!endTime.Before(startTime), "end", "must not be before start")
+	}
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	summary, err := app.models.Consumed.GetSummaryByUserID(user.ID, granularity, startTime, endTime, loc.String())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"granularity": granularity, "timezone": loc.String(), "summary": summary}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// deriveConsumedMacros sets the macros and nutrients of a consumed recipe or consumable from its nutrition and
+// the amount consumed, macros sent by the client are checked against the derived values. A consumable
+// private to another user is data.ErrRecordNotFound
+func (app *application) deriveConsumedMacros(consumed *data.Consumed, v *validator.Validator) error {
+	var derived data.Macronutrients
+	var nutrients data.NutrientPanel
+
+	switch {
+	case consumed.RecipeID != 0 && consumed.ConsumableID != 0:
+		// rejected by ValidateConsumed
+		return nil
+	case consumed.RecipeID != 0:
+		fullRecipe, err := app.models.Recipes.GetFullRecipe(consumed.RecipeID, consumed.UserID)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				return data.ErrRecipeDoesNotExist
+			default:
+				return err
+			}
+		}
+
+		nutrition, err := fullRecipe.Nutrition()
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrIncompatibleUnits):
+				v.AddError("recipe_id", "recipe has steps whose units cannot be converted")
+				return nil
+			default:
+				return err
+			}
+		}
+		fraction := consumed.Quantity
+		if consumed.Amount != 0 {
+			fraction, err = fullRecipe.FractionForWeight(consumed.Amount, consumed.Units)
+			if err != nil {
+				switch {
+				case errors.Is(err, data.ErrIncompatibleUnits):
+					v.AddError("amount", "recipe must have a cooked weight and units must be a weight")
+					return nil
+				default:
+					return err
+				}
+			}
+		}
+		derived = nutrition.Total.Macros.Scale(fraction)
+		nutrients = nutrition.Total.Nutrients.Scale(fraction)
+	case consumed.ConsumableID != 0:
+		consumable, err := app.models.Consumables.GetByID(consumed.ConsumableID)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				return data.ErrConsumableDoesNotExist
+			default:
+				return err
+			}
+		}
+		if !consumable.VisibleTo(consumed.UserID) {
+			return data.ErrRecordNotFound
+		}
+
+		amount, units := consumed.Amount, consumed.Units
+		if consumed.ServingID != 0 {
+			servingSize, err := app.models.ServingSizes.Get(consumed.ServingID)
+			if err != nil {
+				switch {
+				case errors.Is(err, data.ErrRecordNotFound):
+					return data.ErrServingSizeDoesNotExist
+				default:
+					return err
+				}
+			}
+			if servingSize.ConsumableID != consumable.ID {
+				v.AddError("serving_id", "must be a serving size of the consumable")
+				return nil
+			}
+			amount, units = consumed.Amount*servingSize.Amount, servingSize.Units
+		}
+
+		derived, err = consumable.MacrosFor(amount, units)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrIncompatibleUnits):
+				v.AddError("units", "cannot be converted to the units of the consumable")
+				return nil
+			default:
+				return err
+			}
+		}
+
+		nutrients, err = consumable.NutrientsFor(amount, units)
+		if err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+
+	data.ValidateConsumedMacros(v, consumed, derived)
+	consumed.Macros = derived
+	consumed.Nutrients = nutrients
+
+	return nil
+}
+
 func (app *application) postConsumed(w http.ResponseWriter, r *http.Request) {
 
 	var consumed data.Consumed
@@ -60,6 +215,23 @@ func (app *application) postConsumed(w http.ResponseWriter, r *http.Request) {
 	consumed.UserID = app.contextGetUser(r).ID
 
 	v := validator.New()
+	err = app.deriveConsumedMacros(&consumed, v)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecipeDoesNotExist):
+			app.foreignKeyViolationResponse(w, r, err)
+		case errors.Is(err, data.ErrConsumableDoesNotExist):
+			app.foreignKeyViolationResponse(w, r, err)
+		case errors.Is(err, data.ErrServingSizeDoesNotExist):
+			app.foreignKeyViolationResponse(w, r, err)
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
 	data.ValidateConsumed(v, &consumed)
 	if !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
@@ -73,6 +245,10 @@ func (app *application) postConsumed(w http.ResponseWriter, r *http.Request) {
 			app.foreignKeyViolationResponse(w, r, err)
 		case errors.Is(err, data.ErrRecipeDoesNotExist):
 			app.foreignKeyViolationResponse(w, r, err)
+		case errors.Is(err, data.ErrConsumableDoesNotExist):
+			app.foreignKeyViolationResponse(w, r, err)
+		case errors.Is(err, data.ErrServingSizeDoesNotExist):
+			app.foreignKeyViolationResponse(w, r, err)
 		default:
 			app.serverErrorResponse(w, r, err)
 		}
@@ -98,6 +274,23 @@ func (app *application) updateConsumed(w http.ResponseWriter, r *http.Request) {
 	consumed.UserID = app.contextGetUser(r).ID
 
 	v := validator.New()
+	err = app.deriveConsumedMacros(&consumed, v)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecipeDoesNotExist):
+			app.foreignKeyViolationResponse(w, r, err)
+		case errors.Is(err, data.ErrConsumableDoesNotExist):
+			app.foreignKeyViolationResponse(w, r, err)
+		case errors.Is(err, data.ErrServingSizeDoesNotExist):
+			app.foreignKeyViolationResponse(w, r, err)
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
 	data.ValidateConsumed(v, &consumed)
 	if !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
@@ -113,6 +306,10 @@ func (app *application) updateConsumed(w http.ResponseWriter, r *http.Request) {
 			app.foreignKeyViolationResponse(w, r, err)
 		case errors.Is(err, data.ErrRecipeDoesNotExist):
 			app.foreignKeyViolationResponse(w, r, err)
+		case errors.Is(err, data.ErrConsumableDoesNotExist):
+			app.foreignKeyViolationResponse(w, r, err)
+		case errors.Is(err, data.ErrServingSizeDoesNotExist):
+			app.foreignKeyViolationResponse(w, r, err)
 		default:
 			app.serverErrorResponse(w, r, err)
 		}
@@ -239,6 +239,222 @@ func TestPostConsumed(t *testing.T) {
 			"notes": ""
 		}`,
 		},
+		{
+			Name:       "valid without macros",
+			StatusCode: http.StatusCreated,
+			User: &data.User{
+				ID:       1,
+				Username: "test1",
+				Email:    "test1@gmail.com",
+			},
+			Body: `{
+			"user_id": 1,
+			"recipe_id": 1,
+			"quantity": 2,
+			"consumed_at": "2024-01-01T10:00:00Z",
+			"notes": ""
+		}`,
+		},
+		{
+			Name:       "macros disagree with recipe",
+			StatusCode: http.StatusUnprocessableEntity,
+			User: &data.User{
+				ID:       1,
+				Username: "test1",
+				Email:    "test1@gmail.com",
+			},
+			Body: `{
+			"user_id": 1,
+			"recipe_id": 1,
+			"quantity": 1,
+			"macros": {
+				"carbs": 50,
+				"fats": 1,
+				"proteins": 1,
+				"alcohol": 1
+			},
+			"consumed_at": "2024-01-01T10:00:00Z",
+			"notes": ""
+		}`,
+		},
+		{
+			Name:       "unknown nutrient",
+			StatusCode: http.StatusUnprocessableEntity,
+			User: &data.User{
+				ID:       1,
+				Username: "test1",
+				Email:    "test1@gmail.com",
+			},
+			Body: `{
+			"user_id": 1,
+			"consumable_id": 1,
+			"amount": 150,
+			"units": "g",
+			"nutrients": {"fiber": 3},
+			"consumed_at": "2024-01-01T10:00:00Z",
+			"notes": ""
+		}`,
+		},
+		{
+			Name:       "other users private consumable",
+			StatusCode: http.StatusNotFound,
+			User: &data.User{
+				ID:       1,
+				Username: "test1",
+				Email:    "test1@gmail.com",
+			},
+			Body: `{
+			"user_id": 1,
+			"consumable_id": 4,
+			"amount": 150,
+			"units": "g",
+			"consumed_at": "2024-01-01T10:00:00Z",
+			"notes": ""
+		}`,
+		},
+		{
+			Name:       "valid consumable",
+			StatusCode: http.StatusCreated,
+			User: &data.User{
+				ID:       1,
+				Username: "test1",
+				Email:    "test1@gmail.com",
+			},
+			Body: `{
+			"user_id": 1,
+			"consumable_id": 1,
+			"amount": 150,
+			"units": "g",
+			"consumed_at": "2024-01-01T10:00:00Z",
+			"notes": ""
+		}`,
+		},
+		{
+			Name:       "valid consumable serving",
+			StatusCode: http.StatusCreated,
+			User: &data.User{
+				ID:       1,
+				Username: "test1",
+				Email:    "test1@gmail.com",
+			},
+			Body: `{
+			"user_id": 1,
+			"consumable_id": 1,
+			"serving_id": 1,
+			"amount": 2,
+			"consumed_at": "2024-01-01T10:00:00Z",
+			"notes": ""
+		}`,
+		},
+		{
+			Name:       "serving of other consumable",
+			StatusCode: http.StatusUnprocessableEntity,
+			User: &data.User{
+				ID:       1,
+				Username: "test1",
+				Email:    "test1@gmail.com",
+			},
+			Body: `{
+			"user_id": 1,
+			"consumable_id": 1,
+			"serving_id": 2,
+			"amount": 2,
+			"consumed_at": "2024-01-01T10:00:00Z",
+			"notes": ""
+		}`,
+		},
+		{
+			Name:       "consumable units mismatch",
+			StatusCode: http.StatusUnprocessableEntity,
+			User: &data.User{
+				ID:       1,
+				Username: "test1",
+				Email:    "test1@gmail.com",
+			},
+			Body: `{
+			"user_id": 1,
+			"consumable_id": 1,
+			"amount": 150,
+			"units": "ml",
+			"consumed_at": "2024-01-01T10:00:00Z",
+			"notes": ""
+		}`,
+		},
+		{
+			Name:       "valid recipe by cooked weight",
+			StatusCode: http.StatusCreated,
+			User: &data.User{
+				ID:       1,
+				Username: "test1",
+				Email:    "test1@gmail.com",
+			},
+			Body: `{
+			"user_id": 1,
+			"recipe_id": 1,
+			"amount": 100,
+			"units": "g",
+			"macros": {
+				"carbs": 0.5,
+				"fats": 0.5,
+				"proteins": 0.5,
+				"alcohol": 0.5
+			},
+			"consumed_at": "2024-01-01T10:00:00Z",
+			"notes": ""
+		}`,
+		},
+		{
+			Name:       "recipe by volume",
+			StatusCode: http.StatusUnprocessableEntity,
+			User: &data.User{
+				ID:       1,
+				Username: "test1",
+				Email:    "test1@gmail.com",
+			},
+			Body: `{
+			"user_id": 1,
+			"recipe_id": 1,
+			"amount": 100,
+			"units": "ml",
+			"consumed_at": "2024-01-01T10:00:00Z",
+			"notes": ""
+		}`,
+		},
+		{
+			Name:       "recipe and consumable",
+			StatusCode: http.StatusUnprocessableEntity,
+			User: &data.User{
+				ID:       1,
+				Username: "test1",
+				Email:    "test1@gmail.com",
+			},
+			Body: `{
+			"user_id": 1,
+			"recipe_id": 1,
+			"consumable_id": 1,
+			"quantity": 1,
+			"amount": 150,
+			"units": "g",
+			"consumed_at": "2024-01-01T10:00:00Z",
+			"notes": ""
+		}`,
+		},
+		{
+			Name:       "recipe does not exist",
+			StatusCode: http.StatusConflict,
+			User: &data.User{
+				ID:       1,
+				Username: "test1",
+				Email:    "test1@gmail.com",
+			},
+			Body: `{
+			"user_id": 1,
+			"recipe_id": 99,
+			"quantity": 1,
+			"consumed_at": "2024-01-01T10:00:00Z",
+			"notes": ""
+		}`,
+		},
 	}
 
 	for _, tt := range tests {
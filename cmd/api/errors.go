@@ -1,8 +1,11 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+
+	"github.com/tconnellan/macro-tracker-backend/internal/data"
 )
 
 func (app *application) logError(r *http.Request, err error) {
@@ -41,6 +44,12 @@ func (app *application) methodNotAllowedResponse(w http.ResponseWriter, r *http.
 }
 
 func (app *application) badRequestResponse(w http.ResponseWriter, r *http.Request, err error) {
+	// a misspelt nutrient decodes as well-formed JSON, so report it as a validation failure
+	if errors.Is(err, data.ErrUnknownNutrient) {
+		app.failedValidationResponse(w, r, map[string]string{"nutrients": err.Error()})
+		return
+	}
+
 	app.errorResponse(w, r, http.StatusBadRequest, err.Error())
 }
 
@@ -66,6 +75,11 @@ func (app *application) authenticationRequiredResponse(w http.ResponseWriter, r
 	app.errorResponse(w, r, http.StatusUnauthorized, message)
 }
 
+func (app *application) adminRequiredResponse(w http.ResponseWriter, r *http.Request) {
+	message := "your user account must be an admin to access this resource"
+	app.errorResponse(w, r, http.StatusForbidden, message)
+}
+
 func (app *application) failedValidationResponse(w http.ResponseWriter, r *http.Request, errors map[string]string) {
 	app.errorResponse(w, r, http.StatusUnprocessableEntity, errors)
 }
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/tconnellan/macro-tracker-backend/internal/data"
+	"github.com/tconnellan/macro-tracker-backend/internal/validator"
+)
+
+func (app *application) getGoal(w http.ResponseWriter, r *http.Request) {
+
+	goal, err := app.models.Goals.GetByUserID(app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"goal": goal}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) putGoal(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Macros   data.Macronutrients `json:"macros"`
+		EnergyKJ float64             `json:"energy_kj"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	goal := &data.Goal{
+		UserID:   app.contextGetUser(r).ID,
+		Macros:   input.Macros,
+		EnergyKJ: input.EnergyKJ,
+	}
+
+	v := validator.New()
+	data.ValidateGoal(v, goal)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Goals.Upsert(goal)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrReferencedUserDoesNotExist):
+			app.foreignKeyViolationResponse(w, r, err)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"goal": goal}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) getGoalProgress(w http.ResponseWriter, r *http.Request) {
+
+	user := app.contextGetUser(r)
+	userID := user.ID
+
+	v := validator.New()
+
+	date := app.readDate(r.URL.Query(), "date", time.Now().In(user.Location()), v)
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	goal, err := app.models.Goals.GetByUserID(userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	start, end := dayRange(date)
+
+	consumed, err := app.models.Consumed.GetAllByUserIDAndDate(userID, start, end)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	progress := data.CalculateGoalProgress(goal, consumed)
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"date": start.Format(time.DateOnly), "progress": progress}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/tconnellan/macro-tracker-backend/internal/validator"
@@ -132,6 +133,22 @@ func (app *application) readInt(qs url.Values, key string, defaultValue int, v *
 	return i
 }
 
+func (app *application) readFloat(qs url.Values, key string, defaultValue float64, v *validator.Validator) float64 {
+	s := qs.Get(key)
+
+	if s == "" {
+		return defaultValue
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		v.AddError(key, "must be a number")
+		return defaultValue
+	}
+
+	return f
+}
+
 func (app *application) readBool(qs url.Values, key string, defaultValue bool, v *validator.Validator) bool {
 	s := qs.Get(key)
 
@@ -148,6 +165,53 @@ func (app *application) readBool(qs url.Values, key string, defaultValue bool, v
 	return i
 }
 
+// readDate reads a calendar day in the form YYYY-MM-DD, the day is placed in the location of defaultValue
+func (app *application) readDate(qs url.Values, key string, defaultValue time.Time, v *validator.Validator) time.Time {
+	s := qs.Get(key)
+
+	if s == "" {
+		return defaultValue
+	}
+
+	d, err := time.ParseInLocation(time.DateOnly, s, defaultValue.Location())
+	if err != nil {
+		v.AddError(key, "format must be YYYY-MM-DD")
+		return defaultValue
+	}
+
+	return d
+}
+
+// dayRange returns the first and last instant of the given day, the end is inclusive
+// to the microsecond precision postgres stores timestamps with
+func dayRange(day time.Time) (time.Time, time.Time) {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	end := start.AddDate(0, 0, 1).Add(-time.Microsecond)
+	return start, end
+}
+
+// readTimeBound reads either an RFC3339 timestamp or a YYYY-MM-DD day in loc, a day resolves to
+// its first instant, or its last instant when endOfDay is set so that the whole day is included
+func (app *application) readTimeBound(qs url.Values, key string, loc *time.Location, endOfDay bool, v *validator.Validator) time.Time {
+	s := qs.Get(key)
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t
+	}
+
+	d, err := time.ParseInLocation(time.DateOnly, s, loc)
+	if err != nil {
+		v.AddError(key, "format must be RFC3339 or YYYY-MM-DD")
+		return time.Time{}
+	}
+
+	start, end := dayRange(d)
+	if endOfDay {
+		return end
+	}
+	return start
+}
+
 func (app *application) background(fn func()) {
 
 	go func() {
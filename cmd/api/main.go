@@ -5,6 +5,7 @@ import (
 	"flag"
 	"os"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	_ "github.com/lib/pq"
@@ -138,6 +138,19 @@ func (app *application) requireUserAuthentication(next http.Handler) http.Handle
 	})
 }
 
+// requireAdmin must follow requireUserAuthentication
+func (app *application) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		if !app.contextGetUser(r).IsAdmin {
+			app.adminRequiredResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 var DEFAULT_SECURITY_HEADERS = map[string]string{
 	"Content-Security-Policy": "default-src 'self'; style-src 'self' fonts.googleapis.com; font-src fonts.gstatic.com",
 	"Referer-Policy":          "origin-when-cross-origin",
@@ -0,0 +1,15 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/tconnellan/macro-tracker-backend/internal/data"
+)
+
+// listNutrients returns the nutrients that may be recorded in a nutrient panel
+func (app *application) listNutrients(w http.ResponseWriter, r *http.Request) {
+	err := app.writeJSON(w, http.StatusOK, envelope{"nutrients": data.Nutrients}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -43,6 +43,20 @@ func (app *application) createPantryItem(w http.ResponseWriter, r *http.Request)
 		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
+
+	consumable, err := app.readVisibleConsumable(pantryItem.ConsumableId, pantryItem.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	// an ID merged into another consumable resolves to it
+	pantryItem.ConsumableId = consumable.ID
+
 	err = app.models.PantryItems.Create(&pantryItem)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
@@ -72,6 +86,20 @@ func (app *application) updatePantryItem(w http.ResponseWriter, r *http.Request)
 		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
+
+	consumable, err := app.readVisibleConsumable(pantryItem.ConsumableId, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	// an ID merged into another consumable resolves to it
+	pantryItem.ConsumableId = consumable.ID
+
 	err = app.models.PantryItems.Update(&pantryItem)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
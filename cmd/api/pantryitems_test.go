@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/tconnellan/macro-tracker-backend/internal/assert"
+	"github.com/tconnellan/macro-tracker-backend/internal/data"
+	"github.com/tconnellan/macro-tracker-backend/internal/data/mocks"
+	"github.com/tconnellan/macro-tracker-backend/internal/jsonlog"
+)
+
+func TestCreatePantryItem(t *testing.T) {
+
+	tests := []struct {
+		Name       string
+		Body       string
+		StatusCode int
+	}{
+		{
+			Name:       "own private consumable",
+			Body:       `{"consumable_id": 1, "name": "oats"}`,
+			StatusCode: http.StatusCreated,
+		},
+		{
+			Name:       "verified consumable",
+			Body:       `{"consumable_id": 3, "name": "yogurt"}`,
+			StatusCode: http.StatusCreated,
+		},
+		{
+			Name:       "other users private consumable",
+			Body:       `{"consumable_id": 4, "name": "milk"}`,
+			StatusCode: http.StatusNotFound,
+		},
+		{
+			Name:       "consumable does not exist",
+			Body:       `{"consumable_id": 99, "name": "bread"}`,
+			StatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+
+			app := &application{
+				logger: jsonlog.New(os.Stdout, jsonlog.LevelInfo),
+				models: mocks.NewTestModel(),
+			}
+
+			user := &data.User{ID: 1, Username: "test1", Email: "test1@gmail.com"}
+			ctx := app.testContextSetUser(context.Background(), user)
+
+			rr := httptest.NewRecorder()
+
+			app.createPantryItem(rr, httptest.NewRequestWithContext(ctx, "POST", "/api/v1/pantryitems", strings.NewReader(tt.Body)))
+
+			assert.Equal(t, rr.Result().StatusCode, tt.StatusCode)
+		})
+	}
+}
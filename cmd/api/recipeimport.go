@@ -0,0 +1,174 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/tconnellan/macro-tracker-backend/internal/data"
+	"github.com/tconnellan/macro-tracker-backend/internal/recipeimport"
+	"github.com/tconnellan/macro-tracker-backend/internal/validator"
+)
+
+// importRecipe reads a schema.org Recipe from the body, a JSON-LD document or the HTML page of a recipe
+// website, into a draft recipe. Ingredients are matched to the user's pantry items and then to
+// consumables. Steps whose amount cannot be recorded, such as "2 cloves garlic" or "salt to taste", are
+// drafted with no quantity and listed in needs_amount. Nothing is saved, the draft is confirmed by
+// posting it to /api/v1/recipes
+func (app *application) importRecipe(w http.ResponseWriter, r *http.Request) {
+	maxBytes := 2_097_152
+	r.Body = http.MaxBytesReader(w, r.Body, int64(maxBytes))
+
+	content, err := io.ReadAll(r.Body)
+	if err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("body must not be larger than %d bytes", maxBytes))
+		return
+	}
+
+	imported, err := recipeimport.Parse(content)
+	if err != nil {
+		switch {
+		case errors.Is(err, recipeimport.ErrNoRecipe):
+			v := validator.New()
+			v.AddError("body", "must contain a schema.org Recipe")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.badRequestResponse(w, r, err)
+		}
+		return
+	}
+
+	userID := app.contextGetUser(r).ID
+
+	pantryItems, err := app.models.PantryItems.GetAllByUserID(userID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	draft := RecipeStepsResponse{
+		Recipe: data.Recipe{
+			Name:      imported.Name,
+			CreatorID: userID,
+			Notes:     importedNotes(imported),
+			IsLatest:  true,
+			Servings:  imported.Servings,
+		},
+		RecipeSteps: []RecipeStep{},
+	}
+	unmatched := []int64{}
+	needsAmount := []int64{}
+
+	for i, line := range imported.Ingredients {
+		ingredient := recipeimport.ParseIngredient(line)
+
+		step := RecipeStep{
+			RecipeComponent: data.RecipeComponent{
+				StepNo:          int64(i + 1),
+				StepDescription: line,
+			},
+		}
+
+		if ingredient.Measured() {
+			step.RecipeComponent.Quantity = ingredient.Quantity
+			step.RecipeComponent.Units = data.MeasurementUnit(ingredient.Units)
+		} else {
+			needsAmount = append(needsAmount, step.RecipeComponent.StepNo)
+		}
+
+		pantryItem, consumable, err := app.matchIngredient(ingredient.Name, userID, pantryItems)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		if pantryItem == nil {
+			unmatched = append(unmatched, step.RecipeComponent.StepNo)
+		} else {
+			step.RecipeComponent.PantryItemID = pantryItem.ID
+			step.PantryItem = *pantryItem
+			step.Consumable = *consumable
+		}
+
+		draft.RecipeSteps = append(draft.RecipeSteps, step)
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"draft": draft, "unmatched_steps": unmatched, "needs_amount": needsAmount}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// matchIngredient finds the pantry item for an ingredient name, first among the user's pantry items and
+// then by searching consumables. A consumable found by search gets a pantry item with a zero id, which
+// has to be created before the draft is saved. The pantry item is nil when nothing matches
+func (app *application) matchIngredient(name string, userID int64, pantryItems []*data.PantryItem) (*data.PantryItem, *data.Consumable, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, nil, nil
+	}
+
+	pantryNames := make([]string, len(pantryItems))
+	for i, pantryItem := range pantryItems {
+		pantryNames[i] = pantryItem.Name
+	}
+
+	if i := recipeimport.MatchName(name, pantryNames); i >= 0 {
+		consumable, err := app.models.Consumables.GetByID(pantryItems[i].ConsumableId)
+		if err != nil {
+			return nil, nil, err
+		}
+		return pantryItems[i], consumable, nil
+	}
+
+	filters := data.ConsumableFilters{
+		Metadata: data.MetadataFilters{
+			Page:         1,
+			PageSize:     10,
+			Sort:         "relevance",
+			SortSafeList: []string{"relevance"},
+		},
+		NameSearch: name,
+	}
+
+	consumables, _, err := app.models.Consumables.Search(userID, filters)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(consumables) == 0 {
+		return nil, nil, nil
+	}
+
+	consumableNames := make([]string, len(consumables))
+	for i, consumable := range consumables {
+		consumableNames[i] = consumable.Name
+	}
+
+	// results may only be similar to the name, the most relevant is used when none match closer
+	consumable := &consumables[max(recipeimport.MatchName(name, consumableNames), 0)].Consumable
+
+	pantryName := name
+	if len(pantryName) > 50 {
+		pantryName = consumable.Name
+	}
+
+	return &data.PantryItem{UserID: userID, ConsumableId: consumable.ID, Name: pantryName}, consumable, nil
+}
+
+// importedNotes keeps the imported description and method as the recipe's notes
+func importedNotes(imported *recipeimport.Recipe) string {
+	var notes strings.Builder
+
+	notes.WriteString(imported.Description)
+	for i, instruction := range imported.Instructions {
+		if notes.Len() > 0 {
+			notes.WriteString("\n")
+		}
+		if i == 0 && imported.Description != "" {
+			notes.WriteString("\n")
+		}
+		fmt.Fprintf(&notes, "%d. %s", i+1, instruction)
+	}
+
+	return notes.String()
+}
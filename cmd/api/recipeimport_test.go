@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/tconnellan/macro-tracker-backend/internal/assert"
+	"github.com/tconnellan/macro-tracker-backend/internal/data/mocks"
+	"github.com/tconnellan/macro-tracker-backend/internal/jsonlog"
+)
+
+func TestImportRecipe(t *testing.T) {
+
+	tests := []struct {
+		Name              string
+		Body              string
+		StatusCode        int
+		ExpectNeedsAmount []int64
+		ExpectQuantities  []float64
+	}{
+		{
+			Name:              "valid",
+			Body:              `{"@type": "Recipe", "name": "Garlic Bread", "recipeIngredient": ["500g bread", "2 cloves garlic", "salt to taste"]}`,
+			StatusCode:        http.StatusOK,
+			ExpectNeedsAmount: []int64{2, 3},
+			ExpectQuantities:  []float64{500, 0, 0},
+		},
+		{
+			Name:       "invalid no recipe",
+			Body:       `{"@type": "Person", "name": "Chef"}`,
+			StatusCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+
+			app := &application{
+				logger: jsonlog.New(os.Stdout, jsonlog.LevelInfo),
+				models: mocks.NewTestModel(),
+			}
+
+			rr := httptest.NewRecorder()
+
+			app.importRecipe(rr, newRecipeRequest(app, "POST", "/api/v1/recipeimport", "", tt.Body))
+
+			rs := rr.Result()
+			assert.Equal(t, rs.StatusCode, tt.StatusCode)
+			if tt.StatusCode != http.StatusOK {
+				return
+			}
+
+			body, err := io.ReadAll(rs.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var response struct {
+				Draft       RecipeStepsResponse `json:"draft"`
+				NeedsAmount []int64             `json:"needs_amount"`
+			}
+			err = json.Unmarshal(body, &response)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, len(response.NeedsAmount), len(tt.ExpectNeedsAmount))
+			for i := range tt.ExpectNeedsAmount {
+				assert.Equal(t, response.NeedsAmount[i], tt.ExpectNeedsAmount[i])
+			}
+
+			assert.Equal(t, len(response.Draft.RecipeSteps), len(tt.ExpectQuantities))
+			for i := range tt.ExpectQuantities {
+				assert.Equal(t, response.Draft.RecipeSteps[i].RecipeComponent.Quantity, tt.ExpectQuantities[i])
+			}
+		})
+	}
+}
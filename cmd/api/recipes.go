@@ -8,6 +8,7 @@ import (
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/tconnellan/macro-tracker-backend/internal/data"
+	"github.com/tconnellan/macro-tracker-backend/internal/recipeexport"
 	"github.com/tconnellan/macro-tracker-backend/internal/validator"
 )
 
@@ -15,44 +16,59 @@ type RecipeStep struct {
 	RecipeComponent data.RecipeComponent `json:"recipe_step"`
 	PantryItem      data.PantryItem      `json:"pantry_item"`
 	Consumable      data.Consumable      `json:"consumable"`
+	ServingSize     *data.ServingSize    `json:"serving_size,omitempty"`
+	SubRecipe       *data.FullRecipe     `json:"sub_recipe,omitempty"`
+	// Nutrition is the step's contribution to the recipe
+	Nutrition *data.NutritionFacts `json:"nutrition,omitempty"`
 }
 
 type RecipeStepsResponse struct {
 	Recipe      data.Recipe  `json:"recipe"`
 	RecipeSteps []RecipeStep `json:"recipe_steps"`
+	// nutrition is left out when a step's units cannot be converted
+	Total      *data.NutritionFacts `json:"total,omitempty"`
+	PerServing *data.NutritionFacts `json:"per_serving,omitempty"`
 }
 
+// listRecipes searches the user's recipes by name, ingredient, tags and nutrition per serving
 func (app *application) listRecipes(w http.ResponseWriter, r *http.Request) {
 
 	v := validator.New()
+	qs := r.URL.Query()
 
-	latest := app.readBool(r.URL.Query(), "latest", false, v)
-	page := app.readInt(r.URL.Query(), "page", 1, v)
-	pagesize := app.readInt(r.URL.Query(), "pagesize ", 1000, v)
+	filters := data.RecipeFilters{
+		Metadata: data.MetadataFilters{
+			Page:     app.readInt(qs, "page", 1, v),
+			PageSize: app.readInt(qs, "pagesize", data.MaxRecipePageSize, v),
+			Sort:     app.readString(qs, "sort", "id"),
+			SortSafeList: []string{
+				"id", "name", "created_at", "last_edited_at", "energy",
+				"-id", "-name", "-created_at", "-last_edited_at", "-energy",
+			},
+		},
+		NameSearch:   app.readString(qs, "search", ""),
+		LatestOnly:   app.readBool(qs, "latest", false, v),
+		ConsumableID: int64(app.readInt(qs, "consumable_id", 0, v)),
+		PantryItemID: int64(app.readInt(qs, "pantry_item_id", 0, v)),
+		MinProtein:   app.readFloat(qs, "min_protein", 0, v),
+		MaxProtein:   app.readFloat(qs, "max_protein", 0, v),
+		MinEnergy:    app.readFloat(qs, "min_energy", 0, v),
+		MaxEnergy:    app.readFloat(qs, "max_energy", 0, v),
+		Tags:         app.readCSV(qs, "tags", []string{}),
+	}
 
 	if !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
-	var recipes []*data.Recipe
-	filters := data.RecipeFilters{
-		Metadata: data.MetadataFilters{
-			Page:         page,
-			PageSize:     pagesize,
-			Sort:         "ID",
-			SortSafeList: []string{"ID"},
-		},
-		NameSearch: "",
+	data.ValidateRecipeFilters(v, filters)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
 	}
-	var metadata data.Metadata
-	var err error
 
-	if latest {
-		recipes, metadata, err = app.models.Recipes.GetLatestByCreatorID(app.contextGetUser(r).ID, filters)
-	} else {
-		recipes, metadata, err = app.models.Recipes.GetByCreatorID(app.contextGetUser(r).ID, filters)
-	}
+	recipes, metadata, err := app.models.Recipes.Search(app.contextGetUser(r).ID, filters)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
@@ -87,6 +103,11 @@ func (app *application) getRecipe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	app.writeRecipeSteps(w, r, fullRecipes)
+}
+
+// writeRecipeSteps responds with the recipe laid out as steps, along with its nutrition
+func (app *application) writeRecipeSteps(w http.ResponseWriter, r *http.Request, fullRecipes *data.FullRecipe) {
 	recipeSteps := RecipeStepsResponse{
 		Recipe:      fullRecipes.Recipe,
 		RecipeSteps: []RecipeStep{},
@@ -99,9 +120,26 @@ func (app *application) getRecipe(w http.ResponseWriter, r *http.Request) {
 			RecipeComponent: *recipeComponent,
 			PantryItem:      *pantryItem,
 			Consumable:      *consumable,
+			ServingSize:     fullRecipes.ServingSize(recipeComponent.ServingID),
+			SubRecipe:       fullRecipes.SubRecipe(recipeComponent.SubRecipeID),
 		})
 	}
 
+	nutrition, err := fullRecipes.Nutrition()
+	switch {
+	case err == nil:
+		for i := range recipeSteps.RecipeSteps {
+			recipeSteps.RecipeSteps[i].Nutrition = &nutrition.Steps[i]
+		}
+		recipeSteps.Total = &nutrition.Total
+		recipeSteps.PerServing = &nutrition.PerServing
+	case errors.Is(err, data.ErrIncompatibleUnits), errors.Is(err, data.ErrServingSizeDoesNotExist), errors.Is(err, data.ErrSubRecipeDoesNotExist):
+		// the steps are still returned, only without nutrition
+	default:
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
 	err = app.writeJSON(w, http.StatusOK, envelope{"recipesteps": recipeSteps}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
@@ -124,10 +162,23 @@ func (app *application) createChildRecipe(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	// the version replaced is the one in the route, any ID in the body is ignored
+	fullRecipe.Recipe.ID = 0
 	fullRecipe.Recipe.CreatorID = app.contextGetUser(r).ID
 	fullRecipe.Recipe.ParentRecipeID = int64(parentId)
 
 	v := validator.New()
+	err = app.readStepServingSizes(v, &fullRecipe)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrPantryItemDoesNotExist), errors.Is(err, data.ErrServingSizeDoesNotExist):
+			app.foreignKeyViolationResponse(w, r, err)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
 	data.ValidateFullRecipe(v, &fullRecipe)
 	if !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
@@ -145,6 +196,10 @@ func (app *application) createChildRecipe(w http.ResponseWriter, r *http.Request
 			app.notFoundResponse(w, r)
 		case errors.Is(err, data.ErrPantryItemDoesNotExist):
 			app.foreignKeyViolationResponse(w, r, err)
+		case errors.Is(err, data.ErrServingSizeDoesNotExist):
+			app.foreignKeyViolationResponse(w, r, err)
+		case errors.Is(err, data.ErrSubRecipeDoesNotExist):
+			app.foreignKeyViolationResponse(w, r, err)
 		default:
 			app.serverErrorResponse(w, r, err)
 		}
@@ -157,6 +212,47 @@ func (app *application) createChildRecipe(w http.ResponseWriter, r *http.Request
 	}
 }
 
+// readStepServingSizes replaces the serving sizes sent with a recipe by those stored for its steps,
+// adding a validation error for a serving size that is not of the consumable of its step's pantry item
+func (app *application) readStepServingSizes(v *validator.Validator, fullRecipe *data.FullRecipe) error {
+	servingSizes := []*data.ServingSize{}
+
+	for _, component := range fullRecipe.RecipeComponents {
+		if component.ServingID == 0 || component.SubRecipeID != 0 {
+			continue
+		}
+
+		servingSize, err := app.models.ServingSizes.Get(component.ServingID)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				return data.ErrServingSizeDoesNotExist
+			default:
+				return err
+			}
+		}
+
+		pantryItem, err := app.models.PantryItems.Get(component.PantryItemID)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				return data.ErrPantryItemDoesNotExist
+			default:
+				return err
+			}
+		}
+
+		if servingSize.ConsumableID != pantryItem.ConsumableId {
+			v.AddError("serving_id", fmt.Sprintf("serving size of step %d must belong to its consumable", component.StepNo))
+			continue
+		}
+		servingSizes = append(servingSizes, servingSize)
+	}
+
+	fullRecipe.ServingSizes = servingSizes
+	return nil
+}
+
 func (app *application) createNewRecipe(w http.ResponseWriter, r *http.Request) {
 
 	var recipePayload RecipeStepsResponse
@@ -170,10 +266,14 @@ func (app *application) createNewRecipe(w http.ResponseWriter, r *http.Request)
 	var components []*data.RecipeComponent
 	var consumables []*data.Consumable
 	var pantryItems []*data.PantryItem
+	var servingSizes []*data.ServingSize
 	for _, val := range recipePayload.RecipeSteps {
 		components = append(components, &val.RecipeComponent)
 		consumables = append(consumables, &val.Consumable)
 		pantryItems = append(pantryItems, &val.PantryItem)
+		if val.ServingSize != nil {
+			servingSizes = append(servingSizes, val.ServingSize)
+		}
 	}
 
 	var fullRecipe = data.FullRecipe{
@@ -181,12 +281,27 @@ func (app *application) createNewRecipe(w http.ResponseWriter, r *http.Request)
 		RecipeComponents: components,
 		PantryItems:      pantryItems,
 		Consumables:      consumables,
+		ServingSizes:     servingSizes,
 	}
 
 	fullRecipe.Recipe.CreatorID = app.contextGetUser(r).ID
 	fullRecipe.Recipe.ParentRecipeID = 0
+	fullRecipe.Recipe.ForkedFromID = 0
+	// recipes are shared through setRecipeVisibility, which checks their sub-recipes are public
+	fullRecipe.Recipe.IsPublic = false
 
 	v := validator.New()
+	err = app.readStepServingSizes(v, &fullRecipe)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrPantryItemDoesNotExist), errors.Is(err, data.ErrServingSizeDoesNotExist):
+			app.foreignKeyViolationResponse(w, r, err)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
 	data.ValidateFullRecipe(v, &fullRecipe)
 	if !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
@@ -203,6 +318,10 @@ func (app *application) createNewRecipe(w http.ResponseWriter, r *http.Request)
 			app.foreignKeyViolationResponse(w, r, err)
 		case errors.Is(err, data.ErrPantryItemDoesNotExist):
 			app.foreignKeyViolationResponse(w, r, err)
+		case errors.Is(err, data.ErrServingSizeDoesNotExist):
+			app.foreignKeyViolationResponse(w, r, err)
+		case errors.Is(err, data.ErrSubRecipeDoesNotExist):
+			app.foreignKeyViolationResponse(w, r, err)
 		case errors.Is(err, data.ErrParentRecipeDoesNotExist):
 			app.foreignKeyViolationResponse(w, r, err)
 		default:
@@ -306,3 +425,483 @@ func (app *application) getAncestors(w http.ResponseWriter, r *http.Request) {
 		app.serverErrorResponse(w, r, err)
 	}
 }
+
+// readScaledRecipe reads the recipe in the id parameter scaled by either the factor or servings query
+// parameter, on failure a response has been written and ok is false
+func (app *application) readScaledRecipe(w http.ResponseWriter, r *http.Request) (scaled *data.FullRecipe, factor float64, ok bool) {
+	recipeID, err := app.readIDParam(r)
+	if err != nil || recipeID < 1 {
+		app.notFoundResponse(w, r)
+		return nil, 0, false
+	}
+
+	v := validator.New()
+
+	factor = app.readFloat(r.URL.Query(), "factor", 0, v)
+	servings := app.readInt(r.URL.Query(), "servings", 0, v)
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return nil, 0, false
+	}
+
+	v.Check((factor != 0) != (servings != 0), "factor", "must provide exactly one of factor or servings")
+	v.Check(factor >= 0, "factor", "must be positive")
+	v.Check(servings >= 0, "servings", "must be positive")
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return nil, 0, false
+	}
+
+	fullRecipe, err := app.models.Recipes.GetFullRecipe(recipeID, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, 0, false
+	}
+
+	if servings != 0 {
+		v.Check(fullRecipe.Recipe.Servings > 0, "servings", "recipe does not have servings to scale from")
+		if !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return nil, 0, false
+		}
+		factor = float64(servings) / float64(fullRecipe.Recipe.Servings)
+	}
+
+	scaled, err = fullRecipe.Scaled(factor)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrFractionalServings):
+			v.AddError("factor", fmt.Sprintf("must scale the recipe's %d servings to a whole number of servings", fullRecipe.Recipe.Servings))
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, 0, false
+	}
+
+	return scaled, factor, true
+}
+
+// getScaledRecipe returns a recipe scaled by a factor or to a number of servings without saving it
+func (app *application) getScaledRecipe(w http.ResponseWriter, r *http.Request) {
+	scaled, factor, ok := app.readScaledRecipe(w, r)
+	if !ok {
+		return
+	}
+
+	var nutrition *data.RecipeNutrition
+	recipeNutrition, err := scaled.Nutrition()
+	switch {
+	case err == nil:
+		nutrition = &recipeNutrition
+	case errors.Is(err, data.ErrIncompatibleUnits), errors.Is(err, data.ErrServingSizeDoesNotExist), errors.Is(err, data.ErrSubRecipeDoesNotExist):
+		// the scaled recipe is still returned, only without nutrition
+	default:
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"fullRecipe": scaled, "factor": factor, "nutrition": nutrition}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// saveScaledRecipe saves a scaled recipe as a new child version of the recipe
+func (app *application) saveScaledRecipe(w http.ResponseWriter, r *http.Request) {
+	scaled, _, ok := app.readScaledRecipe(w, r)
+	if !ok {
+		return
+	}
+
+	scaled.Recipe.ParentRecipeID = scaled.Recipe.ID
+
+	v := validator.New()
+	data.ValidateFullRecipe(v, scaled)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err := app.models.Recipes.UpdateFullRecipe(scaled)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrPantryItemDoesNotExist):
+			app.foreignKeyViolationResponse(w, r, err)
+		case errors.Is(err, data.ErrServingSizeDoesNotExist):
+			app.foreignKeyViolationResponse(w, r, err)
+		case errors.Is(err, data.ErrSubRecipeDoesNotExist):
+			app.foreignKeyViolationResponse(w, r, err)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"fullRecipe": scaled}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// getRecipeDiff compares two versions of a recipe, both must belong to the user
+func (app *application) getRecipeDiff(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	recipeID, err := strconv.ParseInt(params.ByName("id"), 10, 64)
+	if err != nil || recipeID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+	otherID, err := strconv.ParseInt(params.ByName("otherId"), 10, 64)
+	if err != nil || otherID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	versions := make([]*data.FullRecipe, 0, 2)
+	for _, ID := range []int64{recipeID, otherID} {
+		fullRecipe, err := app.models.Recipes.GetFullRecipe(ID, app.contextGetUser(r).ID)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+		versions = append(versions, fullRecipe)
+	}
+
+	sameLineage, err := app.models.Recipes.SameLineage(recipeID, otherID, app.contextGetUser(r).ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if !sameLineage {
+		v := validator.New()
+		v.AddError("otherId", "must be a version of the same recipe")
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	diff, err := data.DiffFullRecipes(versions[0], versions[1])
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"diff": diff}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// getRecipeTree returns every version of the recipe, its ancestors and descendants
+func (app *application) getRecipeTree(w http.ResponseWriter, r *http.Request) {
+	recipeID, err := app.readIDParam(r)
+	if err != nil || recipeID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	tree, err := app.models.Recipes.GetVersionTree(recipeID, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"tree": tree}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// revertRecipe copies the version in the id parameter into a new child of the recipe's current version
+func (app *application) revertRecipe(w http.ResponseWriter, r *http.Request) {
+	recipeID, err := app.readIDParam(r)
+	if err != nil || recipeID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	userID := app.contextGetUser(r).ID
+
+	tree, err := app.models.Recipes.GetVersionTree(recipeID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	v := validator.New()
+	v.Check(tree.CurrentID != recipeID, "id", "is already the current version")
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	versions := make([]*data.FullRecipe, 0, 2)
+	for _, ID := range []int64{recipeID, tree.CurrentID} {
+		fullRecipe, err := app.models.Recipes.GetFullRecipe(ID, userID)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+		versions = append(versions, fullRecipe)
+	}
+
+	reverted := versions[1].RevertedTo(versions[0])
+
+	data.ValidateFullRecipe(v, reverted)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Recipes.UpdateFullRecipe(reverted)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrPantryItemDoesNotExist):
+			app.foreignKeyViolationResponse(w, r, err)
+		case errors.Is(err, data.ErrServingSizeDoesNotExist):
+			app.foreignKeyViolationResponse(w, r, err)
+		case errors.Is(err, data.ErrSubRecipeDoesNotExist):
+			app.foreignKeyViolationResponse(w, r, err)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"fullRecipe": reverted}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// listPublicRecipes returns the latest version of every public recipe, optionally matching ?search= by name
+func (app *application) listPublicRecipes(w http.ResponseWriter, r *http.Request) {
+
+	v := validator.New()
+
+	search := app.readString(r.URL.Query(), "search", "")
+	page := app.readInt(r.URL.Query(), "page", 1, v)
+	pagesize := app.readInt(r.URL.Query(), "pagesize", 20, v)
+
+	filters := data.RecipeFilters{
+		Metadata: data.MetadataFilters{
+			Page:         page,
+			PageSize:     pagesize,
+			Sort:         "ID",
+			SortSafeList: []string{"ID"},
+		},
+		NameSearch: search,
+	}
+
+	data.ValidateMetadataFilters(v, filters.Metadata)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	recipes, metadata, err := app.models.Recipes.GetPublic(filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"recipes": recipes, "metadata": metadata}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// getPublicRecipe returns a public recipe of any user laid out as steps
+func (app *application) getPublicRecipe(w http.ResponseWriter, r *http.Request) {
+	recipeID, err := app.readIDParam(r)
+	if err != nil || recipeID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	fullRecipe, err := app.models.Recipes.GetPublicFullRecipe(recipeID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	app.writeRecipeSteps(w, r, fullRecipe)
+}
+
+// setRecipeVisibility makes every version of one of the user's recipes public or private, a recipe using
+// sub-recipes can only be made public once they are
+func (app *application) setRecipeVisibility(w http.ResponseWriter, r *http.Request) {
+	recipeID, err := app.readIDParam(r)
+	if err != nil || recipeID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		IsPublic *bool `json:"is_public"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	v.Check(input.IsPublic != nil, "is_public", "must be provided")
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Recipes.SetVisibility(recipeID, app.contextGetUser(r).ID, *input.IsPublic)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrSubRecipeNotPublic):
+			v.AddError("is_public", "sub-recipes used by the recipe must be made public first")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"id": recipeID, "is_public": *input.IsPublic}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// forkRecipe copies a public recipe, and the sub-recipes it uses, into the user's account
+func (app *application) forkRecipe(w http.ResponseWriter, r *http.Request) {
+	recipeID, err := app.readIDParam(r)
+	if err != nil || recipeID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	fork, err := app.models.Recipes.ForkFullRecipe(recipeID, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrSubRecipeDoesNotExist):
+			app.foreignKeyViolationResponse(w, r, err)
+		case errors.Is(err, data.ErrRecipeCycle):
+			app.foreignKeyViolationResponse(w, r, err)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"fullRecipe": fork}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// exportRecipe renders a recipe as a card to print or share, ?format= is markdown, text or jsonld. The
+// card leaves out nutrition when a step's units cannot be converted
+func (app *application) exportRecipe(w http.ResponseWriter, r *http.Request) {
+	recipeID, err := app.readIDParam(r)
+	if err != nil || recipeID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	v := validator.New()
+
+	format := app.readString(r.URL.Query(), "format", "markdown")
+	v.Check(validator.In(format, "markdown", "text", "jsonld"), "format", "must be markdown, text or jsonld")
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	fullRecipe, err := app.models.Recipes.GetFullRecipe(recipeID, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	var nutrition *data.RecipeNutrition
+	recipeNutrition, err := fullRecipe.Nutrition()
+	switch {
+	case err == nil:
+		nutrition = &recipeNutrition
+	case errors.Is(err, data.ErrIncompatibleUnits), errors.Is(err, data.ErrServingSizeDoesNotExist), errors.Is(err, data.ErrSubRecipeDoesNotExist):
+		// the card is still exported, only without nutrition
+	default:
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	card := recipeexport.NewCard(fullRecipe, nutrition)
+
+	var body []byte
+	switch format {
+	case "markdown":
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		body = card.Markdown()
+	case "text":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		body = card.Text()
+	case "jsonld":
+		body, err = card.JSONLD()
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/ld+json")
+		body = append(body, '\n')
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
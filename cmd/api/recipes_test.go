@@ -0,0 +1,366 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/tconnellan/macro-tracker-backend/internal/assert"
+	"github.com/tconnellan/macro-tracker-backend/internal/data"
+	"github.com/tconnellan/macro-tracker-backend/internal/data/mocks"
+	"github.com/tconnellan/macro-tracker-backend/internal/jsonlog"
+	"github.com/tconnellan/macro-tracker-backend/internal/validator"
+)
+
+// newRecipeRequest builds a request to a recipe handler with the user and id route parameter set
+func newRecipeRequest(app *application, method string, target string, id string, body string) *http.Request {
+	user := &data.User{ID: 1, Username: "test1", Email: "test1@gmail.com"}
+
+	ctx := app.testContextSetUser(context.Background(), user)
+	ctx = context.WithValue(ctx, httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: id}})
+
+	return httptest.NewRequestWithContext(ctx, method, target, strings.NewReader(body))
+}
+
+func TestListPublicRecipes(t *testing.T) {
+
+	tests := []struct {
+		Name       string
+		Query      string
+		StatusCode int
+	}{
+		{
+			Name:       "valid",
+			Query:      "?search=lasagne",
+			StatusCode: http.StatusOK,
+		},
+		{
+			Name:       "invalid page",
+			Query:      "?page=0",
+			StatusCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+
+			app := &application{
+				logger: jsonlog.New(os.Stdout, jsonlog.LevelInfo),
+				models: mocks.NewTestModel(),
+			}
+
+			rr := httptest.NewRecorder()
+
+			app.listPublicRecipes(rr, newRecipeRequest(app, "GET", "/api/v1/public/recipes"+tt.Query, "", ""))
+
+			assert.Equal(t, rr.Result().StatusCode, tt.StatusCode)
+		})
+	}
+}
+
+func TestGetPublicRecipe(t *testing.T) {
+
+	tests := []struct {
+		Name       string
+		ID         string
+		StatusCode int
+	}{
+		{
+			Name:       "valid",
+			ID:         "1",
+			StatusCode: http.StatusOK,
+		},
+		{
+			Name:       "private recipe",
+			ID:         "2",
+			StatusCode: http.StatusNotFound,
+		},
+		{
+			Name:       "invalid id",
+			ID:         "abc",
+			StatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+
+			app := &application{
+				logger: jsonlog.New(os.Stdout, jsonlog.LevelInfo),
+				models: mocks.NewTestModel(),
+			}
+
+			rr := httptest.NewRecorder()
+
+			app.getPublicRecipe(rr, newRecipeRequest(app, "GET", "/api/v1/public/recipes/"+tt.ID, tt.ID, ""))
+
+			rs := rr.Result()
+			defer rs.Body.Close()
+
+			assert.Equal(t, rs.StatusCode, tt.StatusCode)
+			if rs.StatusCode != http.StatusOK {
+				return
+			}
+
+			body, err := io.ReadAll(rs.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var response struct {
+				RecipeSteps RecipeStepsResponse `json:"recipesteps"`
+			}
+			err = json.Unmarshal(body, &response)
+			assert.NilError(t, err)
+			assert.Equal(t, response.RecipeSteps.Recipe.IsPublic, true)
+			assert.Equal(t, len(response.RecipeSteps.RecipeSteps), 1)
+		})
+	}
+}
+
+func TestSetRecipeVisibility(t *testing.T) {
+
+	tests := []struct {
+		Name       string
+		ID         string
+		Body       string
+		StatusCode int
+	}{
+		{
+			Name:       "valid make public",
+			ID:         "1",
+			Body:       `{"is_public": true}`,
+			StatusCode: http.StatusOK,
+		},
+		{
+			Name:       "valid make private",
+			ID:         "2",
+			Body:       `{"is_public": false}`,
+			StatusCode: http.StatusOK,
+		},
+		{
+			Name:       "private sub-recipe",
+			ID:         "2",
+			Body:       `{"is_public": true}`,
+			StatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			Name:       "missing is_public",
+			ID:         "1",
+			Body:       `{}`,
+			StatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			Name:       "other users recipe",
+			ID:         "3",
+			Body:       `{"is_public": true}`,
+			StatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+
+			app := &application{
+				logger: jsonlog.New(os.Stdout, jsonlog.LevelInfo),
+				models: mocks.NewTestModel(),
+			}
+
+			rr := httptest.NewRecorder()
+
+			app.setRecipeVisibility(rr, newRecipeRequest(app, "PUT", "/api/v1/recipes/"+tt.ID+"/visibility", tt.ID, tt.Body))
+
+			assert.Equal(t, rr.Result().StatusCode, tt.StatusCode)
+		})
+	}
+}
+
+func TestForkRecipe(t *testing.T) {
+
+	tests := []struct {
+		Name       string
+		ID         string
+		StatusCode int
+	}{
+		{
+			Name:       "valid",
+			ID:         "1",
+			StatusCode: http.StatusCreated,
+		},
+		{
+			Name:       "private recipe",
+			ID:         "2",
+			StatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+
+			app := &application{
+				logger: jsonlog.New(os.Stdout, jsonlog.LevelInfo),
+				models: mocks.NewTestModel(),
+			}
+
+			rr := httptest.NewRecorder()
+
+			app.forkRecipe(rr, newRecipeRequest(app, "POST", "/api/v1/recipes/"+tt.ID+"/fork", tt.ID, ""))
+
+			rs := rr.Result()
+			defer rs.Body.Close()
+
+			assert.Equal(t, rs.StatusCode, tt.StatusCode)
+			if rs.StatusCode != http.StatusCreated {
+				return
+			}
+
+			body, err := io.ReadAll(rs.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var response struct {
+				FullRecipe data.FullRecipe `json:"fullRecipe"`
+			}
+			err = json.Unmarshal(body, &response)
+			assert.NilError(t, err)
+			assert.Equal(t, response.FullRecipe.Recipe.ForkedFromID, 1)
+			assert.Equal(t, response.FullRecipe.Recipe.IsPublic, false)
+		})
+	}
+}
+
+func TestExportRecipe(t *testing.T) {
+
+	tests := []struct {
+		Name              string
+		ID                string
+		Query             string
+		StatusCode        int
+		ExpectContentType string
+		ExpectBody        string
+	}{
+		{
+			Name:              "valid markdown",
+			ID:                "1",
+			Query:             "",
+			StatusCode:        http.StatusOK,
+			ExpectContentType: "text/markdown; charset=utf-8",
+			ExpectBody:        "# recipe\n",
+		},
+		{
+			Name:              "valid text",
+			ID:                "1",
+			Query:             "?format=text",
+			StatusCode:        http.StatusOK,
+			ExpectContentType: "text/plain; charset=utf-8",
+			ExpectBody:        "100 g pantry item",
+		},
+		{
+			Name:              "valid jsonld",
+			ID:                "1",
+			Query:             "?format=jsonld",
+			StatusCode:        http.StatusOK,
+			ExpectContentType: "application/ld+json",
+			ExpectBody:        `"@type": "NutritionInformation"`,
+		},
+		{
+			Name:       "invalid format",
+			ID:         "1",
+			Query:      "?format=pdf",
+			StatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			Name:       "not found",
+			ID:         "2",
+			Query:      "",
+			StatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+
+			app := &application{
+				logger: jsonlog.New(os.Stdout, jsonlog.LevelInfo),
+				models: mocks.NewTestModel(),
+			}
+
+			rr := httptest.NewRecorder()
+
+			app.exportRecipe(rr, newRecipeRequest(app, "GET", "/api/v1/recipes/"+tt.ID+"/export"+tt.Query, tt.ID, ""))
+
+			rs := rr.Result()
+			assert.Equal(t, rs.StatusCode, tt.StatusCode)
+			if tt.StatusCode != http.StatusOK {
+				return
+			}
+
+			body, err := io.ReadAll(rs.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, rs.Header.Get("Content-Type"), tt.ExpectContentType)
+			assert.Equal(t, strings.Contains(string(body), tt.ExpectBody), true)
+		})
+	}
+}
+
+func TestReadStepServingSizes(t *testing.T) {
+
+	tests := []struct {
+		Name         string
+		PantryItemID int64
+		ServingID    int64
+		ExpectValid  bool
+		ExpectError  error
+	}{
+		{
+			Name:         "serving of the consumable",
+			PantryItemID: 1,
+			ServingID:    1,
+			ExpectValid:  true,
+		},
+		{
+			Name:         "serving of another consumable",
+			PantryItemID: 1,
+			ServingID:    2,
+			ExpectValid:  false,
+		},
+		{
+			Name:         "serving does not exist",
+			PantryItemID: 1,
+			ServingID:    99,
+			ExpectValid:  true,
+			ExpectError:  data.ErrServingSizeDoesNotExist,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+
+			app := &application{
+				logger: jsonlog.New(os.Stdout, jsonlog.LevelInfo),
+				models: mocks.NewTestModel(),
+			}
+
+			// the serving size sent with the recipe claims to be of consumable 1 and is ignored
+			fullRecipe := data.FullRecipe{
+				RecipeComponents: []*data.RecipeComponent{{PantryItemID: tt.PantryItemID, ServingID: tt.ServingID, StepNo: 1}},
+				ServingSizes:     []*data.ServingSize{{ID: tt.ServingID, ConsumableID: 1, Amount: 1, Units: "g"}},
+			}
+
+			v := validator.New()
+			err := app.readStepServingSizes(v, &fullRecipe)
+			assert.ExpectError(t, err, tt.ExpectError)
+			assert.Equal(t, v.Valid(), tt.ExpectValid)
+		})
+	}
+}
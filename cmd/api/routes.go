@@ -17,14 +17,19 @@ func (app *application) routes() http.Handler {
 
 	protectedMiddleware := dynamicMiddleware.Append(app.requireUserAuthentication) // .Append(noSurf)
 
+	adminMiddleware := protectedMiddleware.Append(app.requireAdmin)
+
 	router.NotFound = http.HandlerFunc(app.notFoundResponse)
 
 	router.MethodNotAllowed = http.HandlerFunc(app.methodNotAllowedResponse)
 
 	router.Handler(http.MethodPost, "/api/v1/users", dynamicMiddleware.ThenFunc(app.registerUserHandler))
 	router.Handler(http.MethodPost, "/api/v1/users/login", dynamicMiddleware.ThenFunc(app.UserLoginHandler))
+	router.Handler(http.MethodPut, "/api/v1/users/timezone", protectedMiddleware.ThenFunc(app.updateUserTimezone))
+	router.Handler(http.MethodOptions, "/api/v1/users/timezone", standardMiddleware.Then(app.respondCors(nil)))
 
 	router.Handler(http.MethodGet, "/api/v1/consumed", protectedMiddleware.ThenFunc(app.getConsumed))
+	router.Handler(http.MethodGet, "/api/v1/consumed/summary", protectedMiddleware.ThenFunc(app.getConsumedSummary))
 	router.Handler(http.MethodPost, "/api/v1/consumed", protectedMiddleware.ThenFunc(app.postConsumed))
 	router.Handler(http.MethodPut, "/api/v1/consumed", protectedMiddleware.ThenFunc(app.updateConsumed))
 	router.Handler(http.MethodDelete, "/api/v1/consumed/:id", protectedMiddleware.ThenFunc(app.deleteConsumed))
@@ -38,16 +43,75 @@ func (app *application) routes() http.Handler {
 	// allow update of modifiable parts of step
 	router.Handler(http.MethodPut, "/api/v1/recipes/:id/step", protectedMiddleware.ThenFunc(app.updateStep))
 	router.Handler(http.MethodGet, "/api/v1/recipes/:id/ancestors", protectedMiddleware.ThenFunc(app.getAncestors))
+	// revert copies an earlier version into a new child of the current version
+	router.Handler(http.MethodPost, "/api/v1/recipes/:id/revert", protectedMiddleware.ThenFunc(app.revertRecipe))
+	router.Handler(http.MethodGet, "/api/v1/recipes/:id/tree", protectedMiddleware.ThenFunc(app.getRecipeTree))
+	router.Handler(http.MethodGet, "/api/v1/recipes/:id/diff/:otherId", protectedMiddleware.ThenFunc(app.getRecipeDiff))
+	// scale a recipe by ?factor= or to ?servings=, posting saves the result as a child recipe
+	router.Handler(http.MethodGet, "/api/v1/recipes/:id/scaled", protectedMiddleware.ThenFunc(app.getScaledRecipe))
+	router.Handler(http.MethodPost, "/api/v1/recipes/:id/scaled", protectedMiddleware.ThenFunc(app.saveScaledRecipe))
+	router.Handler(http.MethodPut, "/api/v1/recipes/:id/visibility", protectedMiddleware.ThenFunc(app.setRecipeVisibility))
+	// fork copies another user's public recipe into the user's account
+	router.Handler(http.MethodPost, "/api/v1/recipes/:id/fork", protectedMiddleware.ThenFunc(app.forkRecipe))
+	router.Handler(http.MethodGet, "/api/v1/recipes/:id/tags", protectedMiddleware.ThenFunc(app.getRecipeTags))
+	// export renders a recipe card as ?format=markdown, text or jsonld
+	router.Handler(http.MethodGet, "/api/v1/recipes/:id/export", protectedMiddleware.ThenFunc(app.exportRecipe))
 	router.Handler(http.MethodOptions, "/api/v1/recipes", standardMiddleware.Then(app.respondCors(nil)))
 
+	// import reads a recipe from a website into a draft to confirm, not under /recipes/import as that
+	// would clash with /recipes/:id
+	router.Handler(http.MethodPost, "/api/v1/recipeimport", protectedMiddleware.ThenFunc(app.importRecipe))
+	router.Handler(http.MethodOptions, "/api/v1/recipeimport", standardMiddleware.Then(app.respondCors(nil)))
+
+	// recipe tags, not under /recipes/tags as that would clash with /recipes/:id
+	router.Handler(http.MethodGet, "/api/v1/recipetags", protectedMiddleware.ThenFunc(app.listTags))
+	router.Handler(http.MethodPost, "/api/v1/recipetags", protectedMiddleware.ThenFunc(app.createTag))
+	router.Handler(http.MethodPut, "/api/v1/recipetags/:id", protectedMiddleware.ThenFunc(app.updateTag))
+	router.Handler(http.MethodDelete, "/api/v1/recipetags/:id", protectedMiddleware.ThenFunc(app.deleteTag))
+	// tags apply to every version of the recipe
+	router.Handler(http.MethodPost, "/api/v1/recipetags/:id/recipes/:recipeId", protectedMiddleware.ThenFunc(app.tagRecipe))
+	router.Handler(http.MethodDelete, "/api/v1/recipetags/:id/recipes/:recipeId", protectedMiddleware.ThenFunc(app.untagRecipe))
+	router.Handler(http.MethodOptions, "/api/v1/recipetags", standardMiddleware.Then(app.respondCors(nil)))
+
+	// collections
+	router.Handler(http.MethodGet, "/api/v1/collections", protectedMiddleware.ThenFunc(app.listCollections))
+	router.Handler(http.MethodGet, "/api/v1/collections/:id", protectedMiddleware.ThenFunc(app.getCollection))
+	router.Handler(http.MethodPost, "/api/v1/collections", protectedMiddleware.ThenFunc(app.createCollection))
+	router.Handler(http.MethodPut, "/api/v1/collections/:id", protectedMiddleware.ThenFunc(app.updateCollection))
+	router.Handler(http.MethodDelete, "/api/v1/collections/:id", protectedMiddleware.ThenFunc(app.deleteCollection))
+	router.Handler(http.MethodPost, "/api/v1/collections/:id/recipes/:recipeId", protectedMiddleware.ThenFunc(app.addCollectionRecipe))
+	router.Handler(http.MethodDelete, "/api/v1/collections/:id/recipes/:recipeId", protectedMiddleware.ThenFunc(app.removeCollectionRecipe))
+	router.Handler(http.MethodOptions, "/api/v1/collections", standardMiddleware.Then(app.respondCors(nil)))
+
+	// public recipes of every user
+	router.Handler(http.MethodGet, "/api/v1/public/recipes", protectedMiddleware.ThenFunc(app.listPublicRecipes))
+	router.Handler(http.MethodGet, "/api/v1/public/recipes/:id", protectedMiddleware.ThenFunc(app.getPublicRecipe))
+
 	// consumables
 	router.Handler(http.MethodGet, "/api/v1/consumable/personal", protectedMiddleware.ThenFunc(app.getUserConsumables))
 	// router.Handler(http.MethodGet, "/api/v1/consumable/:id", protectedMiddleware.ThenFunc(app.getConsumable))
 	router.Handler(http.MethodGet, "/api/v1/consumable/search", protectedMiddleware.ThenFunc(app.searchConsumables))
+	router.Handler(http.MethodGet, "/api/v1/consumable/barcode/:code", protectedMiddleware.ThenFunc(app.getConsumableByBarcode))
 	router.Handler(http.MethodPost, "/api/v1/consumable", protectedMiddleware.ThenFunc(app.createConsumable))
+	// edits to shared consumables are queued for review unless made by an admin
 	router.Handler(http.MethodPut, "/api/v1/consumable/:id", protectedMiddleware.ThenFunc(app.updateConsumable))
+	router.Handler(http.MethodPut, "/api/v1/consumable/:id/visibility", protectedMiddleware.ThenFunc(app.setConsumableVisibility))
+	router.Handler(http.MethodPost, "/api/v1/consumable/:id/merge", protectedMiddleware.ThenFunc(app.mergeConsumables))
+	router.Handler(http.MethodPost, "/api/v1/consumable/:id/servings", protectedMiddleware.ThenFunc(app.createServingSize))
+	router.Handler(http.MethodDelete, "/api/v1/consumable/:id/servings/:servingId", protectedMiddleware.ThenFunc(app.deleteServingSize))
 	router.Handler(http.MethodOptions, "/api/v1/consumable", standardMiddleware.Then(app.respondCors(nil)))
 
+	// admin review of edits to shared consumables, not under /consumable/edits as that would clash with
+	// /consumable/:id
+	router.Handler(http.MethodGet, "/api/v1/consumableedits", adminMiddleware.ThenFunc(app.listConsumableEdits))
+	router.Handler(http.MethodPost, "/api/v1/consumableedits/:id/approve", adminMiddleware.ThenFunc(app.approveConsumableEdit))
+	router.Handler(http.MethodPost, "/api/v1/consumableedits/:id/reject", adminMiddleware.ThenFunc(app.rejectConsumableEdit))
+
+	// likely duplicates of a consumable, a separate root for the same reason as /consumableedits
+	router.Handler(http.MethodGet, "/api/v1/consumableduplicates/:id", protectedMiddleware.ThenFunc(app.findConsumableDuplicates))
+
+	router.Handler(http.MethodGet, "/api/v1/nutrients", protectedMiddleware.ThenFunc(app.listNutrients))
+
 	// pantry items
 	router.Handler(http.MethodGet, "/api/v1/pantryitems", protectedMiddleware.ThenFunc(app.getPantryItems))
 	router.Handler(http.MethodPost, "/api/v1/pantryitems", protectedMiddleware.ThenFunc(app.createPantryItem))
@@ -55,5 +119,11 @@ func (app *application) routes() http.Handler {
 	router.Handler(http.MethodDelete, "/api/v1/pantryitems/:id", protectedMiddleware.ThenFunc(app.deletePantryItem))
 	router.Handler(http.MethodOptions, "/api/v1/pantryitems", standardMiddleware.Then(app.respondCors(nil)))
 
+	// goals
+	router.Handler(http.MethodGet, "/api/v1/goals", protectedMiddleware.ThenFunc(app.getGoal))
+	router.Handler(http.MethodPut, "/api/v1/goals", protectedMiddleware.ThenFunc(app.putGoal))
+	router.Handler(http.MethodGet, "/api/v1/goals/progress", protectedMiddleware.ThenFunc(app.getGoalProgress))
+	router.Handler(http.MethodOptions, "/api/v1/goals", standardMiddleware.Then(app.respondCors(nil)))
+
 	return standardMiddleware.Then(router)
 }
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/tconnellan/macro-tracker-backend/internal/data"
+	"github.com/tconnellan/macro-tracker-backend/internal/validator"
+)
+
+func (app *application) createServingSize(w http.ResponseWriter, r *http.Request) {
+	consumableID, err := app.readIDParam(r)
+	if err != nil || consumableID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		Name   string               `json:"name"`
+		Amount float64              `json:"amount"`
+		Units  data.MeasurementUnit `json:"units"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	servingSize := &data.ServingSize{
+		ConsumableID: consumableID,
+		Name:         input.Name,
+		Amount:       input.Amount,
+		Units:        input.Units,
+	}
+
+	v := validator.New()
+	data.ValidateServingSize(v, servingSize)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.ServingSizes.Insert(servingSize, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrConsumableDoesNotExist):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"serving_size": servingSize}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteServingSize(w http.ResponseWriter, r *http.Request) {
+	consumableID, err := app.readIDParam(r)
+	if err != nil || consumableID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	params := httprouter.ParamsFromContext(r.Context())
+	servingSizeID, err := strconv.ParseInt(params.ByName("servingId"), 10, 64)
+	if err != nil || servingSizeID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.ServingSizes.Delete(servingSizeID, consumableID, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrServingSizeInUse):
+			app.foreignKeyViolationResponse(w, r, err)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusNoContent, nil, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
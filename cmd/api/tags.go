@@ -0,0 +1,233 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/tconnellan/macro-tracker-backend/internal/data"
+	"github.com/tconnellan/macro-tracker-backend/internal/validator"
+)
+
+func (app *application) listTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := app.models.Tags.GetAllByUserID(app.contextGetUser(r).ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"tags": tags}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// getRecipeTags returns the tags on the lineage of the recipe in the id parameter
+func (app *application) getRecipeTags(w http.ResponseWriter, r *http.Request) {
+	recipeID, err := app.readIDParam(r)
+	if err != nil || recipeID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	tags, err := app.models.Tags.GetByRecipeID(recipeID, app.contextGetUser(r).ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"tags": tags}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) createTag(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Name string `json:"name"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	tag := &data.Tag{
+		UserID: app.contextGetUser(r).ID,
+		Name:   input.Name,
+	}
+
+	v := validator.New()
+	data.ValidateTag(v, tag)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Tags.Insert(tag)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateTag):
+			v.AddError("name", "a tag with this name already exists")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrReferencedUserDoesNotExist):
+			app.foreignKeyViolationResponse(w, r, err)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"tag": tag}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateTag(w http.ResponseWriter, r *http.Request) {
+	tagID, err := app.readIDParam(r)
+	if err != nil || tagID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		Name string `json:"name"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	tag := &data.Tag{
+		ID:     tagID,
+		UserID: app.contextGetUser(r).ID,
+		Name:   input.Name,
+	}
+
+	v := validator.New()
+	data.ValidateTag(v, tag)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Tags.Update(tag)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrDuplicateTag):
+			v.AddError("name", "a tag with this name already exists")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"tag": tag}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteTag(w http.ResponseWriter, r *http.Request) {
+	tagID, err := app.readIDParam(r)
+	if err != nil || tagID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Tags.Delete(tagID, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusNoContent, nil, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// readRecipeIDParam reads the recipeId parameter of routes linking a recipe to a tag or collection
+func (app *application) readRecipeIDParam(r *http.Request) (int64, error) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	recipeID, err := strconv.ParseInt(params.ByName("recipeId"), 10, 64)
+	if err != nil || recipeID < 1 {
+		return 0, errors.New("invalid recipe id parameter")
+	}
+
+	return recipeID, nil
+}
+
+// tagRecipe tags the lineage of the recipe in the recipeId parameter
+func (app *application) tagRecipe(w http.ResponseWriter, r *http.Request) {
+	tagID, err := app.readIDParam(r)
+	if err != nil || tagID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+	recipeID, err := app.readRecipeIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Tags.AddRecipe(tagID, recipeID, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusNoContent, nil, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// untagRecipe takes the tag off the lineage of the recipe in the recipeId parameter
+func (app *application) untagRecipe(w http.ResponseWriter, r *http.Request) {
+	tagID, err := app.readIDParam(r)
+	if err != nil || tagID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+	recipeID, err := app.readRecipeIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Tags.RemoveRecipe(tagID, recipeID, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusNoContent, nil, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -14,6 +14,7 @@ func (app *application) registerUserHandler(w http.ResponseWriter, r *http.Reque
 		Username string `json:"username"`
 		Email    string `json:"email"`
 		Password string `json:"password"`
+		Timezone string `json:"timezone"`
 	}
 
 	err := app.readJSON(w, r, &input)
@@ -25,6 +26,9 @@ func (app *application) registerUserHandler(w http.ResponseWriter, r *http.Reque
 	v := validator.New()
 	data.ValidatePasswordPlaintext(v, input.Password)
 	data.ValidateEmail(v, input.Email)
+	if input.Timezone != "" {
+		data.ValidateTimezone(v, input.Timezone)
+	}
 
 	if !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
@@ -34,6 +38,7 @@ func (app *application) registerUserHandler(w http.ResponseWriter, r *http.Reque
 	user := &data.User{
 		Username: input.Username,
 		Email:    input.Email,
+		Timezone: input.Timezone,
 	}
 
 	err = user.Password.Set(input.Password)
@@ -112,3 +117,41 @@ func (app *application) UserLoginHandler(w http.ResponseWriter, r *http.Request)
 		app.serverErrorResponse(w, r, err)
 	}
 }
+
+func (app *application) updateUserTimezone(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Timezone string `json:"timezone"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	data.ValidateTimezone(v, input.Timezone)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	user := app.contextGetUser(r)
+	user.Timezone = input.Timezone
+
+	err = app.models.Users.Update(user)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"user": user}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,284 @@
+// Command importer seeds consumables from a nutrition database dump on disk, an Open Food Facts CSV or
+// JSONL export or a USDA FoodData Central JSON download. Foods are loaded in batches through COPY and
+// deduplicated on barcode or on name and brand. Every food that is not stored is written to a CSV
+// report with the reason. Imported consumables are shared with every user unless -visibility says
+// otherwise.
+//
+//	importer -file en.openfoodfacts.org.products.csv -creator 1
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tconnellan/macro-tracker-backend/internal/data"
+	"github.com/tconnellan/macro-tracker-backend/internal/foodimport"
+	"github.com/tconnellan/macro-tracker-backend/internal/jsonlog"
+)
+
+type config struct {
+	dsn        string
+	file       string
+	format     string
+	creatorID  int64
+	visibility string
+	batchSize  int
+	rejects    string
+}
+
+func main() {
+	var cfg config
+
+	flag.StringVar(&cfg.dsn, "db-dsn", os.Getenv("MACROTRACKER_DB_DSN"), "PostgreSQL DSN")
+	flag.StringVar(&cfg.file, "file", "", "Dump to import")
+	flag.StringVar(&cfg.format, "format", "", "Dump format (off-csv|off-jsonl|fdc-json), guessed from the file extension when not given")
+	flag.Int64Var(&cfg.creatorID, "creator", 0, "ID of the user the imported consumables are created by")
+	flag.StringVar(&cfg.visibility, "visibility", string(data.VisibilityShared), "Visibility of the imported consumables (private|shared|verified)")
+	flag.IntVar(&cfg.batchSize, "batch-size", 5000, "Foods loaded per COPY")
+	flag.StringVar(&cfg.rejects, "rejects", "rejects.csv", "CSV report of the foods not imported")
+
+	flag.Parse()
+
+	logger := jsonlog.New(os.Stdout, jsonlog.LevelInfo)
+
+	summary, err := run(cfg, logger)
+	if err != nil {
+		logger.PrintFatal(err, nil)
+	}
+
+	logger.PrintInfo("import finished", map[string]string{
+		"read":       strconv.Itoa(summary.read),
+		"inserted":   strconv.Itoa(summary.inserted),
+		"duplicates": strconv.Itoa(summary.duplicates),
+		"rejected":   strconv.Itoa(summary.rejected),
+		"rejects":    cfg.rejects,
+	})
+}
+
+func run(cfg config, logger *jsonlog.Logger) (summary, error) {
+	if cfg.file == "" {
+		return summary{}, errors.New("-file must be provided")
+	}
+	if cfg.creatorID < 1 {
+		return summary{}, errors.New("-creator must be the ID of a user")
+	}
+	if !data.IsValidVisibility(data.ConsumableVisibility(cfg.visibility)) {
+		return summary{}, errors.New("-visibility must be private, shared or verified")
+	}
+	if cfg.batchSize < 1 {
+		return summary{}, errors.New("-batch-size must be positive")
+	}
+
+	format := foodimport.Format(cfg.format)
+	if format == "" {
+		guessed, err := foodimport.FormatOf(cfg.file)
+		if err != nil {
+			return summary{}, fmt.Errorf("%w, give -format", err)
+		}
+		format = guessed
+	}
+
+	file, err := os.Open(cfg.file)
+	if err != nil {
+		return summary{}, err
+	}
+	defer file.Close()
+
+	reader, err := foodimport.NewReader(format, file)
+	if err != nil {
+		return summary{}, err
+	}
+
+	db, err := openDB(cfg.dsn)
+	if err != nil {
+		return summary{}, err
+	}
+	defer db.Close()
+
+	logger.PrintInfo("database connection pool established", nil)
+
+	rejectsFile, err := os.Create(cfg.rejects)
+	if err != nil {
+		return summary{}, err
+	}
+	defer rejectsFile.Close()
+
+	imp := newImporter(data.ConsumableModel{DB: db}, cfg.creatorID, data.ConsumableVisibility(cfg.visibility), cfg.batchSize, rejectsFile)
+
+	err = imp.run(reader)
+	if err != nil {
+		return imp.summary, err
+	}
+
+	return imp.summary, nil
+}
+
+func openDB(dsn string) (*pgxpool.Pool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	db, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Ping(ctx)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// consumableImporter stores a batch of consumables, returning the indexes of those already stored
+type consumableImporter interface {
+	Import([]*data.Consumable) ([]int, error)
+}
+
+type summary struct {
+	read       int
+	inserted   int
+	duplicates int
+	rejected   int
+}
+
+// importer groups the foods of a dump into batches, a food repeated within a batch is kept once and
+// repeats across batches are found by the database
+type importer struct {
+	consumables consumableImporter
+	creatorID   int64
+	visibility  data.ConsumableVisibility
+	batchSize   int
+	rejects     *csv.Writer
+	summary     summary
+
+	batch     []*data.Consumable
+	batchRows []int64
+	// seen is the row of each consumable in the batch by data.ImportKey
+	seen map[string]int64
+}
+
+func newImporter(consumables consumableImporter, creatorID int64, visibility data.ConsumableVisibility, batchSize int, rejects io.Writer) *importer {
+	return &importer{
+		consumables: consumables,
+		creatorID:   creatorID,
+		visibility:  visibility,
+		batchSize:   batchSize,
+		rejects:     csv.NewWriter(rejects),
+		seen:        map[string]int64{},
+	}
+}
+
+func (imp *importer) run(reader foodimport.Reader) error {
+	err := imp.rejects.Write([]string{"row", "code", "name", "reason"})
+	if err != nil {
+		return err
+	}
+
+	for {
+		food, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var rowErr *foodimport.RowError
+		switch {
+		case errors.As(err, &rowErr):
+			imp.summary.read++
+			err = imp.reject(rowErr.Row, "", "", rowErr.Err.Error())
+			if err != nil {
+				return err
+			}
+			continue
+		case err != nil:
+			return err
+		}
+
+		imp.summary.read++
+
+		consumable, err := food.Consumable(imp.creatorID)
+		if err != nil {
+			err = imp.reject(food.Row, food.Code, food.Name, err.Error())
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		consumable.Visibility = imp.visibility
+
+		key := data.ImportKey(consumable)
+		if row, ok := imp.seen[key]; ok {
+			imp.summary.duplicates++
+			err = imp.writeReject(food.Row, consumable.Barcode, consumable.Name, fmt.Sprintf("duplicate of row %d", row))
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		imp.seen[key] = food.Row
+		imp.batch = append(imp.batch, consumable)
+		imp.batchRows = append(imp.batchRows, food.Row)
+
+		if len(imp.batch) >= imp.batchSize {
+			err = imp.flush()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = imp.flush()
+	if err != nil {
+		return err
+	}
+
+	imp.rejects.Flush()
+	return imp.rejects.Error()
+}
+
+// flush loads the batch, consumables already stored are reported as duplicates
+func (imp *importer) flush() error {
+	if len(imp.batch) == 0 {
+		return nil
+	}
+
+	duplicates, err := imp.consumables.Import(imp.batch)
+	if err != nil {
+		return fmt.Errorf("importing rows %d to %d: %w", imp.batchRows[0], imp.batchRows[len(imp.batchRows)-1], err)
+	}
+
+	for _, i := range duplicates {
+		err = imp.writeReject(imp.batchRows[i], imp.batch[i].Barcode, imp.batch[i].Name, "already stored")
+		if err != nil {
+			return err
+		}
+	}
+
+	imp.summary.duplicates += len(duplicates)
+	imp.summary.inserted += len(imp.batch) - len(duplicates)
+
+	imp.batch = imp.batch[:0]
+	imp.batchRows = imp.batchRows[:0]
+	clear(imp.seen)
+
+	return nil
+}
+
+func (imp *importer) reject(row int64, code string, name string, reason string) error {
+	imp.summary.rejected++
+	return imp.writeReject(row, code, name, reason)
+}
+
+func (imp *importer) writeReject(row int64, code string, name string, reason string) error {
+	return imp.rejects.Write([]string{strconv.FormatInt(row, 10), code, name, reason})
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"github.com/tconnellan/macro-tracker-backend/internal/assert"
+	"github.com/tconnellan/macro-tracker-backend/internal/data"
+	"github.com/tconnellan/macro-tracker-backend/internal/foodimport"
+)
+
+// storedConsumables imports into memory, a consumable is a duplicate when its import key is stored
+type storedConsumables struct {
+	stored       map[string]bool
+	batches      int
+	visibilities map[data.ConsumableVisibility]int
+}
+
+func (s *storedConsumables) Import(consumables []*data.Consumable) ([]int, error) {
+	s.batches++
+
+	duplicates := []int{}
+	for i, consumable := range consumables {
+		key := data.ImportKey(consumable)
+		if s.stored[key] {
+			duplicates = append(duplicates, i)
+			continue
+		}
+		s.stored[key] = true
+		s.visibilities[consumable.Visibility]++
+	}
+	return duplicates, nil
+}
+
+func TestImporterRun(t *testing.T) {
+
+	dump := "code\tproduct_name\tbrands\tproteins_100g\n" +
+		"3017620422003\tNutella\tFerrero\t6.3\n" +
+		"3017620422003\tNutella Jar\tFerrero\t6.3\n" +
+		"\tOats\tUncle Tobys\t13\n" +
+		"\tBad\tBrand\tlots\n" +
+		"\t\tBrand\t1\n" +
+		"\toats\tuncle tobys\t13\n" +
+		"\tMilk\tPauls\t3.4\n"
+
+	reader, err := foodimport.NewReader(foodimport.OpenFoodFactsCSV, bytes.NewBufferString(dump))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Oats are stored in the first batch and found again in the second
+	consumables := &storedConsumables{stored: map[string]bool{}, visibilities: map[data.ConsumableVisibility]int{}}
+	var rejects bytes.Buffer
+
+	imp := newImporter(consumables, 1, data.VisibilityShared, 2, &rejects)
+	err = imp.run(reader)
+	assert.NilError(t, err)
+
+	assert.Equal(t, imp.summary.read, 7)
+	assert.Equal(t, imp.summary.inserted, 3)
+	assert.Equal(t, imp.summary.duplicates, 2)
+	assert.Equal(t, imp.summary.rejected, 2)
+	assert.Equal(t, consumables.batches, 2)
+	assert.Equal(t, consumables.visibilities[data.VisibilityShared], 3)
+
+	records, err := csv.NewReader(&rejects).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := [][]string{
+		{"row", "code", "name", "reason"},
+		{"3", "03017620422003", "Nutella Jar", "duplicate of row 2"},
+		{"5", "", "", "proteins_100g is not a number"},
+		{"6", "", "", "name must be provided"},
+		{"7", "", "oats", "already stored"},
+	}
+
+	assert.Equal(t, len(records), len(expect))
+	for i := range min(len(records), len(expect)) {
+		for j := range expect[i] {
+			assert.Equal(t, records[i][j], expect[i][j])
+		}
+	}
+}
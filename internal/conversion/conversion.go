@@ -0,0 +1,78 @@
+// Package conversion converts amounts between the measurement units food is recorded in.
+// Mass units convert freely, volumes, units and servings need a per food Profile to be
+// expressed as a mass.
+package conversion
+
+import (
+	"errors"
+)
+
+type Unit string
+
+const (
+	Grams       Unit = "g"
+	Millilitres Unit = "ml"
+	Units       Unit = "units"
+	Servings    Unit = "servings"
+	Ounces      Unit = "oz"
+	Pounds      Unit = "lb"
+)
+
+var ErrIncompatibleUnits = errors.New("units cannot be converted")
+
+// gramsPerUnit holds the fixed mass conversions
+var gramsPerUnit = map[Unit]float64{
+	Grams:  1,
+	Ounces: 28.349523125,
+	Pounds: 453.59237,
+}
+
+// Profile describes how a single food converts between volume, count and mass,
+// a zero value means the conversion is unknown
+type Profile struct {
+	DensityGPerML  float64
+	UnitWeightG    float64
+	ServingWeightG float64
+}
+
+// gramsPer returns the mass of one of unit for the food described by profile
+func (p Profile) gramsPer(unit Unit) (float64, error) {
+	if grams, ok := gramsPerUnit[unit]; ok {
+		return grams, nil
+	}
+
+	var grams float64
+	switch unit {
+	case Millilitres:
+		grams = p.DensityGPerML
+	case Units:
+		grams = p.UnitWeightG
+	case Servings:
+		grams = p.ServingWeightG
+	}
+
+	if grams <= 0 {
+		return 0, ErrIncompatibleUnits
+	}
+
+	return grams, nil
+}
+
+// Convert expresses amount of from in the unit to, going through mass when the units differ
+func Convert(amount float64, from Unit, to Unit, profile Profile) (float64, error) {
+	if from == to {
+		return amount, nil
+	}
+
+	fromGrams, err := profile.gramsPer(from)
+	if err != nil {
+		return 0, err
+	}
+
+	toGrams, err := profile.gramsPer(to)
+	if err != nil {
+		return 0, err
+	}
+
+	return amount * fromGrams / toGrams, nil
+}
@@ -0,0 +1,110 @@
+package conversion
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tconnellan/macro-tracker-backend/internal/assert"
+)
+
+func TestConvert(t *testing.T) {
+
+	milk := Profile{
+		DensityGPerML:  1.03,
+		UnitWeightG:    0,
+		ServingWeightG: 257.5,
+	}
+
+	tests := []struct {
+		name        string
+		amount      float64
+		from        Unit
+		to          Unit
+		profile     Profile
+		expect      float64
+		expectError error
+	}{
+		{
+			name:        "same unit",
+			amount:      250,
+			from:        Millilitres,
+			to:          Millilitres,
+			profile:     Profile{},
+			expect:      250,
+			expectError: nil,
+		},
+		{
+			name:        "ounces to grams",
+			amount:      2,
+			from:        Ounces,
+			to:          Grams,
+			profile:     Profile{},
+			expect:      56.69904625,
+			expectError: nil,
+		},
+		{
+			name:        "pounds to ounces",
+			amount:      1,
+			from:        Pounds,
+			to:          Ounces,
+			profile:     Profile{},
+			expect:      16,
+			expectError: nil,
+		},
+		{
+			name:        "millilitres to grams with density",
+			amount:      100,
+			from:        Millilitres,
+			to:          Grams,
+			profile:     milk,
+			expect:      103,
+			expectError: nil,
+		},
+		{
+			name:        "servings to millilitres",
+			amount:      1,
+			from:        Servings,
+			to:          Millilitres,
+			profile:     milk,
+			expect:      250,
+			expectError: nil,
+		},
+		{
+			name:        "millilitres to grams without density",
+			amount:      100,
+			from:        Millilitres,
+			to:          Grams,
+			profile:     Profile{},
+			expectError: ErrIncompatibleUnits,
+		},
+		{
+			name:        "units without unit weight",
+			amount:      1,
+			from:        Units,
+			to:          Grams,
+			profile:     milk,
+			expectError: ErrIncompatibleUnits,
+		},
+		{
+			name:        "unknown unit",
+			amount:      1,
+			from:        Unit("cups"),
+			to:          Grams,
+			profile:     milk,
+			expectError: ErrIncompatibleUnits,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			converted, err := Convert(tt.amount, tt.from, tt.to, tt.profile)
+
+			assert.ExpectError(t, err, tt.expectError)
+			if err != nil {
+				return
+			}
+
+			assert.Equal(t, math.Abs(converted-tt.expect) < 1e-9, true)
+		})
+	}
+}
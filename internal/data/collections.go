@@ -0,0 +1,284 @@
+package data
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tconnellan/macro-tracker-backend/internal/validator"
+)
+
+// Collection is a named list of recipe lineages such as "meal prep", adding any version of a recipe adds
+// every version of it
+type Collection struct {
+	ID           int64     `json:"id"`
+	UserID       int64     `json:"user_id"`
+	Name         string    `json:"name"`
+	Description  string    `json:"description"`
+	CreatedAt    time.Time `json:"created_at"`
+	LastEditedAt time.Time `json:"last_edited_at"`
+}
+
+func ValidateCollection(v *validator.Validator, collection *Collection) {
+	v.Check(strings.TrimSpace(collection.Name) != "", "name", "must be provided")
+	v.Check(len(strings.TrimSpace(collection.Name)) <= 50, "name", "must be maximum 50 characters")
+	v.Check(len(collection.Description) <= 1000, "description", "must be maximum 1000 characters")
+}
+
+type CollectionModel struct {
+	DB *pgxpool.Pool
+}
+
+type ICollectionModel interface {
+	GetAllByUserID(int64) ([]*Collection, error)
+	Get(int64, int64) (*Collection, error)
+	GetRecipes(int64, int64) ([]*Recipe, error)
+	Insert(*Collection) error
+	Update(*Collection) error
+	Delete(int64, int64) error
+	AddRecipe(int64, int64, int64) error
+	RemoveRecipe(int64, int64, int64) error
+}
+
+func (m CollectionModel) GetAllByUserID(userID int64) ([]*Collection, error) {
+	stmt := `
+	SELECT id, user_id, name, description, created_at, last_edited_at
+	FROM collections
+	WHERE user_id = $1
+	ORDER BY name ASC, id ASC
+	`
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	rows, err := m.DB.Query(ctx, stmt, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collections := []*Collection{}
+
+	for rows.Next() {
+		var collection Collection
+		err = rows.Scan(
+			&collection.ID,
+			&collection.UserID,
+			&collection.Name,
+			&collection.Description,
+			&collection.CreatedAt,
+			&collection.LastEditedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, &collection)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return collections, nil
+}
+
+func (m CollectionModel) Get(ID int64, userID int64) (*Collection, error) {
+	stmt := `
+	SELECT id, user_id, name, description, created_at, last_edited_at
+	FROM collections
+	WHERE id = $1 AND user_id = $2
+	`
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	var collection Collection
+
+	err := m.DB.QueryRow(ctx, stmt, ID, userID).Scan(
+		&collection.ID,
+		&collection.UserID,
+		&collection.Name,
+		&collection.Description,
+		&collection.CreatedAt,
+		&collection.LastEditedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &collection, nil
+}
+
+// GetRecipes returns the current version of each recipe lineage in one of the user's collections, the
+// newest version still marked latest
+func (m CollectionModel) GetRecipes(ID int64, userID int64) ([]*Recipe, error) {
+	stmt := `
+	WITH RECURSIVE ` + recipeLineages + `
+	SELECT DISTINCT ON (L.root_id) R.id, R.recipe_name, R.creator_id, R.created_at, R.last_edited_at, R.notes, COALESCE(R.parent_recipe_id, 0), R.is_latest, COALESCE(R.servings, 0), COALESCE(R.cooked_weight, 0), R.is_public, COALESCE(R.forked_from_id, 0)
+	FROM collection_recipes CR
+	     INNER JOIN collections C ON CR.collection_id = C.id
+	     INNER JOIN lineages L ON L.root_id = CR.recipe_id
+	     INNER JOIN recipes R ON R.id = L.id
+	WHERE C.id = $2 AND C.user_id = $1
+	ORDER BY L.root_id ASC, R.is_latest DESC, R.created_at DESC, R.id DESC
+	`
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	rows, err := m.DB.Query(ctx, stmt, userID, ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recipes := []*Recipe{}
+
+	for rows.Next() {
+		var recipe Recipe
+		err = rows.Scan(
+			&recipe.ID,
+			&recipe.Name,
+			&recipe.CreatorID,
+			&recipe.CreatedAt,
+			&recipe.LastEditedAt,
+			&recipe.Notes,
+			&recipe.ParentRecipeID,
+			&recipe.IsLatest,
+			&recipe.Servings,
+			&recipe.CookedWeight,
+			&recipe.IsPublic,
+			&recipe.ForkedFromID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		recipes = append(recipes, &recipe)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return recipes, nil
+}
+
+// Insert stores the collection with its name trimmed, the same as tags
+func (m CollectionModel) Insert(collection *Collection) error {
+	stmt := `
+	INSERT INTO collections (user_id, name, description)
+	VALUES ($1, $2, $3)
+	RETURNING id, created_at, last_edited_at
+	`
+
+	collection.Name = strings.TrimSpace(collection.Name)
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	err := m.DB.QueryRow(ctx, stmt, collection.UserID, collection.Name, collection.Description).Scan(
+		&collection.ID,
+		&collection.CreatedAt,
+		&collection.LastEditedAt,
+	)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), `ERROR: duplicate key value violates unique constraint "collections_user_name_key"`):
+			return ErrDuplicateCollection
+		case strings.HasPrefix(err.Error(), "ERROR: insert or update on table \"collections\" violates foreign key constraint \"fk_collection_user\""):
+			return ErrReferencedUserDoesNotExist
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Update renames or redescribes one of the user's collections
+func (m CollectionModel) Update(collection *Collection) error {
+	stmt := `
+	UPDATE collections
+	SET name = $3, description = $4, last_edited_at = current_timestamp
+	WHERE id = $1 AND user_id = $2
+	RETURNING created_at, last_edited_at
+	`
+
+	collection.Name = strings.TrimSpace(collection.Name)
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	err := m.DB.QueryRow(ctx, stmt, collection.ID, collection.UserID, collection.Name, collection.Description).Scan(
+		&collection.CreatedAt,
+		&collection.LastEditedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return ErrRecordNotFound
+		case strings.HasPrefix(err.Error(), `ERROR: duplicate key value violates unique constraint "collections_user_name_key"`):
+			return ErrDuplicateCollection
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete deletes one of the user's collections, the recipes in it are kept
+func (m CollectionModel) Delete(ID int64, userID int64) error {
+	stmt := `
+	DELETE FROM collections
+	WHERE id = $1 AND user_id = $2
+	`
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	result, err := m.DB.Exec(ctx, stmt, ID, userID)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// AddRecipe adds the lineage of one of the user's recipes to the collection, adding a lineage twice has
+// no effect
+func (m CollectionModel) AddRecipe(ID int64, recipeID int64, userID int64) error {
+	stmt := `
+	WITH RECURSIVE ` + recipeLineages + `
+	INSERT INTO collection_recipes (collection_id, recipe_id)
+	SELECT C.id, L.root_id
+	FROM collections C CROSS JOIN lineages L
+	WHERE C.id = $2 AND C.user_id = $1 AND L.id = $3
+	`
+
+	return addToLineage(m.DB, stmt, userID, ID, recipeID)
+}
+
+// RemoveRecipe takes the lineage of one of the user's recipes out of the collection
+func (m CollectionModel) RemoveRecipe(ID int64, recipeID int64, userID int64) error {
+	stmt := `
+	WITH RECURSIVE ` + recipeLineages + `
+	DELETE FROM collection_recipes CR
+	USING collections C, lineages L
+	WHERE CR.collection_id = C.id AND CR.recipe_id = L.root_id
+	  AND C.id = $2 AND C.user_id = $1 AND L.id = $3
+	`
+
+	return removeFromLineage(m.DB, stmt, userID, ID, recipeID)
+}
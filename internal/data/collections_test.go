@@ -0,0 +1,95 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tconnellan/macro-tracker-backend/internal/assert"
+	"github.com/tconnellan/macro-tracker-backend/internal/validator"
+)
+
+func TestValidateCollection(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		valid      bool
+		collection Collection
+	}{
+		{
+			name:       "valid collection",
+			valid:      true,
+			collection: Collection{UserID: 1, Name: "meal prep", Description: "sunday cook up"},
+		},
+		{
+			name:       "invalid empty name",
+			valid:      false,
+			collection: Collection{UserID: 1, Name: ""},
+		},
+		{
+			name:       "invalid description too long",
+			valid:      false,
+			collection: Collection{UserID: 1, Name: "meal prep", Description: strings.Repeat("a", 1001)},
+		},
+		{
+			name:       "valid padded name",
+			valid:      true,
+			collection: Collection{UserID: 1, Name: " " + strings.Repeat("a", 50) + " "},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			v := validator.New()
+
+			ValidateCollection(v, &tt.collection)
+			assert.ValidatorValid(t, v, tt.valid)
+		})
+	}
+}
+
+func TestCollectionModelGetRecipes(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db, err := newTestDB(t, "collection")
+	if err != nil {
+		t.Fatal(fmt.Errorf("Failed test db setup: %w", err))
+	}
+	m := CollectionModel{db}
+
+	collection := Collection{UserID: 4, Name: "meal prep"}
+	err = m.Insert(&collection)
+	assert.NilError(t, err)
+
+	err = m.Insert(&Collection{UserID: 4, Name: "meal prep"})
+	assert.ExpectError(t, err, ErrDuplicateCollection)
+
+	err = m.AddRecipe(collection.ID, 9, 4)
+	assert.NilError(t, err)
+
+	err = m.AddRecipe(collection.ID, 1, 4)
+	assert.ExpectError(t, err, ErrRecordNotFound)
+
+	// the lineage is listed once, as its current version
+	recipes, err := m.GetRecipes(collection.ID, 4)
+	assert.NilError(t, err)
+	assert.Equal(t, len(recipes), 1)
+	assert.Equal(t, recipes[0].ID, 10)
+
+	err = m.RemoveRecipe(collection.ID, 10, 4)
+	assert.NilError(t, err)
+
+	recipes, err = m.GetRecipes(collection.ID, 4)
+	assert.NilError(t, err)
+	assert.Equal(t, len(recipes), 0)
+
+	err = m.Delete(collection.ID, 1)
+	assert.ExpectError(t, err, ErrRecordNotFound)
+
+	err = m.Delete(collection.ID, 4)
+	assert.NilError(t, err)
+}
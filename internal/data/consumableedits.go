@@ -0,0 +1,220 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ConsumableEditStatus string
+
+const (
+	EditPending  ConsumableEditStatus = "pending"
+	EditApproved ConsumableEditStatus = "approved"
+	EditRejected ConsumableEditStatus = "rejected"
+)
+
+// ConsumableEdit is a change to a shared consumable made by its creator, which an admin approves or
+// rejects before it is applied. Consumable holds the consumable as it is after the edit
+type ConsumableEdit struct {
+	ID         int64                `json:"id"`
+	EditorID   int64                `json:"editor_id"`
+	CreatedAt  time.Time            `json:"created_at"`
+	Consumable Consumable           `json:"consumable"`
+	Status     ConsumableEditStatus `json:"status"`
+	ReviewerID int64                `json:"reviewer_id"`
+}
+
+type ConsumableEditModel struct {
+	DB *pgxpool.Pool
+}
+
+type IConsumableEditModel interface {
+	GetPending(MetadataFilters) ([]*ConsumableEdit, Metadata, error)
+	Insert(*ConsumableEdit) error
+	Approve(int64, int64) error
+	Reject(int64, int64) error
+}
+
+// GetPending returns the edits waiting for review, oldest first
+func (m ConsumableEditModel) GetPending(filters MetadataFilters) ([]*ConsumableEdit, Metadata, error) {
+	stmt := `
+	SELECT COUNT(*) OVER(), E.id, E.editor_id, E.created_at, E.consumable_id, C.creator_id, C.created_at, E.name, E.brand_name, COALESCE(E.barcode, ''), C.visibility,
+	       E.size, E.units, E.carbs, E.fats, E.proteins, E.alcohol, E.density, E.unit_weight, E.serving_weight, E.nutrients, E.status, COALESCE(E.reviewer_id, 0)
+	FROM consumable_edits E
+	     INNER JOIN consumables C ON C.id = E.consumable_id
+	WHERE E.status = 'pending'
+	ORDER BY E.created_at ASC, E.id ASC
+	LIMIT $1
+	OFFSET $2
+	`
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	rows, err := m.DB.Query(ctx, stmt, filters.pageLimit(), filters.pageOffset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	recordCount := 0
+	edits := []*ConsumableEdit{}
+
+	for rows.Next() {
+		var edit ConsumableEdit
+		err = rows.Scan(
+			&recordCount,
+			&edit.ID,
+			&edit.EditorID,
+			&edit.CreatedAt,
+			&edit.Consumable.ID,
+			&edit.Consumable.CreatorID,
+			&edit.Consumable.CreatedAt,
+			&edit.Consumable.Name,
+			&edit.Consumable.BrandName,
+			&edit.Consumable.Barcode,
+			&edit.Consumable.Visibility,
+			&edit.Consumable.Size,
+			&edit.Consumable.Units,
+			&edit.Consumable.Macros.Carbs,
+			&edit.Consumable.Macros.Fats,
+			&edit.Consumable.Macros.Proteins,
+			&edit.Consumable.Macros.Alcohol,
+			&edit.Consumable.Density,
+			&edit.Consumable.UnitWeight,
+			&edit.Consumable.ServingWeight,
+			&edit.Consumable.Nutrients,
+			&edit.Status,
+			&edit.ReviewerID,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		edits = append(edits, &edit)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return edits, calculateMetadata(recordCount, filters.Page, filters.PageSize), nil
+}
+
+// Insert queues an edit to a consumable created by the editor, the consumable's visibility is not
+// edited. Returns ErrRecordNotFound when the editor did not create the consumable
+func (m ConsumableEditModel) Insert(edit *ConsumableEdit) error {
+	edit.Consumable.Barcode = NormaliseGTIN(edit.Consumable.Barcode)
+
+	stmt := `
+	INSERT INTO consumable_edits (consumable_id, editor_id, name, brand_name, barcode, size, units, carbs, fats, proteins, alcohol, density, unit_weight, serving_weight, nutrients)
+	SELECT id, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9, $10, $11, $12, $13, $14, $15
+	FROM consumables
+	WHERE id = $1 AND creator_id = $2
+	RETURNING id, created_at, status
+	`
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	args := []any{
+		edit.Consumable.ID,
+		edit.EditorID,
+		edit.Consumable.Name,
+		edit.Consumable.BrandName,
+		edit.Consumable.Barcode,
+		edit.Consumable.Size,
+		edit.Consumable.Units,
+		edit.Consumable.Macros.Carbs,
+		edit.Consumable.Macros.Fats,
+		edit.Consumable.Macros.Proteins,
+		edit.Consumable.Macros.Alcohol,
+		edit.Consumable.Density,
+		edit.Consumable.UnitWeight,
+		edit.Consumable.ServingWeight,
+		edit.Consumable.Nutrients,
+	}
+
+	err := m.DB.QueryRow(ctx, stmt, args...).Scan(&edit.ID, &edit.CreatedAt, &edit.Status)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Approve applies a pending edit to its consumable, the reviewer is recorded on the edit
+func (m ConsumableEditModel) Approve(ID int64, reviewerID int64) error {
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	txn, err := m.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer txn.Rollback(ctx)
+
+	err = m.review(ctx, txn, ID, reviewerID, EditApproved)
+	if err != nil {
+		return err
+	}
+
+	stmt := `
+	UPDATE consumables C
+	SET name = E.name, brand_name = E.brand_name, barcode = E.barcode, size = E.size, units = E.units,
+	    carbs = E.carbs, fats = E.fats, proteins = E.proteins, alcohol = E.alcohol, density = E.density,
+	    unit_weight = E.unit_weight, serving_weight = E.serving_weight, nutrients = E.nutrients
+	FROM consumable_edits E
+	WHERE E.id = $1 AND C.id = E.consumable_id
+	`
+
+	_, err = txn.Exec(ctx, stmt, ID)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), `ERROR: duplicate key value violates unique constraint "consumables_brand_barcode_key"`):
+			return ErrDuplicateBarcode
+		default:
+			return err
+		}
+	}
+
+	return txn.Commit(ctx)
+}
+
+// Reject closes a pending edit without applying it
+func (m ConsumableEditModel) Reject(ID int64, reviewerID int64) error {
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	return m.review(ctx, m.DB, ID, reviewerID, EditRejected)
+}
+
+// review records the outcome of a pending edit, returning ErrRecordNotFound when there is no pending
+// edit with the ID
+func (m ConsumableEditModel) review(ctx context.Context, db psqlDB, ID int64, reviewerID int64, status ConsumableEditStatus) error {
+	stmt := `
+	UPDATE consumable_edits
+	SET status = $3, reviewer_id = $2, reviewed_at = current_timestamp
+	WHERE id = $1 AND status = 'pending'
+	`
+
+	result, err := db.Exec(ctx, stmt, ID, reviewerID, status)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package data
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tconnellan/macro-tracker-backend/internal/assert"
+)
+
+func TestConsumableEditModel(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db, err := newTestDB(t, "recipes")
+	if err != nil {
+		t.Fatal(fmt.Errorf("Failed test db setup: %w", err))
+	}
+
+	consumables := ConsumableModel{db}
+	m := ConsumableEditModel{db}
+
+	filters := MetadataFilters{Page: 1, PageSize: 20, Sort: "created_at", SortSafeList: []string{"created_at"}}
+
+	original, err := consumables.GetByID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an edit can only be made by the consumable's creator
+	edit := ConsumableEdit{EditorID: 2, Consumable: *original}
+	err = m.Insert(&edit)
+	assert.ExpectError(t, err, ErrRecordNotFound)
+
+	edit = ConsumableEdit{EditorID: 1, Consumable: *original}
+	edit.Consumable.Name = "Quick Oats"
+	err = m.Insert(&edit)
+	assert.NilError(t, err)
+	assert.Equal(t, edit.Status, EditPending)
+
+	rejected := ConsumableEdit{EditorID: 1, Consumable: *original}
+	rejected.Consumable.Name = "Slow Oats"
+	err = m.Insert(&rejected)
+	assert.NilError(t, err)
+
+	pending, metadata, err := m.GetPending(filters)
+	assert.NilError(t, err)
+	assert.Equal(t, metadata.TotalRecords, 2)
+	if len(pending) == 2 {
+		assert.Equal(t, pending[0].ID, edit.ID)
+		assert.Equal(t, pending[0].Consumable.ID, original.ID)
+		assert.Equal(t, pending[0].Consumable.Name, "Quick Oats")
+	}
+
+	// the consumable is unchanged until the edit is approved
+	current, err := consumables.GetByID(1)
+	assert.NilError(t, err)
+	assert.Equal(t, current.Name, original.Name)
+
+	err = m.Approve(edit.ID, 3)
+	assert.NilError(t, err)
+	err = m.Reject(rejected.ID, 3)
+	assert.NilError(t, err)
+
+	current, err = consumables.GetByID(1)
+	assert.NilError(t, err)
+	assert.Equal(t, current.Name, "Quick Oats")
+
+	// reviewed edits cannot be reviewed again
+	err = m.Approve(rejected.ID, 3)
+	assert.ExpectError(t, err, ErrRecordNotFound)
+	err = m.Reject(edit.ID, 3)
+	assert.ExpectError(t, err, ErrRecordNotFound)
+
+	pending, _, err = m.GetPending(filters)
+	assert.NilError(t, err)
+	assert.Equal(t, len(pending), 0)
+}
@@ -0,0 +1,141 @@
+package data
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// importTimeout bounds loading one batch of an import, which takes far longer than a request
+const importTimeout = 5 * time.Minute
+
+// ImportKey identifies a consumable when deduplicating an import, its barcode when it has one and
+// otherwise its name and brand ignoring case
+func ImportKey(consumable *Consumable) string {
+	if consumable.Barcode != "" {
+		return "barcode:" + consumable.Barcode
+	}
+	return "name:" + strings.ToLower(consumable.Name) + "\x00" + strings.ToLower(consumable.BrandName)
+}
+
+// Import bulk loads a batch of consumables read from a nutrition database dump. The batch is copied
+// into a temporary table and only consumables not already stored, by barcode or by name and brand
+// ignoring case, are inserted. The batch must not contain duplicates itself. Returns the indexes of
+// the batch's consumables that were skipped as duplicates
+func (m ConsumableModel) Import(consumables []*Consumable) ([]int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), importTimeout)
+	defer cancel()
+
+	txn, err := m.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer txn.Rollback(ctx)
+
+	stmtCreate := `
+	CREATE TEMPORARY TABLE consumables_import (
+		row_no INTEGER NOT NULL,
+		creator_id INTEGER,
+		name VARCHAR(50),
+		brand_name VARCHAR(50),
+		barcode VARCHAR(14),
+		visibility VARCHAR(10),
+		size DOUBLE PRECISION,
+		units VARCHAR(10),
+		carbs DOUBLE PRECISION,
+		fats DOUBLE PRECISION,
+		proteins DOUBLE PRECISION,
+		alcohol DOUBLE PRECISION,
+		density DOUBLE PRECISION,
+		unit_weight DOUBLE PRECISION,
+		serving_weight DOUBLE PRECISION,
+		nutrients JSONB
+	) ON COMMIT DROP
+	`
+
+	_, err = txn.Exec(ctx, stmtCreate)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = txn.CopyFrom(ctx, pgx.Identifier{"consumables_import"},
+		[]string{"row_no", "creator_id", "name", "brand_name", "barcode", "visibility", "size", "units", "carbs", "fats", "proteins", "alcohol", "density", "unit_weight", "serving_weight", "nutrients"},
+		pgx.CopyFromSlice(len(consumables), func(i int) ([]any, error) {
+			consumable := consumables[i]
+			return []any{
+				i,
+				consumable.CreatorID,
+				consumable.Name,
+				consumable.BrandName,
+				nullableString(consumable.Barcode),
+				consumable.visibilityOrDefault(),
+				consumable.Size,
+				consumable.Units,
+				consumable.Macros.Carbs,
+				consumable.Macros.Fats,
+				consumable.Macros.Proteins,
+				consumable.Macros.Alcohol,
+				consumable.Density,
+				consumable.UnitWeight,
+				consumable.ServingWeight,
+				consumable.Nutrients,
+			}, nil
+		}))
+	if err != nil {
+		return nil, err
+	}
+
+	// the insert runs although only the duplicates are selected
+	stmtInsert := `
+	WITH duplicates AS (
+		SELECT I.row_no
+		FROM consumables_import I
+		WHERE (I.barcode IS NOT NULL AND EXISTS (SELECT 1 FROM consumables C WHERE C.barcode = I.barcode))
+		   OR EXISTS (SELECT 1 FROM consumables C WHERE lower(C.name) = lower(I.name) AND lower(C.brand_name) = lower(I.brand_name))
+	), inserted AS (
+		INSERT INTO consumables (creator_id, name, brand_name, barcode, visibility, size, units, carbs, fats, proteins, alcohol, density, unit_weight, serving_weight, nutrients)
+		SELECT creator_id, name, brand_name, barcode, visibility, size, units, carbs, fats, proteins, alcohol, density, unit_weight, serving_weight, nutrients
+		FROM consumables_import
+		WHERE row_no NOT IN (SELECT row_no FROM duplicates)
+		ORDER BY row_no
+	)
+	SELECT row_no
+	FROM duplicates
+	ORDER BY row_no
+	`
+
+	rows, err := txn.Query(ctx, stmtInsert)
+	if err != nil {
+		return nil, err
+	}
+
+	duplicates := []int{}
+	for rows.Next() {
+		var rowNo int
+		err = rows.Scan(&rowNo)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		duplicates = append(duplicates, rowNo)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "ERROR: insert or update on table \"consumables\" violates foreign key constraint \"fk_consumable_creator\""):
+			return nil, ErrReferencedUserDoesNotExist
+		default:
+			return nil, err
+		}
+	}
+
+	err = txn.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return duplicates, nil
+}
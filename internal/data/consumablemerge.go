@@ -0,0 +1,271 @@
+package data
+
+import (
+	"fmt"
+)
+
+const (
+	// duplicateNameSimilarity is the trigram similarity of two names from which they may name the same food
+	duplicateNameSimilarity = 0.5
+	// duplicateMacroDistance is the largest total difference in macros per 100g or ml of two duplicates
+	duplicateMacroDistance = 5.0
+	// duplicateLimit bounds the candidates returned for one consumable
+	duplicateLimit = 50
+)
+
+// ConsumableDuplicate is a consumable that is likely a duplicate of another. Similarity runs from 0 to 1
+// and Reasons lists what matched, any of barcode, name, brand and macros
+type ConsumableDuplicate struct {
+	Consumable
+	Similarity float64  `json:"similarity"`
+	Reasons    []string `json:"reasons"`
+}
+
+// normalisedSQL is the SQL for a text column lower cased with its spaces and punctuation removed
+func normalisedSQL(column string) string {
+	return fmt.Sprintf(`regexp_replace(lower(%s), '[^[:alnum:]]+', '', 'g')`, column)
+}
+
+// FindDuplicates returns the consumables visible to the user that are likely duplicates of the
+// consumable, most similar first. A candidate shares the consumable's barcode, or has the same
+// normalised brand, a normalised or similar name and macros within duplicateMacroDistance per 100 units.
+// Candidates are looked up by barcode and by trigram similarity of name, so both lookups use an index,
+// and the normalised names and brands are only compared for those
+func (m ConsumableModel) FindDuplicates(ID int64, userID int64) ([]*ConsumableDuplicate, error) {
+	stmt := fmt.Sprintf(`
+	WITH target AS (
+		SELECT id, name, brand_name, barcode, size, units, carbs, fats, proteins, alcohol
+		FROM consumables
+		WHERE id = $1 AND (visibility <> 'private' OR creator_id = $2)
+	),
+	candidate_ids AS (
+		SELECT C.id
+		FROM consumables C INNER JOIN target T ON C.barcode = T.barcode
+		UNION
+		SELECT C.id
+		FROM consumables C INNER JOIN target T ON C.name %% T.name
+	),
+	candidates AS (
+		SELECT C.id, C.creator_id, C.created_at, C.name, C.brand_name, COALESCE(C.barcode, '') AS barcode, C.visibility, C.size, C.units,
+		       C.carbs, C.fats, C.proteins, C.alcohol, C.density, C.unit_weight, C.serving_weight, C.nutrients,
+		       COALESCE(C.barcode = T.barcode, FALSE) AS barcode_match,
+		       %[1]s = %[2]s AS brand_match,
+		       CASE WHEN %[3]s = %[4]s THEN 1 ELSE similarity(C.name, T.name) END AS name_similarity,
+		       CASE WHEN C.units = T.units AND C.size > 0 AND T.size > 0
+		            THEN 100 * (abs(C.carbs / C.size - T.carbs / T.size) + abs(C.fats / C.size - T.fats / T.size)
+		                      + abs(C.proteins / C.size - T.proteins / T.size) + abs(C.alcohol / C.size - T.alcohol / T.size))
+		       END AS macro_distance
+		FROM candidate_ids I
+		     INNER JOIN consumables C ON C.id = I.id
+		     CROSS JOIN target T
+		WHERE C.id <> T.id AND (C.visibility <> 'private' OR C.creator_id = $2)
+	),
+	matched AS (
+		SELECT *, name_similarity >= $3 AS name_match, COALESCE(macro_distance <= $4, FALSE) AS macro_match
+		FROM candidates
+	)
+	SELECT id, creator_id, created_at, name, brand_name, barcode, visibility, size, units, carbs, fats, proteins, alcohol,
+	       density, unit_weight, serving_weight, nutrients, barcode_match, name_match, brand_match, macro_match,
+	       (barcode_match::INTEGER + brand_match::INTEGER + name_similarity + COALESCE(1 - LEAST(macro_distance / (2 * $4), 1), 0)) / 4 AS similarity
+	FROM matched
+	WHERE barcode_match OR (brand_match AND name_match AND macro_match)
+	ORDER BY similarity DESC, id ASC
+	LIMIT $5
+	`, normalisedSQL("C.brand_name"), normalisedSQL("T.brand_name"), normalisedSQL("C.name"), normalisedSQL("T.name"))
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	rows, err := m.DB.Query(ctx, stmt, ID, userID, duplicateNameSimilarity, duplicateMacroDistance, duplicateLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	duplicates := []*ConsumableDuplicate{}
+
+	for rows.Next() {
+		var duplicate ConsumableDuplicate
+		var barcodeMatch, nameMatch, brandMatch, macroMatch bool
+
+		err = rows.Scan(
+			&duplicate.ID,
+			&duplicate.CreatorID,
+			&duplicate.CreatedAt,
+			&duplicate.Name,
+			&duplicate.BrandName,
+			&duplicate.Barcode,
+			&duplicate.Visibility,
+			&duplicate.Size,
+			&duplicate.Units,
+			&duplicate.Macros.Carbs,
+			&duplicate.Macros.Fats,
+			&duplicate.Macros.Proteins,
+			&duplicate.Macros.Alcohol,
+			&duplicate.Density,
+			&duplicate.UnitWeight,
+			&duplicate.ServingWeight,
+			&duplicate.Nutrients,
+			&barcodeMatch,
+			&nameMatch,
+			&brandMatch,
+			&macroMatch,
+			&duplicate.Similarity,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		duplicate.Reasons = []string{}
+		for _, reason := range []struct {
+			matched bool
+			name    string
+		}{{barcodeMatch, "barcode"}, {nameMatch, "name"}, {brandMatch, "brand"}, {macroMatch, "macros"}} {
+			if reason.matched {
+				duplicate.Reasons = append(duplicate.Reasons, reason.name)
+			}
+		}
+
+		duplicates = append(duplicates, &duplicate)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return duplicates, nil
+}
+
+// CanMergeInto reports whether the consumable can be merged into other without breaking the recipes,
+// serving sizes and pantry items using it. Every unit the consumable converts to must also convert to
+// other's units, through other's density, unit weight or serving weight where the units differ. Sizes
+// may differ as macros are scaled by size
+func (consumable *Consumable) CanMergeInto(other *Consumable) bool {
+	if other.Size <= 0 {
+		return false
+	}
+
+	for _, units := range ValidMeasurementUnits {
+		_, err := consumable.ConvertToUnits(1, units)
+		if err != nil {
+			continue
+		}
+		_, err = other.ConvertToUnits(1, units)
+		if err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Merge folds the duplicates into the consumable in one transaction. Pantry items, consumed entries,
+// serving sizes and earlier aliases of the duplicates are moved to the consumable, recipe steps keep
+// their amounts and units so their nutrition is unchanged, the duplicates are deleted along with any
+// edits waiting for review and their IDs are kept as aliases so they still resolve through GetByID. Returns ErrRecordNotFound when any of the consumables does not exist and
+// ErrIncompatibleMerge when a duplicate cannot be merged into the consumable, see CanMergeInto
+func (m ConsumableModel) Merge(ID int64, duplicateIDs []int64, mergedBy int64) error {
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	txn, err := m.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer txn.Rollback(ctx)
+
+	// lock the consumables so none is edited or merged elsewhere part way through
+	stmtLock := `
+	SELECT id, size, units, density, unit_weight, serving_weight
+	FROM consumables
+	WHERE id = $1 OR id = ANY($2)
+	FOR UPDATE
+	`
+
+	rows, err := txn.Query(ctx, stmtLock, ID, duplicateIDs)
+	if err != nil {
+		return err
+	}
+
+	locked := map[int64]*Consumable{}
+	for rows.Next() {
+		var consumable Consumable
+		err = rows.Scan(
+			&consumable.ID,
+			&consumable.Size,
+			&consumable.Units,
+			&consumable.Density,
+			&consumable.UnitWeight,
+			&consumable.ServingWeight,
+		)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		locked[consumable.ID] = &consumable
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	if len(locked) != len(duplicateIDs)+1 {
+		return ErrRecordNotFound
+	}
+
+	for _, duplicateID := range duplicateIDs {
+		if !locked[duplicateID].CanMergeInto(locked[ID]) {
+			return ErrIncompatibleMerge
+		}
+	}
+
+	// a step without units is measured in the units of its consumable, so is given the duplicate's units
+	// before it is moved to a consumable measured in others
+	stmtStepUnits := `
+	UPDATE recipe_components
+	SET units = $2
+	WHERE units IS NULL AND serving_id IS NULL
+	  AND pantry_item_id IN (SELECT id FROM pantry_items WHERE consumable_id = $1)
+	`
+
+	for _, duplicateID := range duplicateIDs {
+		if locked[duplicateID].Units == locked[ID].Units {
+			continue
+		}
+		_, err = txn.Exec(ctx, stmtStepUnits, duplicateID, locked[duplicateID].Units)
+		if err != nil {
+			return err
+		}
+	}
+
+	stmtsRepoint := []string{
+		`UPDATE pantry_items SET consumable_id = $1 WHERE consumable_id = ANY($2)`,
+		`UPDATE consumed SET consumable_id = $1 WHERE consumable_id = ANY($2)`,
+		`UPDATE serving_sizes SET consumable_id = $1 WHERE consumable_id = ANY($2)`,
+		`UPDATE consumable_aliases SET consumable_id = $1 WHERE consumable_id = ANY($2)`,
+	}
+
+	for _, stmt := range stmtsRepoint {
+		_, err = txn.Exec(ctx, stmt, ID, duplicateIDs)
+		if err != nil {
+			return err
+		}
+	}
+
+	stmtAlias := `
+	INSERT INTO consumable_aliases (alias_id, consumable_id, merged_by)
+	SELECT unnest($2::INTEGER[]), $1, $3
+	`
+
+	_, err = txn.Exec(ctx, stmtAlias, ID, duplicateIDs, mergedBy)
+	if err != nil {
+		return err
+	}
+
+	_, err = txn.Exec(ctx, `DELETE FROM consumables WHERE id = ANY($1)`, duplicateIDs)
+	if err != nil {
+		return err
+	}
+
+	return txn.Commit(ctx)
+}
@@ -0,0 +1,192 @@
+package data
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/tconnellan/macro-tracker-backend/internal/assert"
+)
+
+func TestConsumableCanMergeInto(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		duplicate Consumable
+		other     Consumable
+		expect    bool
+	}{
+		{
+			name:      "same units different size",
+			duplicate: Consumable{Size: 100, Units: "g"},
+			other:     Consumable{Size: 62.5, Units: "g"},
+			expect:    true,
+		},
+		{
+			name:      "weight into volume without density",
+			duplicate: Consumable{Size: 100, Units: "g"},
+			other:     Consumable{Size: 250, Units: "ml"},
+			expect:    false,
+		},
+		{
+			name:      "weight into volume with density",
+			duplicate: Consumable{Size: 100, Units: "g"},
+			other:     Consumable{Size: 250, Units: "ml", Density: 1.03},
+			expect:    true,
+		},
+		{
+			name:      "volume through density into weight without density",
+			duplicate: Consumable{Size: 250, Units: "ml", Density: 1.03},
+			other:     Consumable{Size: 100, Units: "g"},
+			expect:    false,
+		},
+		{
+			name:      "unit weight lost",
+			duplicate: Consumable{Size: 100, Units: "g", UnitWeight: 120},
+			other:     Consumable{Size: 100, Units: "g"},
+			expect:    false,
+		},
+		{
+			name:      "units into weight with unit weight",
+			duplicate: Consumable{Size: 1, Units: "units"},
+			other:     Consumable{Size: 100, Units: "g", UnitWeight: 120},
+			expect:    true,
+		},
+		{
+			name:      "no size",
+			duplicate: Consumable{Size: 100, Units: "g"},
+			other:     Consumable{Size: 0, Units: "g"},
+			expect:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.duplicate.CanMergeInto(&tt.other), tt.expect)
+		})
+	}
+}
+
+func TestConsumableModelMerge(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db, err := newTestDB(t, "recipes")
+	if err != nil {
+		t.Fatal(fmt.Errorf("Failed test db setup: %w", err))
+	}
+
+	m := ConsumableModel{db}
+
+	// consumable 3 is Greek Yogurt by Jalna, 90g with 3.8 carbs, 5 fats and 9 proteins
+	duplicate := Consumable{CreatorID: 2, Name: "Greek-Yogurt", BrandName: "JALNA", Size: 100, Units: "g", Macros: Macronutrients{Carbs: 4.2, Fats: 5.6, Proteins: 10}}
+	err = m.Insert(&duplicate)
+	assert.NilError(t, err)
+
+	flavoured := Consumable{CreatorID: 2, Name: "Greek Yogurt Honey", BrandName: "Jalna", Size: 100, Units: "g", Macros: Macronutrients{Carbs: 15, Fats: 5, Proteins: 8}}
+	err = m.Insert(&flavoured)
+	assert.NilError(t, err)
+
+	duplicates, err := m.FindDuplicates(3, 2)
+	assert.NilError(t, err)
+	assert.Equal(t, len(duplicates), 1)
+	if len(duplicates) == 1 {
+		assert.Equal(t, duplicates[0].ID, duplicate.ID)
+		assert.Equal(t, fmt.Sprint(duplicates[0].Reasons), "[name brand macros]")
+		assert.Equal(t, duplicates[0].Similarity > 0.5, true)
+	}
+
+	// the duplicate is private to user 2
+	duplicates, err = m.FindDuplicates(3, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(duplicates), 0)
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	var pantryItemID int64
+	err = db.QueryRow(ctx, `INSERT INTO pantry_items (user_id, consumable_id, name) VALUES (2, $1, 'yogurt') RETURNING id`, duplicate.ID).Scan(&pantryItemID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.Merge(3, []int64{duplicate.ID}, 1)
+	assert.NilError(t, err)
+
+	var consumableID int64
+	err = db.QueryRow(ctx, `SELECT consumable_id FROM pantry_items WHERE id = $1`, pantryItemID).Scan(&consumableID)
+	assert.NilError(t, err)
+	assert.Equal(t, consumableID, int64(3))
+
+	// the merged ID resolves to the consumable it was merged into
+	merged, err := m.GetByID(duplicate.ID)
+	assert.NilError(t, err)
+	assert.Equal(t, merged.ID, int64(3))
+
+	err = m.Merge(3, []int64{duplicate.ID}, 1)
+	assert.ExpectError(t, err, ErrRecordNotFound)
+
+	// aliases follow a consumable when it is merged again
+	err = m.Merge(flavoured.ID, []int64{3}, 1)
+	assert.NilError(t, err)
+
+	merged, err = m.GetByID(duplicate.ID)
+	assert.NilError(t, err)
+	assert.Equal(t, merged.ID, flavoured.ID)
+}
+
+func TestConsumableModelMergeNutrition(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db, err := newTestDB(t, "recipes")
+	if err != nil {
+		t.Fatal(fmt.Errorf("Failed test db setup: %w", err))
+	}
+
+	m := ConsumableModel{db}
+	recipes := RecipeModel{db}
+
+	before, err := recipes.GetFullRecipe(1, 1)
+	assert.NilError(t, err)
+	beforeNutrition, err := before.Nutrition()
+	assert.NilError(t, err)
+
+	// consumable 17 is San Remo Lasagne Pasta Large, 62.5g with 46.6 carbs, 0.9 fats and 7.9 proteins,
+	// recipe 1 uses 4 of its units. The same pasta given per 250ml at 0.5g per ml replaces it, the step
+	// keeps measuring 4g
+	pasta := Consumable{CreatorID: 4, Name: "Lasagne Pasta", BrandName: "San Remo", Size: 250, Units: "ml", Density: 0.5, Macros: Macronutrients{Carbs: 93.2, Fats: 1.8, Proteins: 15.8}}
+	err = m.Insert(&pasta)
+	assert.NilError(t, err)
+
+	// a pasta given per ml has no density to convert the recipe's grams with
+	measured := Consumable{CreatorID: 4, Name: "Lasagne Pasta Sheets", BrandName: "San Remo", Size: 100, Units: "ml", Macros: Macronutrients{Carbs: 70}}
+	err = m.Insert(&measured)
+	assert.NilError(t, err)
+
+	err = m.Merge(measured.ID, []int64{17}, 1)
+	assert.ExpectError(t, err, ErrIncompatibleMerge)
+
+	err = m.Merge(pasta.ID, []int64{17}, 1)
+	assert.NilError(t, err)
+
+	after, err := recipes.GetFullRecipe(1, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, after.Consumables[0].ID, pasta.ID)
+	assert.Equal(t, after.RecipeComponents[0].Units, MeasurementUnit("g"))
+	afterNutrition, err := after.Nutrition()
+	assert.NilError(t, err)
+
+	for _, pair := range [][2]float64{
+		{beforeNutrition.Total.KJ, afterNutrition.Total.KJ},
+		{beforeNutrition.Total.Macros.Carbs, afterNutrition.Total.Macros.Carbs},
+		{beforeNutrition.Total.Macros.Fats, afterNutrition.Total.Macros.Fats},
+		{beforeNutrition.Total.Macros.Proteins, afterNutrition.Total.Macros.Proteins},
+	} {
+		assert.Equal(t, math.Abs(pair[0]-pair[1]) < 1e-9, true)
+	}
+}
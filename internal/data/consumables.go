@@ -9,6 +9,7 @@ import (
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tconnellan/macro-tracker-backend/internal/conversion"
 	"github.com/tconnellan/macro-tracker-backend/internal/validator"
 )
 
@@ -25,15 +26,44 @@ var (
 	}
 )
 
+// ConsumableVisibility controls who finds a consumable. Private consumables are seen only by their
+// creator, shared ones by every user and verified ones are shared consumables an admin has checked
+type ConsumableVisibility string
+
+const (
+	VisibilityPrivate  ConsumableVisibility = "private"
+	VisibilityShared   ConsumableVisibility = "shared"
+	VisibilityVerified ConsumableVisibility = "verified"
+)
+
+var ValidConsumableVisibilities = []ConsumableVisibility{VisibilityPrivate, VisibilityShared, VisibilityVerified}
+
 type Consumable struct {
-	ID        int64           `json:"id"`
-	CreatorID int64           `json:"creator_id"`
-	CreatedAt time.Time       `json:"created_at"`
-	Name      string          `json:"name"`
-	BrandName string          `json:"brand_name"`
-	Size      float64         `json:"size"`
-	Units     MeasurementUnit `json:"units"`
-	Macros    Macronutrients  `json:"macros"`
+	ID        int64     `json:"id"`
+	CreatorID int64     `json:"creator_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Name      string    `json:"name"`
+	BrandName string    `json:"brand_name"`
+	// Barcode is the GTIN of packaged food zero padded to 14 digits, empty when it has none
+	Barcode string `json:"barcode"`
+	// Visibility defaults to private when a consumable is created without one
+	Visibility ConsumableVisibility `json:"visibility"`
+	Size       float64              `json:"size"`
+	Units      MeasurementUnit      `json:"units"`
+	Macros     Macronutrients       `json:"macros"`
+	// optional weights in grams used to convert between units, zero when unknown
+	Density       float64 `json:"density"`
+	UnitWeight    float64 `json:"unit_weight"`
+	ServingWeight float64 `json:"serving_weight"`
+	// nutrients beyond macronutrients, also given per Size
+	Nutrients NutrientPanel `json:"nutrients"`
+}
+
+// ConsumableMatch is a consumable found by Search, Score is how closely it matches the search plus
+// a boost when the user created it or logged it recently
+type ConsumableMatch struct {
+	Consumable
+	Score float64 `json:"score"`
 }
 
 type ConsumableFilters struct {
@@ -41,6 +71,8 @@ type ConsumableFilters struct {
 	NameSearch                   string
 	BrandNameSearch              string
 	RequireNameAndBrandNameMatch bool
+	// Visibilities limits a search to consumables with one of the visibilities, empty allows all
+	Visibilities []ConsumableVisibility
 }
 
 func (options ConsumableFilters) GetWhereClauseDelimiter() string {
@@ -51,16 +83,213 @@ func (options ConsumableFilters) GetWhereClauseDelimiter() string {
 	}
 }
 
-func ValidateMeasurementUnit(v *validator.Validator, consumable *Consumable) {
-	valid := false
+// orderBy sorts by the sort column, relevance sorts the best match first and -relevance the worst
+func (options ConsumableFilters) orderBy() string {
+	column := options.Metadata.sortColumn()
+	direction := options.Metadata.sortDirection()
+
+	if column == "relevance" {
+		column = "score"
+		if direction == "ASC" {
+			direction = "DESC"
+		} else {
+			direction = "ASC"
+		}
+	}
+
+	return column + " " + direction
+}
+
+// matchClause is the SQL for the consumables matching the name and brand searches. Each search is an
+// OR of predicates the trigram and tsvector indexes on consumables answer, so the matches are found
+// with bitmap index scans rather than by scoring every consumable visible to the user
+func (options ConsumableFilters) matchClause() string {
+	clauses := []string{}
+
+	if strings.TrimSpace(options.NameSearch) != "" {
+		clauses = append(clauses, `(C.name ILIKE ('%' || $1 || '%') OR $1 <% C.name OR to_tsvector('simple', C.name) @@ plainto_tsquery('simple', $1))`)
+	}
+	if strings.TrimSpace(options.BrandNameSearch) != "" {
+		clauses = append(clauses, `(C.brand_name ILIKE ('%' || $2 || '%') OR $2 <% C.brand_name OR to_tsvector('simple', C.brand_name) @@ plainto_tsquery('simple', $2))`)
+	}
+
+	if len(clauses) == 0 {
+		return "TRUE"
+	}
+
+	return "(" + strings.Join(clauses, " "+options.GetWhereClauseDelimiter()+" ") + ")"
+}
+
+// searchQuery is the statement and arguments of Search. Only the consumables matching the searches are
+// scored, see matchClause
+func (options ConsumableFilters) searchQuery(userID int64) (string, []any) {
+	stmt := fmt.Sprintf(`
+	WITH recently_logged AS (
+		SELECT DISTINCT consumable_id
+		FROM consumed
+		WHERE user_id = $5 AND consumable_id IS NOT NULL AND consumed_at > now() - INTERVAL '30 days'
+	), matched AS (
+		SELECT C.id, C.creator_id, C.created_at, C.name, C.brand_name, COALESCE(C.barcode, '') AS barcode, C.visibility, C.size, C.units, C.carbs, C.fats, C.proteins, C.alcohol, C.density, C.unit_weight, C.serving_weight, C.nutrients
+		FROM consumables C
+		WHERE (C.visibility <> 'private' OR C.creator_id = $5)
+		  AND (cardinality($6::TEXT[]) = 0 OR C.visibility = ANY($6::TEXT[]))
+		  AND %s
+	), scored AS (
+		SELECT M.*,
+		CASE WHEN $1 = '' THEN 0
+			ELSE ts_rank(to_tsvector('simple', M.name), plainto_tsquery('simple', $1)) + word_similarity($1, M.name)
+		END
+		+ CASE WHEN $2 = '' THEN 0
+			ELSE ts_rank(to_tsvector('simple', M.brand_name), plainto_tsquery('simple', $2)) + word_similarity($2, M.brand_name)
+		END
+		+ CASE WHEN M.creator_id = $5 THEN 0.5 ELSE 0 END
+		+ CASE WHEN R.consumable_id IS NOT NULL THEN 0.5 ELSE 0 END AS score
+		FROM matched M
+		LEFT JOIN recently_logged R ON R.consumable_id = M.id
+	)
+	SELECT COUNT(*) OVER(), id, creator_id, created_at, name, brand_name, barcode, visibility, size, units, carbs, fats, proteins, alcohol, density, unit_weight, serving_weight, nutrients, score
+	FROM scored
+	ORDER BY %s, id ASC
+	LIMIT $3
+	OFFSET $4
+	`, options.matchClause(), options.orderBy())
+
+	nameSearch := strings.TrimSpace(options.NameSearch)
+	brandNameSearch := strings.TrimSpace(options.BrandNameSearch)
+
+	visibilities := make([]string, len(options.Visibilities))
+	for i, visibility := range options.Visibilities {
+		visibilities[i] = string(visibility)
+	}
+
+	return stmt, []any{
+		nameSearch,
+		brandNameSearch,
+		options.Metadata.pageLimit(),
+		options.Metadata.pageOffset(),
+		userID,
+		visibilities,
+	}
+}
+
+func isValidMeasurementUnit(units MeasurementUnit) bool {
 	for _, unit := range ValidMeasurementUnits {
-		if consumable.Units == unit {
-			valid = true
-			break
+		if units == unit {
+			return true
 		}
 	}
+	return false
+}
+
+func IsValidVisibility(visibility ConsumableVisibility) bool {
+	for _, valid := range ValidConsumableVisibilities {
+		if visibility == valid {
+			return true
+		}
+	}
+	return false
+}
 
-	v.Check(valid, "units", "must be valid")
+// visibilityOrDefault is the visibility a consumable is stored with
+func (consumable *Consumable) visibilityOrDefault() ConsumableVisibility {
+	if consumable.Visibility == "" {
+		return VisibilityPrivate
+	}
+	return consumable.Visibility
+}
+
+// VisibleTo reports whether the user can find the consumable, private consumables are only visible to
+// their creator
+func (consumable *Consumable) VisibleTo(userID int64) bool {
+	return consumable.visibilityOrDefault() != VisibilityPrivate || consumable.CreatorID == userID
+}
+
+func ValidateMeasurementUnit(v *validator.Validator, consumable *Consumable) {
+	v.Check(isValidMeasurementUnit(consumable.Units), "units", "must be valid")
+}
+
+func (consumable *Consumable) conversionProfile() conversion.Profile {
+	return conversion.Profile{
+		DensityGPerML:  consumable.Density,
+		UnitWeightG:    consumable.UnitWeight,
+		ServingWeightG: consumable.ServingWeight,
+	}
+}
+
+// ConvertToUnits expresses amount of units in the consumable's own Units
+func (consumable *Consumable) ConvertToUnits(amount float64, units MeasurementUnit) (float64, error) {
+	return conversion.Convert(amount, conversion.Unit(units), conversion.Unit(consumable.Units), consumable.conversionProfile())
+}
+
+// scaleFactor is the multiple of Size that amount of units represents
+func (consumable *Consumable) scaleFactor(amount float64, units MeasurementUnit) (float64, error) {
+	if consumable.Size <= 0 {
+		return 0, ErrIncompatibleUnits
+	}
+
+	converted, err := consumable.ConvertToUnits(amount, units)
+	if err != nil {
+		return 0, err
+	}
+
+	return converted / consumable.Size, nil
+}
+
+// MacrosFor scales the consumable's macros, given per Size, to the amount in units
+func (consumable *Consumable) MacrosFor(amount float64, units MeasurementUnit) (Macronutrients, error) {
+	factor, err := consumable.scaleFactor(amount, units)
+	if err != nil {
+		return Macronutrients{}, err
+	}
+
+	return consumable.Macros.Scale(factor), nil
+}
+
+// NutrientsFor scales the consumable's nutrient panel, given per Size, to the amount in units
+func (consumable *Consumable) NutrientsFor(amount float64, units MeasurementUnit) (NutrientPanel, error) {
+	factor, err := consumable.scaleFactor(amount, units)
+	if err != nil {
+		return NutrientPanel{}, err
+	}
+
+	return consumable.Nutrients.Scale(factor), nil
+}
+
+// ValidGTIN reports whether code is an 8, 12, 13 or 14 digit GTIN, such as an EAN-13 or UPC-A barcode,
+// with a correct check digit
+func ValidGTIN(code string) bool {
+	switch len(code) {
+	case 8, 12, 13, 14:
+	default:
+		return false
+	}
+
+	sum := 0
+	for i := 0; i < len(code); i++ {
+		if code[i] < '0' || code[i] > '9' {
+			return false
+		}
+		// digits are weighted 3 and 1 alternately from the right, starting after the check digit
+		digit := int(code[len(code)-1-i] - '0')
+		switch {
+		case i == 0:
+		case i%2 == 1:
+			sum += 3 * digit
+		default:
+			sum += digit
+		}
+	}
+
+	return int(code[len(code)-1]-'0') == (10-sum%10)%10
+}
+
+// NormaliseGTIN zero pads a GTIN to 14 digits so a product has one barcode whichever format it was
+// scanned in, an empty code stays empty
+func NormaliseGTIN(code string) string {
+	if code == "" {
+		return ""
+	}
+	return strings.Repeat("0", max(14-len(code), 0)) + code
 }
 
 func ValidateConsumable(v *validator.Validator, consumable *Consumable) {
@@ -70,11 +299,20 @@ func ValidateConsumable(v *validator.Validator, consumable *Consumable) {
 	v.Check(consumable.BrandName != "", "brand_name", "must be provided")
 	v.Check(len(consumable.BrandName) <= 50, "brand_name", "must be maximum 50 characters")
 
+	v.Check(consumable.Barcode == "" || ValidGTIN(consumable.Barcode), "barcode", "must be a valid 8, 12, 13 or 14 digit GTIN")
+
+	v.Check(consumable.Visibility == "" || IsValidVisibility(consumable.Visibility), "visibility", "must be private, shared or verified")
+
 	v.Check(consumable.Size > 0, "size", "must be positive")
 
 	ValidateMeasurementUnit(v, consumable)
 
+	v.Check(consumable.Density >= 0, "density", "must be non-negative")
+	v.Check(consumable.UnitWeight >= 0, "unit_weight", "must be non-negative")
+	v.Check(consumable.ServingWeight >= 0, "serving_weight", "must be non-negative")
+
 	ValidateMacroNutrients(v, consumable.Macros)
+	ValidateNutrientPanel(v, consumable.Nutrients)
 }
 
 type ConsumableModel struct {
@@ -83,17 +321,23 @@ type ConsumableModel struct {
 
 type IConsumableModel interface {
 	GetByID(int64) (*Consumable, error)
+	GetByBarcode(string, int64) (*Consumable, error)
 	GetByCreatorID(int64, ConsumableFilters) ([]*Consumable, Metadata, error)
-	Search(ConsumableFilters) ([]*Consumable, Metadata, error)
+	Search(int64, ConsumableFilters) ([]*ConsumableMatch, Metadata, error)
 	Insert(*Consumable) error
-	Update(*Consumable) error
-	Delete(int64) error
+	Update(*Consumable, int64) error
+	SetVisibility(int64, ConsumableVisibility) error
+	Delete(int64, int64) error
+	FindDuplicates(int64, int64) ([]*ConsumableDuplicate, error)
+	Merge(int64, []int64, int64) error
 }
 
+// GetByID returns the consumable with the ID, the ID of a consumable merged into another resolves to
+// the consumable it was merged into
 func (m ConsumableModel) GetByID(ID int64) (*Consumable, error) {
-	stmt := `SELECT id, creator_id, created_at, name, brand_name, size, units, carbs, fats, proteins, alcohol
+	stmt := `SELECT id, creator_id, created_at, name, brand_name, COALESCE(barcode, ''), visibility, size, units, carbs, fats, proteins, alcohol, density, unit_weight, serving_weight, nutrients
 	FROM consumables
-	WHERE id = $1`
+	WHERE id = COALESCE((SELECT consumable_id FROM consumable_aliases WHERE alias_id = $1), $1)`
 
 	ctx, cancel := GetDefaultTimeoutContext()
 	defer cancel()
@@ -106,12 +350,18 @@ func (m ConsumableModel) GetByID(ID int64) (*Consumable, error) {
 		&consumable.CreatedAt,
 		&consumable.Name,
 		&consumable.BrandName,
+		&consumable.Barcode,
+		&consumable.Visibility,
 		&consumable.Size,
 		&consumable.Units,
 		&consumable.Macros.Carbs,
 		&consumable.Macros.Fats,
 		&consumable.Macros.Proteins,
 		&consumable.Macros.Alcohol,
+		&consumable.Density,
+		&consumable.UnitWeight,
+		&consumable.ServingWeight,
+		&consumable.Nutrients,
 	)
 
 	if err != nil {
@@ -127,6 +377,52 @@ func (m ConsumableModel) GetByID(ID int64) (*Consumable, error) {
 	return &consumable, nil
 }
 
+// GetByBarcode looks up a consumable visible to the user by a scanned GTIN in any of its formats. A
+// barcode is unique to a brand, when brands share one the user's own is returned before the first created
+func (m ConsumableModel) GetByBarcode(code string, userID int64) (*Consumable, error) {
+	stmt := `SELECT id, creator_id, created_at, name, brand_name, COALESCE(barcode, ''), visibility, size, units, carbs, fats, proteins, alcohol, density, unit_weight, serving_weight, nutrients
+	FROM consumables
+	WHERE barcode = $1 AND (visibility <> 'private' OR creator_id = $2)
+	ORDER BY creator_id = $2 DESC, id ASC
+	LIMIT 1`
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	var consumable Consumable
+
+	err := m.DB.QueryRow(ctx, stmt, NormaliseGTIN(code), userID).Scan(
+		&consumable.ID,
+		&consumable.CreatorID,
+		&consumable.CreatedAt,
+		&consumable.Name,
+		&consumable.BrandName,
+		&consumable.Barcode,
+		&consumable.Visibility,
+		&consumable.Size,
+		&consumable.Units,
+		&consumable.Macros.Carbs,
+		&consumable.Macros.Fats,
+		&consumable.Macros.Proteins,
+		&consumable.Macros.Alcohol,
+		&consumable.Density,
+		&consumable.UnitWeight,
+		&consumable.ServingWeight,
+		&consumable.Nutrients,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &consumable, nil
+}
+
 func (m ConsumableModel) readConsumableRows(stmt string, ctx context.Context, args ...any) ([]*Consumable, int, error) {
 
 	rows, err := m.DB.Query(ctx, stmt, args...)
@@ -147,12 +443,18 @@ func (m ConsumableModel) readConsumableRows(stmt string, ctx context.Context, ar
 			&consumable.CreatedAt,
 			&consumable.Name,
 			&consumable.BrandName,
+			&consumable.Barcode,
+			&consumable.Visibility,
 			&consumable.Size,
 			&consumable.Units,
 			&consumable.Macros.Carbs,
 			&consumable.Macros.Fats,
 			&consumable.Macros.Proteins,
 			&consumable.Macros.Alcohol,
+			&consumable.Density,
+			&consumable.UnitWeight,
+			&consumable.ServingWeight,
+			&consumable.Nutrients,
 		)
 		if err != nil {
 			return nil, 0, err
@@ -169,7 +471,7 @@ func (m ConsumableModel) readConsumableRows(stmt string, ctx context.Context, ar
 
 func (m ConsumableModel) GetByCreatorID(ID int64, filters ConsumableFilters) ([]*Consumable, Metadata, error) {
 	stmt := fmt.Sprintf(`
-	SELECT COUNT(*) OVER(), id, creator_id, created_at, name, brand_name, size, units, carbs, fats, proteins, alcohol
+	SELECT COUNT(*) OVER(), id, creator_id, created_at, name, brand_name, COALESCE(barcode, ''), visibility, size, units, carbs, fats, proteins, alcohol, density, unit_weight, serving_weight, nutrients
 	FROM consumables
 	WHERE creator_id = $1
 	ORDER BY %s %s, id ASC
@@ -188,32 +490,69 @@ func (m ConsumableModel) GetByCreatorID(ID int64, filters ConsumableFilters) ([]
 	return consumables, calculateMetadata(recordCount, filters.Metadata.Page, filters.Metadata.PageSize), nil
 }
 
-func (m ConsumableModel) Search(filters ConsumableFilters) ([]*Consumable, Metadata, error) {
-	stmt := fmt.Sprintf(`
-	SELECT COUNT(*) OVER(), id, creator_id, created_at, name, brand_name, size, units, carbs, fats, proteins, alcohol
-	FROM consumables
-	WHERE ($1 = '' OR to_tsvector('simple', name) @@ plainto_tsquery('simple', $1))
-	   %s ($2 = '' OR to_tsvector('simple', brand_name) @@ plainto_tsquery('simple', $2))
-	ORDER BY %s %s, id ASC
-	LIMIT $3
-	OFFSET $4
-	`, filters.GetWhereClauseDelimiter(), filters.Metadata.sortColumn(), filters.Metadata.sortDirection())
+// Search finds consumables visible to the user by name and brand. A search term matches a word it is
+// part of, such as "chick" in "Chicken Breast", or one it is close to by trigram similarity so
+// misspellings like "bananna" are still found. Matches are scored by ts_rank plus similarity for each
+// term, with a boost for consumables the user created and those they logged in the last 30 days
+func (m ConsumableModel) Search(userID int64, filters ConsumableFilters) ([]*ConsumableMatch, Metadata, error) {
+	stmt, args := filters.searchQuery(userID)
 
 	ctx, cancel := GetDefaultTimeoutContext()
 	defer cancel()
 
-	consumables, recordCount, err := m.readConsumableRows(stmt, ctx, filters.NameSearch, filters.BrandNameSearch, filters.Metadata.pageLimit(), filters.Metadata.pageOffset())
+	rows, err := m.DB.Query(ctx, stmt, args...)
 	if err != nil {
 		return nil, Metadata{}, err
 	}
+	defer rows.Close()
 
-	return consumables, calculateMetadata(recordCount, filters.Metadata.Page, filters.Metadata.PageSize), nil
+	recordCount := 0
+	matches := []*ConsumableMatch{}
+
+	for rows.Next() {
+		var match ConsumableMatch
+		err = rows.Scan(
+			&recordCount,
+			&match.ID,
+			&match.CreatorID,
+			&match.CreatedAt,
+			&match.Name,
+			&match.BrandName,
+			&match.Barcode,
+			&match.Visibility,
+			&match.Size,
+			&match.Units,
+			&match.Macros.Carbs,
+			&match.Macros.Fats,
+			&match.Macros.Proteins,
+			&match.Macros.Alcohol,
+			&match.Density,
+			&match.UnitWeight,
+			&match.ServingWeight,
+			&match.Nutrients,
+			&match.Score,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		matches = append(matches, &match)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return matches, calculateMetadata(recordCount, filters.Metadata.Page, filters.Metadata.PageSize), nil
 }
 
+// Insert stores the consumable with its barcode normalised to 14 digits, private unless it has a visibility
 func (m ConsumableModel) Insert(consumable *Consumable) error {
+	consumable.Barcode = NormaliseGTIN(consumable.Barcode)
+	consumable.Visibility = consumable.visibilityOrDefault()
+
 	stmt := `
-	INSERT INTO consumables (creator_id, name, brand_name, size, units, carbs, fats, proteins, alcohol)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	INSERT INTO consumables (creator_id, name, brand_name, size, units, carbs, fats, proteins, alcohol, density, unit_weight, serving_weight, nutrients, barcode, visibility)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, NULLIF($14, ''), $15)
 	RETURNING id, created_at
 	`
 
@@ -230,29 +569,45 @@ func (m ConsumableModel) Insert(consumable *Consumable) error {
 		consumable.Macros.Fats,
 		consumable.Macros.Proteins,
 		consumable.Macros.Alcohol,
+		consumable.Density,
+		consumable.UnitWeight,
+		consumable.ServingWeight,
+		consumable.Nutrients,
+		consumable.Barcode,
+		consumable.Visibility,
 	}
 
 	if err := m.DB.QueryRow(ctx, stmt, args...).Scan(&consumable.ID, &consumable.CreatedAt); err != nil {
-		if strings.HasPrefix(err.Error(), `ERROR: insert or update on table "consumables" violates foreign key constraint "fk_consumable_creator"`) {
+		switch {
+		case strings.HasPrefix(err.Error(), `ERROR: insert or update on table "consumables" violates foreign key constraint "fk_consumable_creator"`):
 			return ErrReferencedUserDoesNotExist
+		case strings.HasPrefix(err.Error(), `ERROR: duplicate key value violates unique constraint "consumables_brand_barcode_key"`):
+			return ErrDuplicateBarcode
+		default:
+			return err
 		}
-		return err
 	}
 
 	return nil
 }
 
-func (m ConsumableModel) Update(consumable *Consumable) error {
+// Update changes one of the user's consumables, visibility is changed by SetVisibility. Edits to shared
+// consumables by users who are not admins go through ConsumableEditModel for review instead
+func (m ConsumableModel) Update(consumable *Consumable, userID int64) error {
+	consumable.Barcode = NormaliseGTIN(consumable.Barcode)
+
 	stmt := `
 	UPDATE consumables
-	SET name = $2, brand_name = $3, size = $4, units = $5, carbs = $6, fats = $7, proteins = $8, alcohol = $9
-	WHERE id = $1
+	SET name = $2, brand_name = $3, size = $4, units = $5, carbs = $6, fats = $7, proteins = $8, alcohol = $9,
+	    density = $10, unit_weight = $11, serving_weight = $12, nutrients = $13, barcode = NULLIF($14, '')
+	WHERE id = $1 AND creator_id = $15
 	`
 
 	ctx, cancel := GetDefaultTimeoutContext()
 	defer cancel()
 
 	args := []any{
+		consumable.ID,
 		consumable.Name,
 		consumable.BrandName,
 		consumable.Size,
@@ -261,11 +616,22 @@ func (m ConsumableModel) Update(consumable *Consumable) error {
 		consumable.Macros.Fats,
 		consumable.Macros.Proteins,
 		consumable.Macros.Alcohol,
+		consumable.Density,
+		consumable.UnitWeight,
+		consumable.ServingWeight,
+		consumable.Nutrients,
+		consumable.Barcode,
+		userID,
 	}
 
 	result, err := m.DB.Exec(ctx, stmt, args...)
 	if err != nil {
-		return err
+		switch {
+		case strings.HasPrefix(err.Error(), `ERROR: duplicate key value violates unique constraint "consumables_brand_barcode_key"`):
+			return ErrDuplicateBarcode
+		default:
+			return err
+		}
 	}
 
 	rows := result.RowsAffected()
@@ -277,16 +643,40 @@ func (m ConsumableModel) Update(consumable *Consumable) error {
 	return nil
 }
 
-func (m ConsumableModel) Delete(ID int64) error {
+// SetVisibility changes who can find a consumable, callers check the user may change it
+func (m ConsumableModel) SetVisibility(ID int64, visibility ConsumableVisibility) error {
 	stmt := `
-	DELETE FROM consumables
+	UPDATE consumables
+	SET visibility = $2
 	WHERE id = $1
 	`
 
 	ctx, cancel := GetDefaultTimeoutContext()
 	defer cancel()
 
-	result, err := m.DB.Exec(ctx, stmt, ID)
+	result, err := m.DB.Exec(ctx, stmt, ID, visibility)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Delete removes one of the user's consumables
+func (m ConsumableModel) Delete(ID int64, userID int64) error {
+	stmt := `
+	DELETE FROM consumables
+	WHERE id = $1 AND creator_id = $2
+	`
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	result, err := m.DB.Exec(ctx, stmt, ID, userID)
 	if err != nil {
 		return err
 	}
@@ -2,8 +2,11 @@ package data
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/jackc/pgx/v5"
+
 	"github.com/tconnellan/macro-tracker-backend/internal/assert"
 	"github.com/tconnellan/macro-tracker-backend/internal/validator"
 )
@@ -283,6 +286,58 @@ func TestConsumableHelpers(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:  "valid consumable barcode",
+			valid: true,
+			consumable: Consumable{
+				CreatorID: 1,
+				Name:      "Oats",
+				BrandName: "Uncle Tobys",
+				Barcode:   "4006381333931",
+				Size:      100,
+				Units:     "g",
+				Macros:    Macronutrients{Carbs: 40},
+			},
+		},
+		{
+			name:  "invalid consumable barcode check digit",
+			valid: false,
+			consumable: Consumable{
+				CreatorID: 1,
+				Name:      "Oats",
+				BrandName: "Uncle Tobys",
+				Barcode:   "4006381333932",
+				Size:      100,
+				Units:     "g",
+				Macros:    Macronutrients{Carbs: 40},
+			},
+		},
+		{
+			name:  "valid consumable visibility",
+			valid: true,
+			consumable: Consumable{
+				CreatorID:  1,
+				Name:       "Oats",
+				BrandName:  "Uncle Tobys",
+				Visibility: VisibilityVerified,
+				Size:       100,
+				Units:      "g",
+				Macros:     Macronutrients{Carbs: 40},
+			},
+		},
+		{
+			name:  "invalid consumable visibility",
+			valid: false,
+			consumable: Consumable{
+				CreatorID:  1,
+				Name:       "Oats",
+				BrandName:  "Uncle Tobys",
+				Visibility: "public",
+				Size:       100,
+				Units:      "g",
+				Macros:     Macronutrients{Carbs: 40},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -294,6 +349,102 @@ func TestConsumableHelpers(t *testing.T) {
 	}
 }
 
+func TestValidGTIN(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		code   string
+		expect bool
+	}{
+		{name: "valid ean-13", code: "4006381333931", expect: true},
+		{name: "valid upc-a", code: "036000291452", expect: true},
+		{name: "valid ean-8", code: "96385074", expect: true},
+		{name: "valid gtin-14", code: "04006381333931", expect: true},
+		{name: "invalid check digit", code: "4006381333932", expect: false},
+		{name: "invalid length", code: "400638133393", expect: false},
+		{name: "invalid not digits", code: "40063813339a1", expect: false},
+		{name: "invalid empty", code: "", expect: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, ValidGTIN(tt.code), tt.expect)
+		})
+	}
+
+	t.Run("normalise", func(t *testing.T) {
+		assert.Equal(t, NormaliseGTIN("96385074"), "00000096385074")
+		assert.Equal(t, NormaliseGTIN("4006381333931"), "04006381333931")
+		assert.Equal(t, NormaliseGTIN(""), "")
+	})
+}
+
+func TestConsumableMacrosFor(t *testing.T) {
+
+	consumable := Consumable{
+		Size:    200,
+		Units:   "g",
+		Density: 2,
+		Macros: Macronutrients{
+			Carbs:    40,
+			Fats:     10,
+			Proteins: 20,
+			Alcohol:  0,
+		},
+	}
+
+	gramsPerPound := 453.59237
+
+	tests := []struct {
+		name         string
+		amount       float64
+		units        MeasurementUnit
+		expectMacros Macronutrients
+		expectError  error
+	}{
+		{
+			name:         "same units",
+			amount:       50,
+			units:        "g",
+			expectMacros: Macronutrients{Carbs: 10, Fats: 2.5, Proteins: 5, Alcohol: 0},
+			expectError:  nil,
+		},
+		{
+			name:         "mass units",
+			amount:       1,
+			units:        "lb",
+			expectMacros: consumable.Macros.Scale(gramsPerPound / consumable.Size),
+			expectError:  nil,
+		},
+		{
+			name:         "volume with density",
+			amount:       100,
+			units:        "ml",
+			expectMacros: Macronutrients{Carbs: 40, Fats: 10, Proteins: 20, Alcohol: 0},
+			expectError:  nil,
+		},
+		{
+			name:        "units without unit weight",
+			amount:      1,
+			units:       "units",
+			expectError: ErrIncompatibleUnits,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			macros, err := consumable.MacrosFor(tt.amount, tt.units)
+
+			assert.ExpectError(t, err, tt.expectError)
+			if err != nil {
+				return
+			}
+
+			assert.Equal(t, macros, tt.expectMacros)
+		})
+	}
+}
+
 func TestConsumableModelGetByID(t *testing.T) {
 
 	if testing.Short() {
@@ -314,13 +465,14 @@ func TestConsumableModelGetByID(t *testing.T) {
 			ID:        1,
 			wantError: nil,
 			wantConsumable: Consumable{
-				ID:        1,
-				CreatorID: 1,
-				CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-				Name:      "Oats",
-				BrandName: "Uncle Tobys",
-				Size:      100,
-				Units:     "g",
+				ID:         1,
+				CreatorID:  1,
+				CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+				Name:       "Oats",
+				BrandName:  "Uncle Tobys",
+				Visibility: VisibilityShared,
+				Size:       100,
+				Units:      "g",
 				Macros: Macronutrients{
 					Carbs:    40,
 					Fats:     0.5,
@@ -402,13 +554,14 @@ func TestGetByCreatorID(t *testing.T) {
 			},
 			wantConsumables: []*Consumable{
 				{
-					ID:        1,
-					CreatorID: 1,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Oats",
-					BrandName: "Uncle Tobys",
-					Size:      100,
-					Units:     "g",
+					ID:         1,
+					CreatorID:  1,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Oats",
+					BrandName:  "Uncle Tobys",
+					Visibility: VisibilityShared,
+					Size:       100,
+					Units:      "g",
 					Macros: Macronutrients{
 						Carbs:    40,
 						Fats:     0.5,
@@ -417,13 +570,14 @@ func TestGetByCreatorID(t *testing.T) {
 					},
 				},
 				{
-					ID:        2,
-					CreatorID: 1,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Cavendish Banana",
-					BrandName: "Coles",
-					Size:      100,
-					Units:     "g",
+					ID:         2,
+					CreatorID:  1,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Cavendish Banana",
+					BrandName:  "Coles",
+					Visibility: VisibilityShared,
+					Size:       100,
+					Units:      "g",
 					Macros: Macronutrients{
 						Carbs:    38,
 						Fats:     0.1,
@@ -432,13 +586,14 @@ func TestGetByCreatorID(t *testing.T) {
 					},
 				},
 				{
-					ID:        3,
-					CreatorID: 1,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Greek Yogurt",
-					BrandName: "Jalna",
-					Size:      90,
-					Units:     "g",
+					ID:         3,
+					CreatorID:  1,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Greek Yogurt",
+					BrandName:  "Jalna",
+					Visibility: VisibilityShared,
+					Size:       90,
+					Units:      "g",
 					Macros: Macronutrients{
 						Carbs:    3.8,
 						Fats:     5.0,
@@ -447,13 +602,14 @@ func TestGetByCreatorID(t *testing.T) {
 					},
 				},
 				{
-					ID:        4,
-					CreatorID: 1,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Wholemeal Bread",
-					BrandName: "Tip Top",
-					Size:      110,
-					Units:     "g",
+					ID:         4,
+					CreatorID:  1,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Wholemeal Bread",
+					BrandName:  "Tip Top",
+					Visibility: VisibilityShared,
+					Size:       110,
+					Units:      "g",
 					Macros: Macronutrients{
 						Carbs:    41.8,
 						Fats:     2.2,
@@ -462,13 +618,14 @@ func TestGetByCreatorID(t *testing.T) {
 					},
 				},
 				{
-					ID:        5,
-					CreatorID: 1,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Red Apple",
-					BrandName: "Aldi",
-					Size:      95,
-					Units:     "g",
+					ID:         5,
+					CreatorID:  1,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Red Apple",
+					BrandName:  "Aldi",
+					Visibility: VisibilityShared,
+					Size:       95,
+					Units:      "g",
 					Macros: Macronutrients{
 						Carbs:    14.0,
 						Fats:     0.2,
@@ -477,13 +634,14 @@ func TestGetByCreatorID(t *testing.T) {
 					},
 				},
 				{
-					ID:        6,
-					CreatorID: 1,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Chicken Breast",
-					BrandName: "IGA",
-					Size:      105,
-					Units:     "g",
+					ID:         6,
+					CreatorID:  1,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Chicken Breast",
+					BrandName:  "IGA",
+					Visibility: VisibilityShared,
+					Size:       105,
+					Units:      "g",
 					Macros: Macronutrients{
 						Carbs:    0,
 						Fats:     2.6,
@@ -517,13 +675,14 @@ func TestGetByCreatorID(t *testing.T) {
 			},
 			wantConsumables: []*Consumable{
 				{
-					ID:        7,
-					CreatorID: 2,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Almond Milk",
-					BrandName: "Vitasoy",
-					Size:      250,
-					Units:     "ml",
+					ID:         7,
+					CreatorID:  2,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Almond Milk",
+					BrandName:  "Vitasoy",
+					Visibility: VisibilityShared,
+					Size:       250,
+					Units:      "ml",
 					Macros: Macronutrients{
 						Carbs:    0.8,
 						Fats:     1.2,
@@ -532,13 +691,14 @@ func TestGetByCreatorID(t *testing.T) {
 					},
 				},
 				{
-					ID:        8,
-					CreatorID: 2,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Sweet Potato",
-					BrandName: "Woolworths",
-					Size:      150,
-					Units:     "g",
+					ID:         8,
+					CreatorID:  2,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Sweet Potato",
+					BrandName:  "Woolworths",
+					Visibility: VisibilityShared,
+					Size:       150,
+					Units:      "g",
 					Macros: Macronutrients{
 						Carbs:    27.5,
 						Fats:     0.1,
@@ -547,13 +707,14 @@ func TestGetByCreatorID(t *testing.T) {
 					},
 				},
 				{
-					ID:        9,
-					CreatorID: 2,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Salmon Fillet",
-					BrandName: "Tassal",
-					Size:      125,
-					Units:     "g",
+					ID:         9,
+					CreatorID:  2,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Salmon Fillet",
+					BrandName:  "Tassal",
+					Visibility: VisibilityShared,
+					Size:       125,
+					Units:      "g",
 					Macros: Macronutrients{
 						Carbs:    0,
 						Fats:     12.5,
@@ -562,13 +723,14 @@ func TestGetByCreatorID(t *testing.T) {
 					},
 				},
 				{
-					ID:        10,
-					CreatorID: 2,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Quinoa",
-					BrandName: "Coles",
-					Size:      85,
-					Units:     "g",
+					ID:         10,
+					CreatorID:  2,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Quinoa",
+					BrandName:  "Coles",
+					Visibility: VisibilityShared,
+					Size:       85,
+					Units:      "g",
 					Macros: Macronutrients{
 						Carbs:    15.6,
 						Fats:     2.4,
@@ -577,13 +739,14 @@ func TestGetByCreatorID(t *testing.T) {
 					},
 				},
 				{
-					ID:        11,
-					CreatorID: 2,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Red Wine",
-					BrandName: "Penfolds",
-					Size:      150,
-					Units:     "ml",
+					ID:         11,
+					CreatorID:  2,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Red Wine",
+					BrandName:  "Penfolds",
+					Visibility: VisibilityShared,
+					Size:       150,
+					Units:      "ml",
 					Macros: Macronutrients{
 						Carbs:    3.8,
 						Fats:     0,
@@ -617,13 +780,14 @@ func TestGetByCreatorID(t *testing.T) {
 			},
 			wantConsumables: []*Consumable{
 				{
-					ID:        1,
-					CreatorID: 1,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Oats",
-					BrandName: "Uncle Tobys",
-					Size:      100,
-					Units:     "g",
+					ID:         1,
+					CreatorID:  1,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Oats",
+					BrandName:  "Uncle Tobys",
+					Visibility: VisibilityShared,
+					Size:       100,
+					Units:      "g",
 					Macros: Macronutrients{
 						Carbs:    40,
 						Fats:     0.5,
@@ -632,13 +796,14 @@ func TestGetByCreatorID(t *testing.T) {
 					},
 				},
 				{
-					ID:        2,
-					CreatorID: 1,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Cavendish Banana",
-					BrandName: "Coles",
-					Size:      100,
-					Units:     "g",
+					ID:         2,
+					CreatorID:  1,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Cavendish Banana",
+					BrandName:  "Coles",
+					Visibility: VisibilityShared,
+					Size:       100,
+					Units:      "g",
 					Macros: Macronutrients{
 						Carbs:    38,
 						Fats:     0.1,
@@ -672,13 +837,14 @@ func TestGetByCreatorID(t *testing.T) {
 			},
 			wantConsumables: []*Consumable{
 				{
-					ID:        3,
-					CreatorID: 1,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Greek Yogurt",
-					BrandName: "Jalna",
-					Size:      90,
-					Units:     "g",
+					ID:         3,
+					CreatorID:  1,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Greek Yogurt",
+					BrandName:  "Jalna",
+					Visibility: VisibilityShared,
+					Size:       90,
+					Units:      "g",
 					Macros: Macronutrients{
 						Carbs:    3.8,
 						Fats:     5.0,
@@ -687,13 +853,14 @@ func TestGetByCreatorID(t *testing.T) {
 					},
 				},
 				{
-					ID:        4,
-					CreatorID: 1,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Wholemeal Bread",
-					BrandName: "Tip Top",
-					Size:      110,
-					Units:     "g",
+					ID:         4,
+					CreatorID:  1,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Wholemeal Bread",
+					BrandName:  "Tip Top",
+					Visibility: VisibilityShared,
+					Size:       110,
+					Units:      "g",
 					Macros: Macronutrients{
 						Carbs:    41.8,
 						Fats:     2.2,
@@ -803,13 +970,14 @@ func TestConsumableModelSearch(t *testing.T) {
 			},
 			wantConsumables: []*Consumable{
 				{
-					ID:        7,
-					CreatorID: 2,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Almond Milk",
-					BrandName: "Vitasoy",
-					Size:      250,
-					Units:     "ml",
+					ID:         7,
+					CreatorID:  2,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Almond Milk",
+					BrandName:  "Vitasoy",
+					Visibility: VisibilityShared,
+					Size:       250,
+					Units:      "ml",
 					Macros: Macronutrients{
 						Carbs:    0.8,
 						Fats:     1.2,
@@ -818,13 +986,14 @@ func TestConsumableModelSearch(t *testing.T) {
 					},
 				},
 				{
-					ID:        12,
-					CreatorID: 3,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Full Cream Milk",
-					BrandName: "Dairy Farmers",
-					Size:      250,
-					Units:     "ml",
+					ID:         12,
+					CreatorID:  3,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Full Cream Milk",
+					BrandName:  "Dairy Farmers",
+					Visibility: VisibilityShared,
+					Size:       250,
+					Units:      "ml",
 					Macros: Macronutrients{
 						Carbs:    12.5,
 						Fats:     8.8,
@@ -833,13 +1002,14 @@ func TestConsumableModelSearch(t *testing.T) {
 					},
 				},
 				{
-					ID:        13,
-					CreatorID: 3,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Skim Milk",
-					BrandName: "Pauls",
-					Size:      250,
-					Units:     "ml",
+					ID:         13,
+					CreatorID:  3,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Skim Milk",
+					BrandName:  "Pauls",
+					Visibility: VisibilityShared,
+					Size:       250,
+					Units:      "ml",
 					Macros: Macronutrients{
 						Carbs:    12.8,
 						Fats:     0.3,
@@ -848,13 +1018,14 @@ func TestConsumableModelSearch(t *testing.T) {
 					},
 				},
 				{
-					ID:        14,
-					CreatorID: 3,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Soy Milk",
-					BrandName: "Sanitarium",
-					Size:      250,
-					Units:     "ml",
+					ID:         14,
+					CreatorID:  3,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Soy Milk",
+					BrandName:  "Sanitarium",
+					Visibility: VisibilityShared,
+					Size:       250,
+					Units:      "ml",
 					Macros: Macronutrients{
 						Carbs:    5.5,
 						Fats:     3.2,
@@ -863,13 +1034,14 @@ func TestConsumableModelSearch(t *testing.T) {
 					},
 				},
 				{
-					ID:        15,
-					CreatorID: 3,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Oat Milk",
-					BrandName: "Oatly",
-					Size:      250,
-					Units:     "ml",
+					ID:         15,
+					CreatorID:  3,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Oat Milk",
+					BrandName:  "Oatly",
+					Visibility: VisibilityShared,
+					Size:       250,
+					Units:      "ml",
 					Macros: Macronutrients{
 						Carbs:    16.0,
 						Fats:     3.0,
@@ -878,13 +1050,14 @@ func TestConsumableModelSearch(t *testing.T) {
 					},
 				},
 				{
-					ID:        16,
-					CreatorID: 3,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Coconut Milk",
-					BrandName: "Pure Harvest",
-					Size:      250,
-					Units:     "ml",
+					ID:         16,
+					CreatorID:  3,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Coconut Milk",
+					BrandName:  "Pure Harvest",
+					Visibility: VisibilityShared,
+					Size:       250,
+					Units:      "ml",
 					Macros: Macronutrients{
 						Carbs:    3.0,
 						Fats:     5.0,
@@ -917,13 +1090,14 @@ func TestConsumableModelSearch(t *testing.T) {
 			},
 			wantConsumables: []*Consumable{
 				{
-					ID:        5,
-					CreatorID: 1,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Red Apple",
-					BrandName: "Aldi",
-					Size:      95,
-					Units:     "g",
+					ID:         5,
+					CreatorID:  1,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Red Apple",
+					BrandName:  "Aldi",
+					Visibility: VisibilityShared,
+					Size:       95,
+					Units:      "g",
 					Macros: Macronutrients{
 						Carbs:    14.0,
 						Fats:     0.2,
@@ -932,13 +1106,14 @@ func TestConsumableModelSearch(t *testing.T) {
 					},
 				},
 				{
-					ID:        7,
-					CreatorID: 2,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Almond Milk",
-					BrandName: "Vitasoy",
-					Size:      250,
-					Units:     "ml",
+					ID:         7,
+					CreatorID:  2,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Almond Milk",
+					BrandName:  "Vitasoy",
+					Visibility: VisibilityShared,
+					Size:       250,
+					Units:      "ml",
 					Macros: Macronutrients{
 						Carbs:    0.8,
 						Fats:     1.2,
@@ -947,13 +1122,14 @@ func TestConsumableModelSearch(t *testing.T) {
 					},
 				},
 				{
-					ID:        12,
-					CreatorID: 3,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Full Cream Milk",
-					BrandName: "Dairy Farmers",
-					Size:      250,
-					Units:     "ml",
+					ID:         12,
+					CreatorID:  3,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Full Cream Milk",
+					BrandName:  "Dairy Farmers",
+					Visibility: VisibilityShared,
+					Size:       250,
+					Units:      "ml",
 					Macros: Macronutrients{
 						Carbs:    12.5,
 						Fats:     8.8,
@@ -962,13 +1138,14 @@ func TestConsumableModelSearch(t *testing.T) {
 					},
 				},
 				{
-					ID:        13,
-					CreatorID: 3,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Skim Milk",
-					BrandName: "Pauls",
-					Size:      250,
-					Units:     "ml",
+					ID:         13,
+					CreatorID:  3,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Skim Milk",
+					BrandName:  "Pauls",
+					Visibility: VisibilityShared,
+					Size:       250,
+					Units:      "ml",
 					Macros: Macronutrients{
 						Carbs:    12.8,
 						Fats:     0.3,
@@ -977,13 +1154,14 @@ func TestConsumableModelSearch(t *testing.T) {
 					},
 				},
 				{
-					ID:        14,
-					CreatorID: 3,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Soy Milk",
-					BrandName: "Sanitarium",
-					Size:      250,
-					Units:     "ml",
+					ID:         14,
+					CreatorID:  3,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Soy Milk",
+					BrandName:  "Sanitarium",
+					Visibility: VisibilityShared,
+					Size:       250,
+					Units:      "ml",
 					Macros: Macronutrients{
 						Carbs:    5.5,
 						Fats:     3.2,
@@ -992,13 +1170,14 @@ func TestConsumableModelSearch(t *testing.T) {
 					},
 				},
 				{
-					ID:        15,
-					CreatorID: 3,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Oat Milk",
-					BrandName: "Oatly",
-					Size:      250,
-					Units:     "ml",
+					ID:         15,
+					CreatorID:  3,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Oat Milk",
+					BrandName:  "Oatly",
+					Visibility: VisibilityShared,
+					Size:       250,
+					Units:      "ml",
 					Macros: Macronutrients{
 						Carbs:    16.0,
 						Fats:     3.0,
@@ -1007,13 +1186,14 @@ func TestConsumableModelSearch(t *testing.T) {
 					},
 				},
 				{
-					ID:        16,
-					CreatorID: 3,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Coconut Milk",
-					BrandName: "Pure Harvest",
-					Size:      250,
-					Units:     "ml",
+					ID:         16,
+					CreatorID:  3,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Coconut Milk",
+					BrandName:  "Pure Harvest",
+					Visibility: VisibilityShared,
+					Size:       250,
+					Units:      "ml",
 					Macros: Macronutrients{
 						Carbs:    3.0,
 						Fats:     5.0,
@@ -1046,13 +1226,14 @@ func TestConsumableModelSearch(t *testing.T) {
 			},
 			wantConsumables: []*Consumable{
 				{
-					ID:        13,
-					CreatorID: 3,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Skim Milk",
-					BrandName: "Pauls",
-					Size:      250,
-					Units:     "ml",
+					ID:         13,
+					CreatorID:  3,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Skim Milk",
+					BrandName:  "Pauls",
+					Visibility: VisibilityShared,
+					Size:       250,
+					Units:      "ml",
 					Macros: Macronutrients{
 						Carbs:    12.8,
 						Fats:     0.3,
@@ -1085,13 +1266,14 @@ func TestConsumableModelSearch(t *testing.T) {
 			},
 			wantConsumables: []*Consumable{
 				{
-					ID:        7,
-					CreatorID: 2,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Almond Milk",
-					BrandName: "Vitasoy",
-					Size:      250,
-					Units:     "ml",
+					ID:         7,
+					CreatorID:  2,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Almond Milk",
+					BrandName:  "Vitasoy",
+					Visibility: VisibilityShared,
+					Size:       250,
+					Units:      "ml",
 					Macros: Macronutrients{
 						Carbs:    0.8,
 						Fats:     1.2,
@@ -1100,13 +1282,14 @@ func TestConsumableModelSearch(t *testing.T) {
 					},
 				},
 				{
-					ID:        12,
-					CreatorID: 3,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Full Cream Milk",
-					BrandName: "Dairy Farmers",
-					Size:      250,
-					Units:     "ml",
+					ID:         12,
+					CreatorID:  3,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Full Cream Milk",
+					BrandName:  "Dairy Farmers",
+					Visibility: VisibilityShared,
+					Size:       250,
+					Units:      "ml",
 					Macros: Macronutrients{
 						Carbs:    12.5,
 						Fats:     8.8,
@@ -1139,13 +1322,14 @@ func TestConsumableModelSearch(t *testing.T) {
 			},
 			wantConsumables: []*Consumable{
 				{
-					ID:        13,
-					CreatorID: 3,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Skim Milk",
-					BrandName: "Pauls",
-					Size:      250,
-					Units:     "ml",
+					ID:         13,
+					CreatorID:  3,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Skim Milk",
+					BrandName:  "Pauls",
+					Visibility: VisibilityShared,
+					Size:       250,
+					Units:      "ml",
 					Macros: Macronutrients{
 						Carbs:    12.8,
 						Fats:     0.3,
@@ -1154,13 +1338,14 @@ func TestConsumableModelSearch(t *testing.T) {
 					},
 				},
 				{
-					ID:        14,
-					CreatorID: 3,
-					CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
-					Name:      "Soy Milk",
-					BrandName: "Sanitarium",
-					Size:      250,
-					Units:     "ml",
+					ID:         14,
+					CreatorID:  3,
+					CreatedAt:  MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Name:       "Soy Milk",
+					BrandName:  "Sanitarium",
+					Visibility: VisibilityShared,
+					Size:       250,
+					Units:      "ml",
 					Macros: Macronutrients{
 						Carbs:    5.5,
 						Fats:     3.2,
@@ -1199,15 +1384,15 @@ func TestConsumableModelSearch(t *testing.T) {
 
 			m := ConsumableModel{db}
 
-			consumables, metadata, err := m.Search(tt.filters)
+			matches, metadata, err := m.Search(0, tt.filters)
 			assert.ExpectError(t, err, tt.wantError)
 			if err != nil {
 				return
 			}
 
-			assert.Equal(t, len(consumables), len(tt.wantConsumables))
-			for i := range consumables {
-				assert.Equal(t, *consumables[i], *tt.wantConsumables[i])
+			assert.Equal(t, len(matches), len(tt.wantConsumables))
+			for i := range matches {
+				assert.Equal(t, matches[i].Consumable, *tt.wantConsumables[i])
 			}
 
 			assert.Equal(t, metadata, tt.wantMetadata)
@@ -1215,6 +1400,189 @@ func TestConsumableModelSearch(t *testing.T) {
 	}
 }
 
+func TestConsumableModelSearchRelevance(t *testing.T) {
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	tests := []struct {
+		name      string
+		userID    int64
+		filters   ConsumableFilters
+		wantIDs   []int64
+		logRecent int64
+	}{
+		{
+			name:    "misspelt name",
+			filters: ConsumableFilters{NameSearch: "bananna"},
+			wantIDs: []int64{2},
+		},
+		{
+			name:    "partial word",
+			filters: ConsumableFilters{NameSearch: "chick"},
+			wantIDs: []int64{6},
+		},
+		{
+			name:    "partial brand",
+			filters: ConsumableFilters{BrandNameSearch: "woolw"},
+			wantIDs: []int64{8},
+		},
+		{
+			name:    "created by user first",
+			userID:  2,
+			filters: ConsumableFilters{NameSearch: "milk"},
+			wantIDs: []int64{7, 12, 13, 14, 15, 16},
+		},
+		{
+			name:      "logged recently first",
+			userID:    1,
+			filters:   ConsumableFilters{NameSearch: "milk"},
+			wantIDs:   []int64{13, 7, 12, 14, 15, 16},
+			logRecent: 13,
+		},
+		{
+			name:    "least relevant first",
+			userID:  3,
+			filters: ConsumableFilters{NameSearch: "milk", Metadata: MetadataFilters{Sort: "-relevance"}},
+			wantIDs: []int64{7, 12, 13, 14, 15, 16},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			db, err := newTestDB(t, "recipes")
+			if err != nil {
+				t.Fatal(fmt.Errorf("Failed test db setup: %w", err))
+			}
+
+			if tt.logRecent != 0 {
+				ctx, cancel := GetDefaultTimeoutContext()
+				defer cancel()
+				_, err = db.Exec(ctx, `
+				INSERT INTO consumed (user_id, consumable_id, amount, units, quantity, carbs, fats, proteins, alcohol, consumed_at, created_at, last_edited_at)
+				VALUES ($1, $2, 100, 'ml', 1, 1, 1, 1, 0, now(), now(), now())`, tt.userID, tt.logRecent)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			m := ConsumableModel{db}
+
+			tt.filters.Metadata.Page = 1
+			tt.filters.Metadata.PageSize = 100
+			if tt.filters.Metadata.Sort == "" {
+				tt.filters.Metadata.Sort = "relevance"
+			}
+			tt.filters.Metadata.SortSafeList = []string{"relevance", "-relevance"}
+
+			matches, _, err := m.Search(tt.userID, tt.filters)
+			assert.NilError(t, err)
+
+			assert.Equal(t, len(matches), len(tt.wantIDs))
+			for i := range min(len(matches), len(tt.wantIDs)) {
+				assert.Equal(t, matches[i].ID, tt.wantIDs[i])
+				assert.Equal(t, matches[i].Score > 0, true)
+			}
+		})
+	}
+}
+
+func TestConsumableFiltersMatchClause(t *testing.T) {
+
+	tests := []struct {
+		name         string
+		filters      ConsumableFilters
+		wantContains []string
+		wantExcludes []string
+	}{
+		{
+			name:         "no search matches everything",
+			filters:      ConsumableFilters{},
+			wantContains: []string{"TRUE"},
+			wantExcludes: []string{"C.name", "C.brand_name"},
+		},
+		{
+			name:         "name only",
+			filters:      ConsumableFilters{NameSearch: "milk", BrandNameSearch: "  "},
+			wantContains: []string{"C.name ILIKE", "$1 <% C.name", "to_tsvector('simple', C.name)"},
+			wantExcludes: []string{"C.brand_name", " AND ", " OR (C"},
+		},
+		{
+			name:         "name and brand",
+			filters:      ConsumableFilters{NameSearch: "milk", BrandNameSearch: "pauls", RequireNameAndBrandNameMatch: true},
+			wantContains: []string{"$1 <% C.name", "$2 <% C.brand_name", ") AND ("},
+		},
+		{
+			name:         "name or brand",
+			filters:      ConsumableFilters{NameSearch: "milk", BrandNameSearch: "pauls"},
+			wantContains: []string{") OR ("},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clause := tt.filters.matchClause()
+			for _, want := range tt.wantContains {
+				assert.Equal(t, strings.Contains(clause, want), true)
+			}
+			for _, exclude := range tt.wantExcludes {
+				assert.Equal(t, strings.Contains(clause, exclude), false)
+			}
+		})
+	}
+}
+
+// TestConsumableModelSearchPlan checks the match predicates are answered by the trigram and tsvector
+// indexes. Sequential and plain index scans are turned off so the planner only avoids a sequential
+// scan of consumables when the whole match clause can be answered by a bitmap of the indexes
+func TestConsumableModelSearchPlan(t *testing.T) {
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db, err := newTestDB(t, "recipes")
+	if err != nil {
+		t.Fatal(fmt.Errorf("Failed test db setup: %w", err))
+	}
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	conn, err := db.Acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, `SET enable_seqscan = off; SET enable_indexscan = off`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filters := ConsumableFilters{
+		Metadata:        MetadataFilters{Page: 1, PageSize: 20, Sort: "relevance", SortSafeList: []string{"relevance"}},
+		NameSearch:      "milk",
+		BrandNameSearch: "pauls",
+	}
+
+	stmt, args := filters.searchQuery(1)
+
+	rows, err := conn.Query(ctx, "EXPLAIN "+stmt, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan := strings.Join(lines, "\n")
+
+	assert.Equal(t, strings.Contains(plan, "Seq Scan on consumables"), false)
+	assert.Equal(t, strings.Contains(plan, "idx_consumables_name_trgm"), true)
+	assert.Equal(t, strings.Contains(plan, "idx_consumables_brand_name_trgm"), true)
+}
+
 func TestConsumableModelInsert(t *testing.T) {
 
 	if testing.Short() {
@@ -1247,6 +1615,26 @@ func TestConsumableModelInsert(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:      "insert ok barcode",
+			wantError: nil,
+			newConsumable: Consumable{
+				ID:        0,
+				CreatorID: 1,
+				CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
+				Name:      "Rolled Oats",
+				BrandName: "Uncle Tobys",
+				Barcode:   "04006381333931",
+				Size:      100,
+				Units:     "g",
+				Macros: Macronutrients{
+					Carbs:    40,
+					Fats:     0.5,
+					Proteins: 3,
+					Alcohol:  0,
+				},
+			},
+		},
 		{
 			name:      "insert creator does not exist",
 			wantError: ErrReferencedUserDoesNotExist,
@@ -1285,15 +1673,149 @@ func TestConsumableModelInsert(t *testing.T) {
 				return
 			}
 
-			// new ID and createdAt is populated inplace of the given consumable
+			// new ID and createdAt is populated inplace of the given consumable, new consumables are private
 			assert.NotEqual(t, insertConsumable.ID, tt.newConsumable.ID)
 			insertConsumable.ID = tt.newConsumable.ID
 			insertConsumable.CreatedAt = tt.newConsumable.CreatedAt
+			insertConsumable.Visibility = VisibilityPrivate
 			assert.Equal(t, tt.newConsumable, insertConsumable)
 		})
 	}
 }
 
+func TestConsumableModelBarcode(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db, err := newTestDB(t, "recipes")
+	if err != nil {
+		t.Fatal(fmt.Errorf("Failed test db setup: %w", err))
+	}
+
+	m := ConsumableModel{db}
+
+	consumable := Consumable{CreatorID: 1, Name: "Rolled Oats", BrandName: "Uncle Tobys", Barcode: "4006381333931", Size: 100, Units: "g", Macros: Macronutrients{Carbs: 40}}
+	err = m.Insert(&consumable)
+	assert.NilError(t, err)
+	assert.Equal(t, consumable.Barcode, "04006381333931")
+
+	found, err := m.GetByBarcode("4006381333931", 1)
+	assert.NilError(t, err)
+	assert.Equal(t, found.ID, consumable.ID)
+
+	// the consumable is private to its creator
+	_, err = m.GetByBarcode("4006381333931", 3)
+	assert.ExpectError(t, err, ErrRecordNotFound)
+
+	duplicate := Consumable{CreatorID: 2, Name: "Oats", BrandName: "Uncle Tobys", Barcode: "04006381333931", Size: 100, Units: "g", Macros: Macronutrients{Carbs: 40}}
+	err = m.Insert(&duplicate)
+	assert.ExpectError(t, err, ErrDuplicateBarcode)
+
+	otherBrand := Consumable{CreatorID: 2, Name: "Oats", BrandName: "Coles", Barcode: "4006381333931", Size: 100, Units: "g", Macros: Macronutrients{Carbs: 40}}
+	err = m.Insert(&otherBrand)
+	assert.NilError(t, err)
+
+	_, err = m.GetByBarcode("96385074", 1)
+	assert.ExpectError(t, err, ErrRecordNotFound)
+}
+
+func TestConsumableModelVisibility(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db, err := newTestDB(t, "recipes")
+	if err != nil {
+		t.Fatal(fmt.Errorf("Failed test db setup: %w", err))
+	}
+
+	m := ConsumableModel{db}
+
+	consumable := Consumable{CreatorID: 1, Name: "Quinoa Puffs", BrandName: "Macro", Size: 100, Units: "g", Macros: Macronutrients{Carbs: 70}}
+	err = m.Insert(&consumable)
+	assert.NilError(t, err)
+	assert.Equal(t, consumable.Visibility, VisibilityPrivate)
+
+	search := func(userID int64, visibilities ...ConsumableVisibility) []*ConsumableMatch {
+		t.Helper()
+		filters := ConsumableFilters{
+			NameSearch:   "quinoa",
+			Visibilities: visibilities,
+			Metadata:     MetadataFilters{Page: 1, PageSize: 100, Sort: "relevance", SortSafeList: []string{"relevance"}},
+		}
+		matches, _, err := m.Search(userID, filters)
+		assert.NilError(t, err)
+		return matches
+	}
+
+	// private consumables are only found by their creator
+	assert.Equal(t, len(search(1)), 1)
+	assert.Equal(t, len(search(2)), 0)
+
+	// only the creator can update the consumable
+	consumable.Name = "Quinoa Puffs Honey"
+	err = m.Update(&consumable, 2)
+	assert.ExpectError(t, err, ErrRecordNotFound)
+	err = m.Update(&consumable, 1)
+	assert.NilError(t, err)
+
+	err = m.SetVisibility(consumable.ID, VisibilityVerified)
+	assert.NilError(t, err)
+	assert.Equal(t, len(search(2)), 1)
+	assert.Equal(t, len(search(2, VisibilityShared)), 0)
+	assert.Equal(t, len(search(2, VisibilityVerified)), 1)
+
+	err = m.SetVisibility(99999, VisibilityShared)
+	assert.ExpectError(t, err, ErrRecordNotFound)
+}
+
+func TestConsumableModelImport(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db, err := newTestDB(t, "recipes")
+	if err != nil {
+		t.Fatal(fmt.Errorf("Failed test db setup: %w", err))
+	}
+
+	m := ConsumableModel{db}
+
+	barcoded := Consumable{CreatorID: 1, Name: "Hazelnut Spread", BrandName: "Ferrero", Barcode: "03017620422003", Size: 100, Units: "g", Macros: Macronutrients{Fats: 30.9}}
+	err = m.Insert(&barcoded)
+	assert.NilError(t, err)
+
+	batch := []*Consumable{
+		{CreatorID: 1, Name: "Nutella", BrandName: "Ferrero", Barcode: "03017620422003", Size: 100, Units: "g", Macros: Macronutrients{Fats: 30.9}},
+		{CreatorID: 1, Name: "OATS", BrandName: "uncle tobys", Size: 100, Units: "g", Macros: Macronutrients{Carbs: 40}},
+		{CreatorID: 1, Name: "Full Cream Milk", BrandName: "Pauls", Barcode: "09300633602147", Size: 100, Units: "g", Macros: Macronutrients{Proteins: 3.4}},
+		{CreatorID: 1, Name: "Rice Crackers", BrandName: "Sakata", Size: 100, Units: "g", Macros: Macronutrients{Carbs: 82}},
+	}
+
+	duplicates, err := m.Import(batch)
+	assert.NilError(t, err)
+	assert.Equal(t, len(duplicates), 2)
+	if len(duplicates) == 2 {
+		assert.Equal(t, duplicates[0], 0)
+		assert.Equal(t, duplicates[1], 1)
+	}
+
+	found, err := m.GetByBarcode("9300633602147", 1)
+	assert.NilError(t, err)
+	assert.Equal(t, found.Name, "Full Cream Milk")
+
+	duplicates, err = m.Import(batch[2:])
+	assert.NilError(t, err)
+	assert.Equal(t, len(duplicates), 2)
+
+	_, err = m.Import([]*Consumable{{CreatorID: 99999, Name: "Ghost", BrandName: "None", Size: 100, Units: "g", Macros: Macronutrients{Carbs: 1}}})
+	assert.ExpectError(t, err, ErrReferencedUserDoesNotExist)
+}
+
 func TestConsumableModelDelete(t *testing.T) {
 
 	if testing.Short() {
@@ -1303,16 +1825,25 @@ func TestConsumableModelDelete(t *testing.T) {
 	tests := []struct {
 		name      string
 		ID        int64
+		userID    int64
 		wantError error
 	}{
 		{
 			name:      "delete ok",
 			ID:        1,
+			userID:    1,
 			wantError: nil,
 		},
 		{
 			name:      "delete not exist",
 			ID:        99999,
+			userID:    1,
+			wantError: ErrRecordNotFound,
+		},
+		{
+			name:      "delete not creator",
+			ID:        1,
+			userID:    2,
 			wantError: ErrRecordNotFound,
 		},
 	}
@@ -1326,7 +1857,7 @@ func TestConsumableModelDelete(t *testing.T) {
 
 			m := ConsumableModel{db}
 
-			err = m.Delete(tt.ID)
+			err = m.Delete(tt.ID, tt.userID)
 			assert.ExpectError(t, err, tt.wantError)
 		})
 	}
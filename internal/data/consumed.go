@@ -2,6 +2,7 @@ package data
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
@@ -11,20 +12,81 @@ import (
 )
 
 type Consumed struct {
-	ID           int64          `json:"id"`
-	UserID       int64          `json:"user_id"`
-	RecipeID     int64          `json:"recipe_id"`
+	ID           int64           `json:"id"`
+	UserID       int64           `json:"user_id"`
+	RecipeID     int64           `json:"recipe_id"`
+	ConsumableID int64           `json:"consumable_id"`
+	Amount       float64         `json:"amount"`
+	Units        MeasurementUnit `json:"units"`
+	// ServingID when set makes Amount a count of that serving size of the consumable
+	ServingID    int64          `json:"serving_id"`
 	Quantity     float64        `json:"quantity"`
 	Macros       Macronutrients `json:"macros"`
+	Nutrients    NutrientPanel  `json:"nutrients"`
 	ConsumedAt   time.Time      `json:"consumed_at"`
 	CreatedAt    time.Time      `json:"created_at"`
 	LastEditedAt time.Time      `json:"last_edited_at"`
 	Notes        string         `json:"notes"`
 }
 
+// ValidateConsumed requires an entry to be for exactly one of a recipe, a consumable or manually
+// entered macros. Consumable entries are measured by amount and units rather than quantity, recipe entries
+// use either a quantity of the recipe or an amount and units of the cooked dish
 func ValidateConsumed(v *validator.Validator, consumed *Consumed) {
-	v.Check(consumed.Quantity > 0, "quantity", "quantity must be positive")
+	v.Check(consumed.RecipeID == 0 || consumed.ConsumableID == 0, "consumable_id", "must not be provided with a recipe_id")
+
+	if consumed.ConsumableID != 0 {
+		v.Check(consumed.Amount > 0, "amount", "must be positive")
+		if consumed.ServingID != 0 {
+			v.Check(consumed.Units == "", "units", "must not be provided with a serving_id")
+		} else {
+			v.Check(isValidMeasurementUnit(consumed.Units), "units", "must be valid")
+		}
+	} else if consumed.RecipeID != 0 && consumed.Amount != 0 {
+		// a recipe eaten by weight of the cooked dish
+		v.Check(consumed.Amount > 0, "amount", "must be positive")
+		v.Check(consumed.Quantity == 0, "quantity", "must not be provided with an amount")
+		v.Check(consumed.ServingID == 0, "serving_id", "must only be provided with a consumable_id")
+		v.Check(isValidMeasurementUnit(consumed.Units), "units", "must be valid")
+	} else {
+		v.Check(consumed.RecipeID != 0 || !consumed.Macros.IsZero(), "macros", "must be provided without a recipe_id or consumable_id")
+		v.Check(consumed.Quantity > 0, "quantity", "quantity must be positive")
+		v.Check(consumed.ServingID == 0, "serving_id", "must only be provided with a consumable_id")
+		v.Check(consumed.Amount == 0, "amount", "must only be provided with a consumable_id or recipe_id")
+		v.Check(consumed.Units == "", "units", "must only be provided with a consumable_id or recipe_id")
+	}
+
 	ValidateMacroNutrients(v, consumed.Macros)
+	ValidateNutrientPanel(v, consumed.Nutrients)
+}
+
+// ValidateConsumedMacros checks client supplied macros against those derived from the recipe or consumable,
+// a consumed entry without macros is always valid as it takes the derived values
+func ValidateConsumedMacros(v *validator.Validator, consumed *Consumed, derived Macronutrients) {
+	if consumed.Macros.IsZero() {
+		return
+	}
+	v.Check(consumed.Macros.Matches(derived), "macros", "must match the macros of the recipe or consumable for the amount consumed")
+}
+
+var (
+	ValidSummaryGranularities = []string{
+		"day",
+		"week",
+		"month",
+	}
+)
+
+// ConsumedSummary is the total consumed within a single day, week or month
+type ConsumedSummary struct {
+	PeriodStart time.Time      `json:"period_start"`
+	Macros      Macronutrients `json:"macros"`
+	EnergyKJ    float64        `json:"energy_kj"`
+	EntryCount  int            `json:"entry_count"`
+}
+
+func ValidateSummaryGranularity(v *validator.Validator, granularity string) {
+	v.Check(validator.In(granularity, ValidSummaryGranularities...), "granularity", "must be one of day, week or month")
 }
 
 type ConsumedModel struct {
@@ -35,13 +97,14 @@ type IConsumedModel interface {
 	GetByConsumedID(int64) (*Consumed, error)
 	GetAllByUserID(int64) ([]*Consumed, error)
 	GetAllByUserIDAndDate(int64, time.Time, time.Time) ([]*Consumed, error)
+	GetSummaryByUserID(int64, string, time.Time, time.Time, string) ([]*ConsumedSummary, error)
 	Insert(*Consumed) error
 	Update(*Consumed) error
 	Delete(int64, int64) error
 }
 
 func (m ConsumedModel) GetByConsumedID(ConsumedID int64) (*Consumed, error) {
-	stmt := `SELECT id, user_id, COALESCE(recipe_id, 0), quantity, carbs, fats, proteins, alcohol, consumed_at, created_at, last_edited_at, notes
+	stmt := `SELECT id, user_id, COALESCE(recipe_id, 0), COALESCE(consumable_id, 0), COALESCE(amount, 0), COALESCE(units, ''), COALESCE(serving_id, 0), quantity, carbs, fats, proteins, alcohol, nutrients, consumed_at, created_at, last_edited_at, notes
 	FROM consumed
 	WHERE id = $1`
 
@@ -54,11 +117,16 @@ func (m ConsumedModel) GetByConsumedID(ConsumedID int64) (*Consumed, error) {
 		&consumed.ID,
 		&consumed.UserID,
 		&consumed.RecipeID,
+		&consumed.ConsumableID,
+		&consumed.Amount,
+		&consumed.Units,
+		&consumed.ServingID,
 		&consumed.Quantity,
 		&consumed.Macros.Carbs,
 		&consumed.Macros.Fats,
 		&consumed.Macros.Proteins,
 		&consumed.Macros.Alcohol,
+		&consumed.Nutrients,
 		&consumed.ConsumedAt,
 		&consumed.CreatedAt,
 		&consumed.LastEditedAt,
@@ -74,11 +142,13 @@ func (m ConsumedModel) GetByConsumedID(ConsumedID int64) (*Consumed, error) {
 		}
 	}
 
+	consumed.ConsumedAt = consumed.ConsumedAt.UTC()
+
 	return consumed, nil
 }
 
 func (m ConsumedModel) GetAllByUserID(userID int64) ([]*Consumed, error) {
-	stmt := `SELECT id, user_id, COALESCE(recipe_id, 0), quantity, carbs, fats, proteins, alcohol, consumed_at, created_at, last_edited_at, notes
+	stmt := `SELECT id, user_id, COALESCE(recipe_id, 0), COALESCE(consumable_id, 0), COALESCE(amount, 0), COALESCE(units, ''), COALESCE(serving_id, 0), quantity, carbs, fats, proteins, alcohol, nutrients, consumed_at, created_at, last_edited_at, notes
 	FROM consumed
 	WHERE user_id = $1`
 
@@ -99,11 +169,16 @@ func (m ConsumedModel) GetAllByUserID(userID int64) ([]*Consumed, error) {
 			&consumed.ID,
 			&consumed.UserID,
 			&consumed.RecipeID,
+			&consumed.ConsumableID,
+			&consumed.Amount,
+			&consumed.Units,
+			&consumed.ServingID,
 			&consumed.Quantity,
 			&consumed.Macros.Carbs,
 			&consumed.Macros.Fats,
 			&consumed.Macros.Proteins,
 			&consumed.Macros.Alcohol,
+			&consumed.Nutrients,
 			&consumed.ConsumedAt,
 			&consumed.CreatedAt,
 			&consumed.LastEditedAt,
@@ -112,6 +187,7 @@ func (m ConsumedModel) GetAllByUserID(userID int64) ([]*Consumed, error) {
 		if err != nil {
 			return nil, err
 		}
+		consumed.ConsumedAt = consumed.ConsumedAt.UTC()
 		allConsumed = append(allConsumed, &consumed)
 	}
 
@@ -123,7 +199,7 @@ func (m ConsumedModel) GetAllByUserID(userID int64) ([]*Consumed, error) {
 }
 
 func (m ConsumedModel) GetAllByUserIDAndDate(userID int64, from time.Time, to time.Time) ([]*Consumed, error) {
-	stmt := `SELECT id, user_id, COALESCE(recipe_id, 0), quantity, carbs, fats, proteins, alcohol, consumed_at, created_at, last_edited_at, notes
+	stmt := `SELECT id, user_id, COALESCE(recipe_id, 0), COALESCE(consumable_id, 0), COALESCE(amount, 0), COALESCE(units, ''), COALESCE(serving_id, 0), quantity, carbs, fats, proteins, alcohol, nutrients, consumed_at, created_at, last_edited_at, notes
 	FROM consumed
 	WHERE user_id = $1 AND consumed_at >= $2 and consumed_at <= $3
 	ORDER BY consumed_at ASC;`
@@ -145,11 +221,16 @@ func (m ConsumedModel) GetAllByUserIDAndDate(userID int64, from time.Time, to ti
 			&consumed.ID,
 			&consumed.UserID,
 			&consumed.RecipeID,
+			&consumed.ConsumableID,
+			&consumed.Amount,
+			&consumed.Units,
+			&consumed.ServingID,
 			&consumed.Quantity,
 			&consumed.Macros.Carbs,
 			&consumed.Macros.Fats,
 			&consumed.Macros.Proteins,
 			&consumed.Macros.Alcohol,
+			&consumed.Nutrients,
 			&consumed.ConsumedAt,
 			&consumed.CreatedAt,
 			&consumed.LastEditedAt,
@@ -158,6 +239,7 @@ func (m ConsumedModel) GetAllByUserIDAndDate(userID int64, from time.Time, to ti
 		if err != nil {
 			return nil, err
 		}
+		consumed.ConsumedAt = consumed.ConsumedAt.UTC()
 		allConsumed = append(allConsumed, &consumed)
 	}
 
@@ -168,24 +250,66 @@ func (m ConsumedModel) GetAllByUserIDAndDate(userID int64, from time.Time, to ti
 	return allConsumed, nil
 }
 
-func (m ConsumedModel) Insert(consumed *Consumed) error {
-	stmt := `INSERT INTO consumed (user_id, recipe_id, quantity, carbs, fats, proteins, alcohol, consumed_at, notes)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-	RETURNING id, created_at, last_edited_at`
+// GetSummaryByUserID totals the consumed macros and energy between from and to, bucketed by
+// granularity which must be one of ValidSummaryGranularities. Buckets start at midnight in the
+// given IANA timezone
+func (m ConsumedModel) GetSummaryByUserID(userID int64, granularity string, from time.Time, to time.Time, timezone string) ([]*ConsumedSummary, error) {
+	stmt := fmt.Sprintf(`
+	SELECT date_trunc($2, consumed_at AT TIME ZONE $5) AT TIME ZONE $5 AS period_start, COALESCE(SUM(carbs), 0), COALESCE(SUM(fats), 0), COALESCE(SUM(proteins), 0),
+	       COALESCE(SUM(alcohol), 0), COALESCE(SUM(%s), 0), COUNT(*)
+	FROM consumed
+	WHERE user_id = $1 AND consumed_at >= $3 AND consumed_at <= $4
+	GROUP BY period_start
+	ORDER BY period_start ASC
+	`, energySQL)
 
 	ctx, cancel := GetDefaultTimeoutContext()
 	defer cancel()
 
-	var actualRecipeID *int64
-	if consumed.RecipeID == 0 {
-		actualRecipeID = nil
-	} else {
-		actualRecipeID = &consumed.RecipeID
+	rows, err := m.DB.Query(ctx, stmt, userID, granularity, from, to, timezone)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
+
+	summaries := []*ConsumedSummary{}
+
+	for rows.Next() {
+		var summary ConsumedSummary
+		err = rows.Scan(
+			&summary.PeriodStart,
+			&summary.Macros.Carbs,
+			&summary.Macros.Fats,
+			&summary.Macros.Proteins,
+			&summary.Macros.Alcohol,
+			&summary.EnergyKJ,
+			&summary.EntryCount,
+		)
+		if err != nil {
+			return nil, err
+		}
+		summary.PeriodStart = summary.PeriodStart.UTC()
+		summaries = append(summaries, &summary)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return summaries, nil
+}
+
+func (m ConsumedModel) Insert(consumed *Consumed) error {
+	stmt := `INSERT INTO consumed (user_id, recipe_id, quantity, carbs, fats, proteins, alcohol, consumed_at, notes, consumable_id, amount, units, serving_id, nutrients)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11::DOUBLE PRECISION, 0), NULLIF($12, ''), $13, $14)
+	RETURNING id, created_at, last_edited_at`
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
 
 	args := []any{
 		consumed.UserID,
-		actualRecipeID,
+		nullableID(consumed.RecipeID),
 		consumed.Quantity,
 		consumed.Macros.Carbs,
 		consumed.Macros.Fats,
@@ -193,6 +317,11 @@ func (m ConsumedModel) Insert(consumed *Consumed) error {
 		consumed.Macros.Alcohol,
 		consumed.ConsumedAt,
 		consumed.Notes,
+		nullableID(consumed.ConsumableID),
+		consumed.Amount,
+		consumed.Units,
+		nullableID(consumed.ServingID),
+		consumed.Nutrients,
 	}
 
 	err := m.DB.QueryRow(ctx, stmt, args...).Scan(
@@ -205,6 +334,10 @@ func (m ConsumedModel) Insert(consumed *Consumed) error {
 		switch {
 		case strings.HasPrefix(err.Error(), "ERROR: insert or update on table \"consumed\" violates foreign key constraint \"fk_consumed_recipeid\""):
 			return ErrRecipeDoesNotExist
+		case strings.HasPrefix(err.Error(), "ERROR: insert or update on table \"consumed\" violates foreign key constraint \"fk_consumed_consumableid\""):
+			return ErrConsumableDoesNotExist
+		case strings.HasPrefix(err.Error(), "ERROR: insert or update on table \"consumed\" violates foreign key constraint \"fk_consumed_servingid\""):
+			return ErrServingSizeDoesNotExist
 		case strings.HasPrefix(err.Error(), "ERROR: insert or update on table \"consumed\" violates foreign key constraint \"fk_consumed_consumerid\""):
 			return ErrReferencedUserDoesNotExist
 		}
@@ -216,7 +349,8 @@ func (m ConsumedModel) Insert(consumed *Consumed) error {
 
 func (m ConsumedModel) Update(consumed *Consumed) error {
 	stmt := `UPDATE consumed 
-	SET user_id = $1, recipe_id = $2, quantity = $3, carbs = $4, fats = $5, proteins = $6, alcohol = $7, consumed_at = $8, last_edited_at = current_timestamp, notes=$9
+	SET user_id = $1, recipe_id = $2, quantity = $3, carbs = $4, fats = $5, proteins = $6, alcohol = $7, consumed_at = $8, last_edited_at = current_timestamp, notes=$9,
+	    consumable_id = $11, amount = NULLIF($12::DOUBLE PRECISION, 0), units = NULLIF($13, ''), serving_id = $14, nutrients = $15
 	WHERE id = $10
 	RETURNING last_edited_at`
 
@@ -225,7 +359,7 @@ func (m ConsumedModel) Update(consumed *Consumed) error {
 
 	args := []any{
 		consumed.UserID,
-		consumed.RecipeID,
+		nullableID(consumed.RecipeID),
 		consumed.Quantity,
 		consumed.Macros.Carbs,
 		consumed.Macros.Fats,
@@ -234,6 +368,11 @@ func (m ConsumedModel) Update(consumed *Consumed) error {
 		consumed.ConsumedAt,
 		consumed.Notes,
 		consumed.ID,
+		nullableID(consumed.ConsumableID),
+		consumed.Amount,
+		consumed.Units,
+		nullableID(consumed.ServingID),
+		consumed.Nutrients,
 	}
 
 	err := m.DB.QueryRow(ctx, stmt, args...).Scan(&consumed.LastEditedAt)
@@ -243,6 +382,10 @@ func (m ConsumedModel) Update(consumed *Consumed) error {
 			return ErrRecordNotFound
 		case strings.HasPrefix(err.Error(), "ERROR: insert or update on table \"consumed\" violates foreign key constraint \"fk_consumed_recipeid\""):
 			return ErrRecipeDoesNotExist
+		case strings.HasPrefix(err.Error(), "ERROR: insert or update on table \"consumed\" violates foreign key constraint \"fk_consumed_consumableid\""):
+			return ErrConsumableDoesNotExist
+		case strings.HasPrefix(err.Error(), "ERROR: insert or update on table \"consumed\" violates foreign key constraint \"fk_consumed_servingid\""):
+			return ErrServingSizeDoesNotExist
 		case strings.HasPrefix(err.Error(), "ERROR: insert or update on table \"consumed\" violates foreign key constraint \"fk_consumed_consumerid\""):
 			return ErrReferencedUserDoesNotExist
 		}
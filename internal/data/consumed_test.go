@@ -2,6 +2,7 @@ package data
 
 import (
 	"fmt"
+	"math"
 	"testing"
 	"time"
 
@@ -75,6 +76,186 @@ func TestConsumedHelpers(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:  "valid consumable",
+			valid: true,
+			consumed: Consumed{
+				ConsumableID: 1,
+				Amount:       50,
+				Units:        "g",
+				UserID:       1,
+				ConsumedAt:   MustParse(timeFormat, "2024-01-01 10:00:00"),
+				Macros: Macronutrients{
+					Carbs:    20,
+					Fats:     0.25,
+					Proteins: 1.5,
+					Alcohol:  0,
+				},
+			},
+		},
+		{
+			name:  "valid manual macros",
+			valid: true,
+			consumed: Consumed{
+				UserID:     1,
+				ConsumedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
+				Quantity:   1,
+				Macros: Macronutrients{
+					Carbs:    20,
+					Fats:     5,
+					Proteins: 10,
+					Alcohol:  0,
+				},
+			},
+		},
+		{
+			name:  "invalid manual macros zero",
+			valid: false,
+			consumed: Consumed{
+				UserID:     1,
+				ConsumedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
+				Quantity:   1,
+			},
+		},
+		{
+			name:  "invalid recipe and consumable",
+			valid: false,
+			consumed: Consumed{
+				RecipeID:     1,
+				ConsumableID: 1,
+				Amount:       50,
+				Units:        "g",
+				UserID:       1,
+				ConsumedAt:   MustParse(timeFormat, "2024-01-01 10:00:00"),
+				Quantity:     1,
+				Macros: Macronutrients{
+					Carbs:    1,
+					Fats:     1,
+					Proteins: 1,
+					Alcohol:  1,
+				},
+			},
+		},
+		{
+			name:  "valid consumable serving",
+			valid: true,
+			consumed: Consumed{
+				ConsumableID: 4,
+				ServingID:    1,
+				Amount:       2,
+				UserID:       1,
+				ConsumedAt:   MustParse(timeFormat, "2024-01-01 10:00:00"),
+				Macros: Macronutrients{
+					Carbs:    30,
+					Fats:     1.6,
+					Proteins: 6.4,
+					Alcohol:  0,
+				},
+			},
+		},
+		{
+			name:  "invalid serving with units",
+			valid: false,
+			consumed: Consumed{
+				ConsumableID: 4,
+				ServingID:    1,
+				Amount:       2,
+				Units:        "g",
+				UserID:       1,
+				ConsumedAt:   MustParse(timeFormat, "2024-01-01 10:00:00"),
+				Macros: Macronutrients{
+					Carbs:    30,
+					Fats:     1.6,
+					Proteins: 6.4,
+					Alcohol:  0,
+				},
+			},
+		},
+		{
+			name:  "invalid consumable zero amount",
+			valid: false,
+			consumed: Consumed{
+				ConsumableID: 1,
+				Amount:       0,
+				Units:        "g",
+				UserID:       1,
+				ConsumedAt:   MustParse(timeFormat, "2024-01-01 10:00:00"),
+				Macros: Macronutrients{
+					Carbs:    1,
+					Fats:     1,
+					Proteins: 1,
+					Alcohol:  1,
+				},
+			},
+		},
+		{
+			name:  "invalid consumable bad units",
+			valid: false,
+			consumed: Consumed{
+				ConsumableID: 1,
+				Amount:       50,
+				Units:        "handfuls",
+				UserID:       1,
+				ConsumedAt:   MustParse(timeFormat, "2024-01-01 10:00:00"),
+				Macros: Macronutrients{
+					Carbs:    1,
+					Fats:     1,
+					Proteins: 1,
+					Alcohol:  1,
+				},
+			},
+		},
+		{
+			name:  "invalid manual macros with units",
+			valid: false,
+			consumed: Consumed{
+				UserID:     1,
+				ConsumedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
+				Quantity:   1,
+				Units:      "g",
+				Macros: Macronutrients{
+					Carbs:    1,
+					Fats:     1,
+					Proteins: 1,
+					Alcohol:  1,
+				},
+			},
+		},
+		{
+			name:  "valid recipe by cooked weight",
+			valid: true,
+			consumed: Consumed{
+				UserID:     1,
+				RecipeID:   1,
+				ConsumedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
+				Amount:     150,
+				Units:      "g",
+				Macros: Macronutrients{
+					Carbs:    1,
+					Fats:     1,
+					Proteins: 1,
+					Alcohol:  1,
+				},
+			},
+		},
+		{
+			name:  "invalid recipe by cooked weight with quantity",
+			valid: false,
+			consumed: Consumed{
+				UserID:     1,
+				RecipeID:   1,
+				ConsumedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
+				Quantity:   1,
+				Amount:     150,
+				Units:      "g",
+				Macros: Macronutrients{
+					Carbs:    1,
+					Fats:     1,
+					Proteins: 1,
+					Alcohol:  1,
+				},
+			},
+		},
 		// {
 		// 	name:  "invalid component bad user ID",
 		// 	valid: false,
@@ -195,6 +376,47 @@ func TestConsumedHelpers(t *testing.T) {
 	}
 }
 
+func TestConsumedMacrosHelpers(t *testing.T) {
+
+	derived := Macronutrients{Carbs: 100, Fats: 20, Proteins: 30, Alcohol: 0}
+
+	tests := []struct {
+		name   string
+		macros Macronutrients
+		valid  bool
+	}{
+		{
+			name:   "no client macros",
+			macros: Macronutrients{},
+			valid:  true,
+		},
+		{
+			name:   "matching macros",
+			macros: derived,
+			valid:  true,
+		},
+		{
+			name:   "rounded macros",
+			macros: Macronutrients{Carbs: 100.8, Fats: 20.4, Proteins: 30, Alcohol: 0},
+			valid:  true,
+		},
+		{
+			name:   "disagreeing macros",
+			macros: Macronutrients{Carbs: 50, Fats: 20, Proteins: 30, Alcohol: 0},
+			valid:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+
+			ValidateConsumedMacros(v, &Consumed{Quantity: 1, Macros: tt.macros}, derived)
+			assert.ValidatorValid(t, v, tt.valid)
+		})
+	}
+}
+
 func TestConsumedModelGetByConsumedID(t *testing.T) {
 
 	timeFormat := "2006-01-02 15:04:05"
@@ -606,6 +828,180 @@ func TestConsumedModelGetAllByUserIDAndDate(t *testing.T) {
 	}
 }
 
+func TestConsumedSummaryHelpers(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		valid       bool
+		granularity string
+	}{
+		{name: "valid day", valid: true, granularity: "day"},
+		{name: "valid week", valid: true, granularity: "week"},
+		{name: "valid month", valid: true, granularity: "month"},
+		{name: "invalid year", valid: false, granularity: "year"},
+		{name: "invalid empty", valid: false, granularity: ""},
+		{name: "invalid sql", valid: false, granularity: "day'); DROP TABLE consumed; --"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+
+			ValidateSummaryGranularity(v, tt.granularity)
+			assert.ValidatorValid(t, v, tt.valid)
+		})
+	}
+}
+
+func TestConsumedModelGetSummaryByUserID(t *testing.T) {
+
+	timeFormat := "2006-01-02 15:04:05"
+
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	firstDay := []Macronutrients{
+		{Carbs: 1, Fats: 1, Proteins: 1, Alcohol: 1},
+		{Carbs: 7, Fats: 7, Proteins: 7, Alcohol: 1},
+		{Carbs: 3, Fats: 2, Proteins: 1, Alcohol: 0},
+	}
+	secondDay := []Macronutrients{
+		{Carbs: 3, Fats: 2, Proteins: 1, Alcohol: 0},
+	}
+
+	energy := func(entries ...[]Macronutrients) float64 {
+		total := 0.0
+		for _, day := range entries {
+			for _, macros := range day {
+				total += macros.CalculateKJ()
+			}
+		}
+		return total
+	}
+
+	tests := []struct {
+		name          string
+		ID            int64
+		granularity   string
+		timezone      string
+		from          time.Time
+		to            time.Time
+		expectSummary []*ConsumedSummary
+	}{
+		{
+			name:        "daily",
+			ID:          3,
+			granularity: "day",
+			timezone:    "UTC",
+			from:        MustParse(timeFormat, "2024-01-01 00:00:00"),
+			to:          MustParse(timeFormat, "2024-01-03 00:00:00"),
+			expectSummary: []*ConsumedSummary{
+				{
+					PeriodStart: MustParse(timeFormat, "2024-01-01 00:00:00"),
+					Macros:      Macronutrients{Carbs: 11, Fats: 10, Proteins: 9, Alcohol: 2},
+					EnergyKJ:    energy(firstDay),
+					EntryCount:  3,
+				},
+				{
+					PeriodStart: MustParse(timeFormat, "2024-01-02 00:00:00"),
+					Macros:      Macronutrients{Carbs: 3, Fats: 2, Proteins: 1, Alcohol: 0},
+					EnergyKJ:    energy(secondDay),
+					EntryCount:  1,
+				},
+			},
+		},
+		{
+			name:        "weekly",
+			ID:          3,
+			granularity: "week",
+			timezone:    "UTC",
+			from:        MustParse(timeFormat, "2024-01-01 00:00:00"),
+			to:          MustParse(timeFormat, "2024-01-03 00:00:00"),
+			expectSummary: []*ConsumedSummary{
+				{
+					PeriodStart: MustParse(timeFormat, "2024-01-01 00:00:00"),
+					Macros:      Macronutrients{Carbs: 14, Fats: 12, Proteins: 10, Alcohol: 2},
+					EnergyKJ:    energy(firstDay, secondDay),
+					EntryCount:  4,
+				},
+			},
+		},
+		{
+			name:        "monthly restricted range",
+			ID:          3,
+			granularity: "month",
+			timezone:    "UTC",
+			from:        MustParse(timeFormat, "2024-01-01 10:05:00"),
+			to:          MustParse(timeFormat, "2024-01-03 00:00:00"),
+			expectSummary: []*ConsumedSummary{
+				{
+					PeriodStart: MustParse(timeFormat, "2024-01-01 00:00:00"),
+					Macros:      Macronutrients{Carbs: 6, Fats: 4, Proteins: 2, Alcohol: 0},
+					EnergyKJ:    energy(firstDay[2:], secondDay),
+					EntryCount:  2,
+				},
+			},
+		},
+		{
+			name:        "daily in user timezone",
+			ID:          3,
+			granularity: "day",
+			timezone:    "Pacific/Kiritimati",
+			from:        MustParse(timeFormat, "2024-01-01 00:00:00"),
+			to:          MustParse(timeFormat, "2024-01-03 00:00:00"),
+			expectSummary: []*ConsumedSummary{
+				{
+					PeriodStart: MustParse(timeFormat, "2024-01-01 10:00:00"),
+					Macros:      Macronutrients{Carbs: 11, Fats: 10, Proteins: 9, Alcohol: 2},
+					EnergyKJ:    energy(firstDay),
+					EntryCount:  3,
+				},
+				{
+					PeriodStart: MustParse(timeFormat, "2024-01-02 10:00:00"),
+					Macros:      Macronutrients{Carbs: 3, Fats: 2, Proteins: 1, Alcohol: 0},
+					EnergyKJ:    energy(secondDay),
+					EntryCount:  1,
+				},
+			},
+		},
+		{
+			name:          "no consumed",
+			ID:            4,
+			granularity:   "day",
+			timezone:      "UTC",
+			from:          MustParse(timeFormat, "2024-01-01 00:00:00"),
+			to:            MustParse(timeFormat, "2024-01-03 00:00:00"),
+			expectSummary: []*ConsumedSummary{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			db, err := newTestDB(t, "consumed_summary")
+			if err != nil {
+				t.Fatal(fmt.Errorf("Failed test db setup: %w", err))
+			}
+			m := ConsumedModel{db}
+
+			summary, err := m.GetSummaryByUserID(tt.ID, tt.granularity, tt.from, tt.to, tt.timezone)
+			assert.NilError(t, err)
+
+			assert.Equal(t, len(summary), len(tt.expectSummary))
+			for i := range tt.expectSummary {
+				if i >= len(summary) {
+					break
+				}
+				assert.Equal(t, summary[i].PeriodStart, tt.expectSummary[i].PeriodStart)
+				assert.Equal(t, summary[i].Macros, tt.expectSummary[i].Macros)
+				assert.Equal(t, summary[i].EntryCount, tt.expectSummary[i].EntryCount)
+				assert.Equal(t, math.Abs(summary[i].EnergyKJ-tt.expectSummary[i].EnergyKJ) < 1e-6, true)
+			}
+		})
+	}
+}
+
 func TestConsumedModelInsert(t *testing.T) {
 
 	timeFormat := "2006-01-02 15:04:05"
@@ -638,6 +1034,40 @@ func TestConsumedModelInsert(t *testing.T) {
 				Notes: "notes",
 			},
 		},
+		{
+			name:        "insert consumable existing",
+			expectError: nil,
+			consumed: Consumed{
+				ConsumableID: 2,
+				Amount:       50,
+				Units:        "g",
+				UserID:       3,
+				ConsumedAt:   MustParse(timeFormat, "2024-01-01 10:00:00"),
+				Macros: Macronutrients{
+					Carbs:    19,
+					Fats:     0.05,
+					Proteins: 1,
+					Alcohol:  0,
+				},
+			},
+		},
+		{
+			name:        "invalid consumable no existent consumable ID",
+			expectError: ErrConsumableDoesNotExist,
+			consumed: Consumed{
+				ConsumableID: 99999,
+				Amount:       50,
+				Units:        "g",
+				UserID:       3,
+				ConsumedAt:   MustParse(timeFormat, "2024-01-01 10:00:00"),
+				Macros: Macronutrients{
+					Carbs:    1,
+					Fats:     1,
+					Proteins: 1,
+					Alcohol:  1,
+				},
+			},
+		},
 		{
 			name:        "invalid component bad user ID",
 			expectError: ErrReferencedUserDoesNotExist,
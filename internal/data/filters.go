@@ -1,6 +1,7 @@
 package data
 
 import (
+	"fmt"
 	"math"
 	"strings"
 
@@ -23,10 +24,15 @@ type Metadata struct {
 }
 
 func ValidateMetadataFilters(v *validator.Validator, f MetadataFilters) {
+	validateMetadataFilters(v, f, 100)
+}
+
+// validateMetadataFilters checks the filters allowing pages of up to maxPageSize records
+func validateMetadataFilters(v *validator.Validator, f MetadataFilters, maxPageSize int) {
 	v.Check(f.Page > 0, "page", "must be greater than zero")
 	v.Check(f.Page <= 10_000_000, "page", "must be a maximum of 10 million")
 	v.Check(f.PageSize > 0, "page_size", "must be greater than zero")
-	v.Check(f.PageSize <= 100, "page_size", "must be a maximum of 100")
+	v.Check(f.PageSize <= maxPageSize, "page_size", fmt.Sprintf("must be a maximum of %d", maxPageSize))
 
 	v.Check(validator.In(f.Sort, f.SortSafeList...), "sort", "invalid sort value")
 
@@ -0,0 +1,159 @@
+package data
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tconnellan/macro-tracker-backend/internal/validator"
+)
+
+// Goal holds a user's daily targets, a user has at most one goal
+type Goal struct {
+	ID           int64          `json:"id"`
+	UserID       int64          `json:"user_id"`
+	Macros       Macronutrients `json:"macros"`
+	EnergyKJ     float64        `json:"energy_kj"`
+	CreatedAt    time.Time      `json:"created_at"`
+	LastEditedAt time.Time      `json:"last_edited_at"`
+}
+
+func ValidateGoal(v *validator.Validator, goal *Goal) {
+	v.Check(goal.Macros.Carbs >= 0, "carbs", "must be non-negative")
+	v.Check(goal.Macros.Fats >= 0, "fats", "must be non-negative")
+	v.Check(goal.Macros.Proteins >= 0, "proteins", "must be non-negative")
+	v.Check(goal.Macros.Alcohol >= 0, "alcohol", "must be non-negative")
+	v.Check(goal.EnergyKJ >= 0, "energy_kj", "must be non-negative")
+	v.Check(goal.Macros.Carbs+goal.Macros.Fats+goal.Macros.Proteins+goal.Macros.Alcohol+goal.EnergyKJ > 0, "goal", "at least one target must be positive")
+}
+
+// GoalProgress compares what a user has consumed against their goal,
+// remaining amounts are negative once a target has been exceeded
+type GoalProgress struct {
+	Goal        Goal           `json:"goal"`
+	Consumed    Macronutrients `json:"consumed"`
+	ConsumedKJ  float64        `json:"consumed_kj"`
+	Remaining   Macronutrients `json:"remaining"`
+	RemainingKJ float64        `json:"remaining_kj"`
+}
+
+func CalculateGoalProgress(goal *Goal, consumed []*Consumed) *GoalProgress {
+	total := Macronutrients{}
+	for _, c := range consumed {
+		total = total.Add(c.Macros)
+	}
+
+	return &GoalProgress{
+		Goal:        *goal,
+		Consumed:    total,
+		ConsumedKJ:  total.CalculateKJ(),
+		Remaining:   goal.Macros.Subtract(total),
+		RemainingKJ: goal.EnergyKJ - total.CalculateKJ(),
+	}
+}
+
+type GoalModel struct {
+	DB *pgxpool.Pool
+}
+
+type IGoalModel interface {
+	GetByUserID(int64) (*Goal, error)
+	Upsert(*Goal) error
+	Delete(int64) error
+}
+
+func (m GoalModel) GetByUserID(userID int64) (*Goal, error) {
+	stmt := `
+	SELECT id, user_id, carbs, fats, proteins, alcohol, energy_kj, created_at, last_edited_at
+	FROM goals
+	WHERE user_id = $1
+	`
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	var goal Goal
+
+	err := m.DB.QueryRow(ctx, stmt, userID).Scan(
+		&goal.ID,
+		&goal.UserID,
+		&goal.Macros.Carbs,
+		&goal.Macros.Fats,
+		&goal.Macros.Proteins,
+		&goal.Macros.Alcohol,
+		&goal.EnergyKJ,
+		&goal.CreatedAt,
+		&goal.LastEditedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &goal, nil
+}
+
+// Upsert creates the user's goal or replaces the targets of their existing one
+func (m GoalModel) Upsert(goal *Goal) error {
+	stmt := `
+	INSERT INTO goals (user_id, carbs, fats, proteins, alcohol, energy_kj)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (user_id) DO UPDATE
+	SET carbs = EXCLUDED.carbs, fats = EXCLUDED.fats, proteins = EXCLUDED.proteins, alcohol = EXCLUDED.alcohol,
+	    energy_kj = EXCLUDED.energy_kj, last_edited_at = current_timestamp
+	RETURNING id, created_at, last_edited_at
+	`
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	args := []any{
+		goal.UserID,
+		goal.Macros.Carbs,
+		goal.Macros.Fats,
+		goal.Macros.Proteins,
+		goal.Macros.Alcohol,
+		goal.EnergyKJ,
+	}
+
+	err := m.DB.QueryRow(ctx, stmt, args...).Scan(&goal.ID, &goal.CreatedAt, &goal.LastEditedAt)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "ERROR: insert or update on table \"goals\" violates foreign key constraint \"fk_goal_user\""):
+			return ErrReferencedUserDoesNotExist
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m GoalModel) Delete(userID int64) error {
+	stmt := `
+	DELETE FROM goals
+	WHERE user_id = $1
+	`
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	result, err := m.DB.Exec(ctx, stmt, userID)
+	if err != nil {
+		return err
+	}
+
+	rows := result.RowsAffected()
+
+	if rows == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,277 @@
+package data
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tconnellan/macro-tracker-backend/internal/assert"
+	"github.com/tconnellan/macro-tracker-backend/internal/validator"
+)
+
+func TestGoalHelpers(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		valid bool
+		goal  Goal
+	}{
+		{
+			name:  "valid goal",
+			valid: true,
+			goal: Goal{
+				UserID: 1,
+				Macros: Macronutrients{
+					Carbs:    200,
+					Fats:     70,
+					Proteins: 150,
+					Alcohol:  0,
+				},
+				EnergyKJ: 8700,
+			},
+		},
+		{
+			name:  "valid goal energy only",
+			valid: true,
+			goal: Goal{
+				UserID:   1,
+				EnergyKJ: 8700,
+			},
+		},
+		{
+			name:  "invalid goal no targets",
+			valid: false,
+			goal: Goal{
+				UserID: 1,
+			},
+		},
+		{
+			name:  "invalid goal negative macro",
+			valid: false,
+			goal: Goal{
+				UserID: 1,
+				Macros: Macronutrients{
+					Carbs:    200,
+					Fats:     -1,
+					Proteins: 150,
+					Alcohol:  0,
+				},
+				EnergyKJ: 8700,
+			},
+		},
+		{
+			name:  "invalid goal negative energy",
+			valid: false,
+			goal: Goal{
+				UserID: 1,
+				Macros: Macronutrients{
+					Carbs:    200,
+					Fats:     70,
+					Proteins: 150,
+					Alcohol:  0,
+				},
+				EnergyKJ: -1,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+
+			ValidateGoal(v, &tt.goal)
+			assert.ValidatorValid(t, v, tt.valid)
+		})
+	}
+}
+
+func TestCalculateGoalProgress(t *testing.T) {
+
+	goal := Goal{
+		ID:     1,
+		UserID: 1,
+		Macros: Macronutrients{
+			Carbs:    200,
+			Fats:     70,
+			Proteins: 150,
+			Alcohol:  0,
+		},
+		EnergyKJ: 8700,
+	}
+
+	tests := []struct {
+		name            string
+		consumed        []*Consumed
+		expectConsumed  Macronutrients
+		expectRemaining Macronutrients
+	}{
+		{
+			name:            "nothing consumed",
+			consumed:        []*Consumed{},
+			expectConsumed:  Macronutrients{},
+			expectRemaining: goal.Macros,
+		},
+		{
+			name: "multiple consumed",
+			consumed: []*Consumed{
+				{Macros: Macronutrients{Carbs: 50, Fats: 10, Proteins: 30, Alcohol: 0}},
+				{Macros: Macronutrients{Carbs: 25, Fats: 5, Proteins: 20, Alcohol: 10}},
+			},
+			expectConsumed:  Macronutrients{Carbs: 75, Fats: 15, Proteins: 50, Alcohol: 10},
+			expectRemaining: Macronutrients{Carbs: 125, Fats: 55, Proteins: 100, Alcohol: -10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			progress := CalculateGoalProgress(&goal, tt.consumed)
+
+			assert.Equal(t, progress.Goal, goal)
+			assert.Equal(t, progress.Consumed, tt.expectConsumed)
+			assert.Equal(t, progress.ConsumedKJ, tt.expectConsumed.CalculateKJ())
+			assert.Equal(t, progress.Remaining, tt.expectRemaining)
+			assert.Equal(t, progress.RemainingKJ, goal.EnergyKJ-tt.expectConsumed.CalculateKJ())
+		})
+	}
+}
+
+func TestGoalModelGetByUserID(t *testing.T) {
+
+	timeFormat := "2006-01-02 15:04:05"
+
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	tests := []struct {
+		name        string
+		userID      int64
+		expectError error
+		expectGoal  Goal
+	}{
+		{
+			name:        "get existing",
+			userID:      1,
+			expectError: nil,
+			expectGoal: Goal{
+				ID:     1,
+				UserID: 1,
+				Macros: Macronutrients{
+					Carbs:    200,
+					Fats:     70,
+					Proteins: 150,
+					Alcohol:  0,
+				},
+				EnergyKJ:     8700,
+				CreatedAt:    MustParse(timeFormat, "2024-01-01 10:00:00"),
+				LastEditedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
+			},
+		},
+		{
+			name:        "get user without goal",
+			userID:      2,
+			expectError: ErrRecordNotFound,
+		},
+		{
+			name:        "get non existent user",
+			userID:      99999,
+			expectError: ErrRecordNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			db, err := newTestDB(t, "goals")
+			if err != nil {
+				t.Fatal(fmt.Errorf("Failed test db setup: %w", err))
+			}
+			m := GoalModel{db}
+
+			goal, err := m.GetByUserID(tt.userID)
+
+			assert.ExpectError(t, err, tt.expectError)
+			if err != nil {
+				return
+			}
+
+			assert.Equal(t, *goal, tt.expectGoal)
+		})
+	}
+}
+
+func TestGoalModelUpsert(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	tests := []struct {
+		name        string
+		goal        Goal
+		expectError error
+	}{
+		{
+			name: "insert new goal",
+			goal: Goal{
+				UserID: 2,
+				Macros: Macronutrients{
+					Carbs:    180,
+					Fats:     60,
+					Proteins: 140,
+					Alcohol:  0,
+				},
+				EnergyKJ: 8000,
+			},
+			expectError: nil,
+		},
+		{
+			name: "replace existing goal",
+			goal: Goal{
+				UserID: 1,
+				Macros: Macronutrients{
+					Carbs:    100,
+					Fats:     50,
+					Proteins: 200,
+					Alcohol:  0,
+				},
+				EnergyKJ: 7000,
+			},
+			expectError: nil,
+		},
+		{
+			name: "non existent user",
+			goal: Goal{
+				UserID:   99999,
+				EnergyKJ: 7000,
+			},
+			expectError: ErrReferencedUserDoesNotExist,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			db, err := newTestDB(t, "goals")
+			if err != nil {
+				t.Fatal(fmt.Errorf("Failed test db setup: %w", err))
+			}
+			m := GoalModel{db}
+
+			err = m.Upsert(&tt.goal)
+
+			assert.ExpectError(t, err, tt.expectError)
+			if err != nil {
+				return
+			}
+
+			goal, err := m.GetByUserID(tt.goal.UserID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, goal.ID, tt.goal.ID)
+			assert.Equal(t, goal.Macros, tt.goal.Macros)
+			assert.Equal(t, goal.EnergyKJ, tt.goal.EnergyKJ)
+		})
+	}
+}
@@ -0,0 +1,19 @@
+-- +goose Up
+BEGIN;
+CREATE TABLE IF NOT EXISTS goals (
+   id INTEGER               PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+   user_id INTEGER          NOT NULL UNIQUE,
+   carbs DOUBLE PRECISION   NOT NULL DEFAULT 0,
+   fats DOUBLE PRECISION    NOT NULL DEFAULT 0,
+   proteins DOUBLE PRECISION NOT NULL DEFAULT 0,
+   alcohol DOUBLE PRECISION NOT NULL DEFAULT 0,
+   energy_kj DOUBLE PRECISION NOT NULL DEFAULT 0,
+   created_at TIMESTAMP     DEFAULT current_timestamp,
+   last_edited_at TIMESTAMP DEFAULT current_timestamp
+);
+COMMIT;
+
+ALTER TABLE goals ADD CONSTRAINT fk_goal_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+-- +goose Down
+DROP TABLE IF EXISTS goals CASCADE;
\ No newline at end of file
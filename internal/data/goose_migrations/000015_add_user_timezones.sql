@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT 'UTC';
+
+-- existing consumed_at values have no zone, every user starts in UTC so interpret them as UTC
+ALTER TABLE consumed ALTER COLUMN consumed_at TYPE TIMESTAMPTZ USING consumed_at AT TIME ZONE 'UTC';
+
+-- +goose Down
+ALTER TABLE consumed ALTER COLUMN consumed_at TYPE TIMESTAMP USING consumed_at AT TIME ZONE 'UTC';
+
+ALTER TABLE users DROP COLUMN IF EXISTS timezone;
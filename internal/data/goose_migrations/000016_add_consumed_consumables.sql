@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE consumed ADD COLUMN IF NOT EXISTS consumable_id INTEGER;
+ALTER TABLE consumed ADD COLUMN IF NOT EXISTS amount DOUBLE PRECISION;
+ALTER TABLE consumed ADD COLUMN IF NOT EXISTS units TEXT;
+
+ALTER TABLE consumed ADD CONSTRAINT fk_consumed_consumableid FOREIGN KEY (consumable_id) REFERENCES consumables(id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE consumed DROP CONSTRAINT IF EXISTS fk_consumed_consumableid;
+
+ALTER TABLE consumed DROP COLUMN IF EXISTS units;
+ALTER TABLE consumed DROP COLUMN IF EXISTS amount;
+ALTER TABLE consumed DROP COLUMN IF EXISTS consumable_id;
@@ -0,0 +1,38 @@
+-- +goose Up
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS serving_sizes (
+    id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    consumable_id INTEGER NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    amount DOUBLE PRECISION NOT NULL,
+    units TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT current_timestamp
+);
+
+ALTER TABLE serving_sizes ADD CONSTRAINT fk_servingsize_consumable FOREIGN KEY (consumable_id) REFERENCES consumables(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_servingsize_consumableid ON serving_sizes USING BTREE(consumable_id);
+
+-- a step or consumed entry with a serving records a count of that serving in its quantity or amount
+ALTER TABLE recipe_components ADD COLUMN IF NOT EXISTS serving_id INTEGER;
+ALTER TABLE recipe_components ADD CONSTRAINT fk_recipecomponent_serving FOREIGN KEY (serving_id) REFERENCES serving_sizes(id) ON DELETE RESTRICT;
+
+ALTER TABLE consumed ADD COLUMN IF NOT EXISTS serving_id INTEGER;
+ALTER TABLE consumed ADD CONSTRAINT fk_consumed_servingid FOREIGN KEY (serving_id) REFERENCES serving_sizes(id) ON DELETE SET NULL;
+
+COMMIT;
+
+
+-- +goose Down
+BEGIN;
+
+ALTER TABLE consumed DROP CONSTRAINT IF EXISTS fk_consumed_servingid;
+ALTER TABLE consumed DROP COLUMN IF EXISTS serving_id;
+
+ALTER TABLE recipe_components DROP CONSTRAINT IF EXISTS fk_recipecomponent_serving;
+ALTER TABLE recipe_components DROP COLUMN IF EXISTS serving_id;
+
+DROP TABLE IF EXISTS serving_sizes CASCADE;
+
+COMMIT;
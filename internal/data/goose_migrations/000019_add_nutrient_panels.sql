@@ -0,0 +1,14 @@
+-- +goose Up
+-- nutrient panels are objects of nutrient key to amount so new nutrients need no migration
+ALTER TABLE consumables ADD COLUMN IF NOT EXISTS nutrients JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE consumed ADD COLUMN IF NOT EXISTS nutrients JSONB NOT NULL DEFAULT '{}';
+
+ALTER TABLE consumables ADD CONSTRAINT consumables_nutrients_check CHECK (jsonb_typeof(nutrients) = 'object');
+ALTER TABLE consumed ADD CONSTRAINT consumed_nutrients_check CHECK (jsonb_typeof(nutrients) = 'object');
+
+-- +goose Down
+ALTER TABLE consumed DROP CONSTRAINT IF EXISTS consumed_nutrients_check;
+ALTER TABLE consumables DROP CONSTRAINT IF EXISTS consumables_nutrients_check;
+
+ALTER TABLE consumed DROP COLUMN IF EXISTS nutrients;
+ALTER TABLE consumables DROP COLUMN IF EXISTS nutrients;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- NULL servings means the recipe yield was not given
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS servings INTEGER;
+
+ALTER TABLE recipes ADD CONSTRAINT recipes_servings_check CHECK (servings > 0);
+
+-- +goose Down
+ALTER TABLE recipes DROP CONSTRAINT IF EXISTS recipes_servings_check;
+
+ALTER TABLE recipes DROP COLUMN IF EXISTS servings;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- NULL cooked_weight means the weight of the finished dish was not recorded
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS cooked_weight DOUBLE PRECISION;
+
+ALTER TABLE recipes ADD CONSTRAINT recipes_cooked_weight_check CHECK (cooked_weight > 0);
+
+-- +goose Down
+ALTER TABLE recipes DROP CONSTRAINT IF EXISTS recipes_cooked_weight_check;
+
+ALTER TABLE recipes DROP COLUMN IF EXISTS cooked_weight;
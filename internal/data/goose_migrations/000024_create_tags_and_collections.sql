@@ -0,0 +1,53 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS tags (
+    id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    user_id INTEGER NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT current_timestamp
+);
+
+ALTER TABLE tags ADD CONSTRAINT fk_tag_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE tags ADD CONSTRAINT tags_user_name_key UNIQUE (user_id, name);
+
+CREATE TABLE IF NOT EXISTS collections (
+    id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    user_id INTEGER NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+    last_edited_at TIMESTAMP NOT NULL DEFAULT current_timestamp
+);
+
+ALTER TABLE collections ADD CONSTRAINT fk_collection_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE collections ADD CONSTRAINT collections_user_name_key UNIQUE (user_id, name);
+
+-- tags and collections hold recipe lineages, recipe_id is the first version of the lineage
+CREATE TABLE IF NOT EXISTS recipe_tags (
+    tag_id INTEGER NOT NULL,
+    recipe_id INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (tag_id, recipe_id)
+);
+
+ALTER TABLE recipe_tags ADD CONSTRAINT fk_recipetag_tag FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE;
+ALTER TABLE recipe_tags ADD CONSTRAINT fk_recipetag_recipe FOREIGN KEY (recipe_id) REFERENCES recipes(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_recipetags_recipeid ON recipe_tags USING BTREE(recipe_id);
+
+CREATE TABLE IF NOT EXISTS collection_recipes (
+    collection_id INTEGER NOT NULL,
+    recipe_id INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (collection_id, recipe_id)
+);
+
+ALTER TABLE collection_recipes ADD CONSTRAINT fk_collectionrecipe_collection FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE;
+ALTER TABLE collection_recipes ADD CONSTRAINT fk_collectionrecipe_recipe FOREIGN KEY (recipe_id) REFERENCES recipes(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_collectionrecipes_recipeid ON collection_recipes USING BTREE(recipe_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS collection_recipes;
+DROP TABLE IF EXISTS recipe_tags;
+DROP TABLE IF EXISTS collections;
+DROP TABLE IF EXISTS tags;
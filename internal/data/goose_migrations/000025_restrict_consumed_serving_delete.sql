@@ -0,0 +1,16 @@
+-- +goose Up
+BEGIN;
+
+-- a consumed entry's amount is a count of its serving, it cannot be kept without one
+ALTER TABLE consumed DROP CONSTRAINT IF EXISTS fk_consumed_servingid;
+ALTER TABLE consumed ADD CONSTRAINT fk_consumed_servingid FOREIGN KEY (serving_id) REFERENCES serving_sizes(id) ON DELETE RESTRICT;
+
+COMMIT;
+
+-- +goose Down
+BEGIN;
+
+ALTER TABLE consumed DROP CONSTRAINT IF EXISTS fk_consumed_servingid;
+ALTER TABLE consumed ADD CONSTRAINT fk_consumed_servingid FOREIGN KEY (serving_id) REFERENCES serving_sizes(id) ON DELETE SET NULL;
+
+COMMIT;
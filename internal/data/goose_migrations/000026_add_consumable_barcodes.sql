@@ -0,0 +1,15 @@
+-- +goose Up
+-- barcode is the consumable's GTIN zero padded to 14 digits, NULL when it has none
+ALTER TABLE consumables ADD COLUMN IF NOT EXISTS barcode VARCHAR(14);
+
+-- a barcode identifies one product of a brand
+ALTER TABLE consumables ADD CONSTRAINT consumables_brand_barcode_key UNIQUE (brand_name, barcode);
+
+CREATE INDEX IF NOT EXISTS idx_consumables_barcode ON consumables USING BTREE(barcode) WHERE barcode IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_consumables_barcode;
+
+ALTER TABLE consumables DROP CONSTRAINT IF EXISTS consumables_brand_barcode_key;
+
+ALTER TABLE consumables DROP COLUMN IF EXISTS barcode;
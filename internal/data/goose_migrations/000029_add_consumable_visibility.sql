@@ -0,0 +1,50 @@
+-- +goose Up
+-- admins review edits to shared consumables, the role is granted in the database
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- private consumables are seen only by their creator, shared by every user and verified are shared
+-- consumables an admin has checked. Consumables were searchable by every user before so start shared
+ALTER TABLE consumables ADD COLUMN IF NOT EXISTS visibility VARCHAR(10) NOT NULL DEFAULT 'shared';
+ALTER TABLE consumables ADD CONSTRAINT consumables_visibility_check CHECK (visibility IN ('private', 'shared', 'verified'));
+
+CREATE INDEX IF NOT EXISTS idx_consumables_visibility ON consumables USING BTREE(visibility, creator_id);
+
+-- edits to shared consumables wait for an admin, each holds the consumable as it is after the edit
+CREATE TABLE IF NOT EXISTS consumable_edits (
+    id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    consumable_id INTEGER NOT NULL,
+    editor_id INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+    name VARCHAR(50) NOT NULL,
+    brand_name VARCHAR(50) NOT NULL,
+    barcode VARCHAR(14),
+    size DOUBLE PRECISION NOT NULL,
+    units VARCHAR(10) NOT NULL,
+    carbs DOUBLE PRECISION NOT NULL,
+    fats DOUBLE PRECISION NOT NULL,
+    proteins DOUBLE PRECISION NOT NULL,
+    alcohol DOUBLE PRECISION NOT NULL,
+    density DOUBLE PRECISION NOT NULL DEFAULT 0,
+    unit_weight DOUBLE PRECISION NOT NULL DEFAULT 0,
+    serving_weight DOUBLE PRECISION NOT NULL DEFAULT 0,
+    nutrients JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(10) NOT NULL DEFAULT 'pending',
+    reviewer_id INTEGER,
+    reviewed_at TIMESTAMP
+);
+
+ALTER TABLE consumable_edits ADD CONSTRAINT fk_consumableedit_consumable FOREIGN KEY (consumable_id) REFERENCES consumables(id) ON DELETE CASCADE;
+ALTER TABLE consumable_edits ADD CONSTRAINT fk_consumableedit_editor FOREIGN KEY (editor_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE consumable_edits ADD CONSTRAINT fk_consumableedit_reviewer FOREIGN KEY (reviewer_id) REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE consumable_edits ADD CONSTRAINT consumable_edits_status_check CHECK (status IN ('pending', 'approved', 'rejected'));
+
+CREATE INDEX IF NOT EXISTS idx_consumableedits_pending ON consumable_edits USING BTREE(created_at) WHERE status = 'pending';
+
+-- +goose Down
+DROP TABLE IF EXISTS consumable_edits;
+
+DROP INDEX IF EXISTS idx_consumables_visibility;
+ALTER TABLE consumables DROP CONSTRAINT IF EXISTS consumables_visibility_check;
+ALTER TABLE consumables DROP COLUMN IF EXISTS visibility;
+
+ALTER TABLE users DROP COLUMN IF EXISTS is_admin;
@@ -22,3 +22,27 @@ func Max[T Number](a, b T) T {
 func GetDefaultTimeoutContext() (context.Context, context.CancelFunc) {
 	return context.WithTimeout(context.Background(), 3*time.Second)
 }
+
+// nullableID maps an unset id to NULL so optional foreign keys can be written
+func nullableID(id int64) *int64 {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
+
+// nullableUnits maps unset units to NULL
+func nullableUnits(units MeasurementUnit) *MeasurementUnit {
+	if units == "" {
+		return nil
+	}
+	return &units
+}
+
+// nullableString maps an empty string to NULL
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
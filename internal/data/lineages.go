@@ -0,0 +1,57 @@
+package data
+
+import (
+	"strings"
+)
+
+// recipeLineages is a recursive query naming the lineage of each recipe of the user in $1. A lineage is a
+// recipe's versions created by the same user, root_id is its first version
+const recipeLineages = `lineages(id, root_id) AS (
+		SELECT R.id, R.id
+		FROM recipes R LEFT JOIN recipes P ON R.parent_recipe_id = P.id AND P.creator_id = R.creator_id
+		WHERE R.creator_id = $1 AND P.id IS NULL
+		UNION
+		SELECT R.id, L.root_id
+		FROM recipes R INNER JOIN lineages L ON R.parent_recipe_id = L.id
+		WHERE R.creator_id = $1
+	)`
+
+// addToLineage runs an insert linking a recipe lineage to a tag or collection. The statement inserts no
+// rows when the recipe or the tag or collection is not the user's, linking a lineage twice has no effect
+func addToLineage(db psqlDB, stmt string, args ...any) error {
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	result, err := db.Exec(ctx, stmt, args...)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "ERROR: duplicate key value violates unique constraint"):
+			return nil
+		default:
+			return err
+		}
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// removeFromLineage runs a delete unlinking a recipe lineage from a tag or collection
+func removeFromLineage(db psqlDB, stmt string, args ...any) error {
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	result, err := db.Exec(ctx, stmt, args...)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
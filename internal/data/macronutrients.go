@@ -1,6 +1,22 @@
 package data
 
-import "github.com/tconnellan/macro-tracker-backend/internal/validator"
+import (
+	"fmt"
+	"math"
+
+	"github.com/tconnellan/macro-tracker-backend/internal/validator"
+)
+
+// energy density of each macronutrient in kJ per gram
+const (
+	kjPerGramCarbs    = 16.7
+	kjPerGramFats     = 37.7
+	kjPerGramProteins = 16.7
+	kjPerGramAlcohol  = 29.0
+)
+
+// energySQL calculates kJ from the carbs, fats, proteins and alcohol columns of a row
+var energySQL = fmt.Sprintf("(%g * carbs + %g * fats + %g * proteins + %g * alcohol)", kjPerGramCarbs, kjPerGramFats, kjPerGramProteins, kjPerGramAlcohol)
 
 type Macronutrients struct {
 	Carbs    float64 `json:"carbs"`
@@ -18,5 +34,55 @@ func ValidateMacroNutrients(v *validator.Validator, macros Macronutrients) {
 }
 
 func (macros *Macronutrients) CalculateKJ() float64 {
-	return 16.7*macros.Carbs + 37.7*macros.Fats + 16.7*macros.Proteins + 29*macros.Alcohol
+	return kjPerGramCarbs*macros.Carbs + kjPerGramFats*macros.Fats + kjPerGramProteins*macros.Proteins + kjPerGramAlcohol*macros.Alcohol
+}
+
+func (macros Macronutrients) Add(other Macronutrients) Macronutrients {
+	return Macronutrients{
+		Carbs:    macros.Carbs + other.Carbs,
+		Fats:     macros.Fats + other.Fats,
+		Proteins: macros.Proteins + other.Proteins,
+		Alcohol:  macros.Alcohol + other.Alcohol,
+	}
+}
+
+func (macros Macronutrients) Subtract(other Macronutrients) Macronutrients {
+	return Macronutrients{
+		Carbs:    macros.Carbs - other.Carbs,
+		Fats:     macros.Fats - other.Fats,
+		Proteins: macros.Proteins - other.Proteins,
+		Alcohol:  macros.Alcohol - other.Alcohol,
+	}
+}
+
+func (macros Macronutrients) Scale(factor float64) Macronutrients {
+	return Macronutrients{
+		Carbs:    macros.Carbs * factor,
+		Fats:     macros.Fats * factor,
+		Proteins: macros.Proteins * factor,
+		Alcohol:  macros.Alcohol * factor,
+	}
+}
+
+// macroTolerance is how far apart two macro amounts may be, in grams or as a fraction of the larger
+// amount, and still be treated as the same, this absorbs rounding done by clients
+const (
+	macroToleranceGrams    = 0.5
+	macroToleranceFraction = 0.01
+)
+
+// Matches reports whether each macronutrient is within rounding tolerance of other
+func (macros Macronutrients) Matches(other Macronutrients) bool {
+	within := func(a, b float64) bool {
+		return math.Abs(a-b) <= math.Max(macroToleranceGrams, macroToleranceFraction*math.Max(math.Abs(a), math.Abs(b)))
+	}
+
+	return within(macros.Carbs, other.Carbs) &&
+		within(macros.Fats, other.Fats) &&
+		within(macros.Proteins, other.Proteins) &&
+		within(macros.Alcohol, other.Alcohol)
+}
+
+func (macros Macronutrients) IsZero() bool {
+	return macros == Macronutrients{}
 }
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS goals CASCADE;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE consumed ALTER COLUMN consumed_at TYPE TIMESTAMP USING consumed_at AT TIME ZONE 'UTC';
+
+ALTER TABLE users DROP COLUMN IF EXISTS timezone;
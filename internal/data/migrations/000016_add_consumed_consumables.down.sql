@@ -0,0 +1,5 @@
+ALTER TABLE consumed DROP CONSTRAINT IF EXISTS fk_consumed_consumableid;
+
+ALTER TABLE consumed DROP COLUMN IF EXISTS units;
+ALTER TABLE consumed DROP COLUMN IF EXISTS amount;
+ALTER TABLE consumed DROP COLUMN IF EXISTS consumable_id;
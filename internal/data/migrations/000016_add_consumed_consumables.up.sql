@@ -0,0 +1,5 @@
+ALTER TABLE consumed ADD COLUMN IF NOT EXISTS consumable_id INTEGER;
+ALTER TABLE consumed ADD COLUMN IF NOT EXISTS amount DOUBLE PRECISION;
+ALTER TABLE consumed ADD COLUMN IF NOT EXISTS units TEXT;
+
+ALTER TABLE consumed ADD CONSTRAINT fk_consumed_consumableid FOREIGN KEY (consumable_id) REFERENCES consumables(id) ON DELETE SET NULL;
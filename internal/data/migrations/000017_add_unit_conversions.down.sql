@@ -0,0 +1,9 @@
+ALTER TABLE recipe_components DROP COLUMN IF EXISTS units;
+
+ALTER TABLE consumables DROP CONSTRAINT IF EXISTS consumables_serving_weight_check;
+ALTER TABLE consumables DROP CONSTRAINT IF EXISTS consumables_unit_weight_check;
+ALTER TABLE consumables DROP CONSTRAINT IF EXISTS consumables_density_check;
+
+ALTER TABLE consumables DROP COLUMN IF EXISTS serving_weight;
+ALTER TABLE consumables DROP COLUMN IF EXISTS unit_weight;
+ALTER TABLE consumables DROP COLUMN IF EXISTS density;
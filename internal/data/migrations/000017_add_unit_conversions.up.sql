@@ -0,0 +1,10 @@
+ALTER TABLE consumables ADD COLUMN IF NOT EXISTS density DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE consumables ADD COLUMN IF NOT EXISTS unit_weight DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE consumables ADD COLUMN IF NOT EXISTS serving_weight DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+ALTER TABLE consumables ADD CONSTRAINT consumables_density_check CHECK (density >= 0);
+ALTER TABLE consumables ADD CONSTRAINT consumables_unit_weight_check CHECK (unit_weight >= 0);
+ALTER TABLE consumables ADD CONSTRAINT consumables_serving_weight_check CHECK (serving_weight >= 0);
+
+-- NULL units means the step is measured in the units of its consumable
+ALTER TABLE recipe_components ADD COLUMN IF NOT EXISTS units TEXT;
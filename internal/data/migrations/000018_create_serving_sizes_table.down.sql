@@ -0,0 +1,11 @@
+BEGIN;
+
+ALTER TABLE consumed DROP CONSTRAINT IF EXISTS fk_consumed_servingid;
+ALTER TABLE consumed DROP COLUMN IF EXISTS serving_id;
+
+ALTER TABLE recipe_components DROP CONSTRAINT IF EXISTS fk_recipecomponent_serving;
+ALTER TABLE recipe_components DROP COLUMN IF EXISTS serving_id;
+
+DROP TABLE IF EXISTS serving_sizes CASCADE;
+
+COMMIT;
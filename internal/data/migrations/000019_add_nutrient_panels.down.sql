@@ -0,0 +1,5 @@
+ALTER TABLE consumed DROP CONSTRAINT IF EXISTS consumed_nutrients_check;
+ALTER TABLE consumables DROP CONSTRAINT IF EXISTS consumables_nutrients_check;
+
+ALTER TABLE consumed DROP COLUMN IF EXISTS nutrients;
+ALTER TABLE consumables DROP COLUMN IF EXISTS nutrients;
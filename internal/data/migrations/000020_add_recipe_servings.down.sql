@@ -0,0 +1,3 @@
+ALTER TABLE recipes DROP CONSTRAINT IF EXISTS recipes_servings_check;
+
+ALTER TABLE recipes DROP COLUMN IF EXISTS servings;
@@ -0,0 +1,4 @@
+-- NULL servings means the recipe yield was not given
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS servings INTEGER;
+
+ALTER TABLE recipes ADD CONSTRAINT recipes_servings_check CHECK (servings > 0);
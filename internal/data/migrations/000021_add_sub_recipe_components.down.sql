@@ -0,0 +1,10 @@
+DELETE FROM recipe_components WHERE sub_recipe_id IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_recipecomponents_subrecipeid;
+
+ALTER TABLE recipe_components DROP CONSTRAINT IF EXISTS recipe_components_sub_recipe_check;
+ALTER TABLE recipe_components DROP CONSTRAINT IF EXISTS recipe_components_ingredient_check;
+ALTER TABLE recipe_components DROP CONSTRAINT IF EXISTS fk_recipecomponent_sub_recipe;
+
+ALTER TABLE recipe_components DROP COLUMN IF EXISTS sub_recipe_id;
+ALTER TABLE recipe_components ALTER COLUMN pantry_item_id SET NOT NULL;
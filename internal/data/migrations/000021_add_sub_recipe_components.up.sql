@@ -0,0 +1,9 @@
+-- a step uses either a pantry item or another recipe
+ALTER TABLE recipe_components ALTER COLUMN pantry_item_id DROP NOT NULL;
+ALTER TABLE recipe_components ADD COLUMN IF NOT EXISTS sub_recipe_id INTEGER;
+
+ALTER TABLE recipe_components ADD CONSTRAINT fk_recipecomponent_sub_recipe FOREIGN KEY (sub_recipe_id) REFERENCES recipes(id) ON DELETE RESTRICT;
+ALTER TABLE recipe_components ADD CONSTRAINT recipe_components_ingredient_check CHECK ((pantry_item_id IS NULL) <> (sub_recipe_id IS NULL));
+ALTER TABLE recipe_components ADD CONSTRAINT recipe_components_sub_recipe_check CHECK (sub_recipe_id <> recipe_id);
+
+CREATE INDEX IF NOT EXISTS idx_recipecomponents_subrecipeid ON recipe_components USING BTREE(sub_recipe_id);
@@ -0,0 +1,3 @@
+ALTER TABLE recipes DROP CONSTRAINT IF EXISTS recipes_cooked_weight_check;
+
+ALTER TABLE recipes DROP COLUMN IF EXISTS cooked_weight;
@@ -0,0 +1,4 @@
+-- NULL cooked_weight means the weight of the finished dish was not recorded
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS cooked_weight DOUBLE PRECISION;
+
+ALTER TABLE recipes ADD CONSTRAINT recipes_cooked_weight_check CHECK (cooked_weight > 0);
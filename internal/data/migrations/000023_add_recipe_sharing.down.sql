@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_recipes_public;
+
+ALTER TABLE recipes DROP CONSTRAINT IF EXISTS fk_recipe_forked_from;
+
+ALTER TABLE recipes DROP COLUMN IF EXISTS forked_from_id;
+ALTER TABLE recipes DROP COLUMN IF EXISTS is_public;
@@ -0,0 +1,8 @@
+-- public recipes can be browsed and forked by every user
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS is_public BOOLEAN NOT NULL DEFAULT FALSE;
+-- NULL forked_from_id means the recipe was not forked from another user's recipe
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS forked_from_id INTEGER;
+
+ALTER TABLE recipes ADD CONSTRAINT fk_recipe_forked_from FOREIGN KEY (forked_from_id) REFERENCES recipes(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_recipes_public ON recipes USING BTREE(recipe_name) WHERE is_public AND is_latest;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS collection_recipes;
+DROP TABLE IF EXISTS recipe_tags;
+DROP TABLE IF EXISTS collections;
+DROP TABLE IF EXISTS tags;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE consumed DROP CONSTRAINT IF EXISTS fk_consumed_servingid;
+ALTER TABLE consumed ADD CONSTRAINT fk_consumed_servingid FOREIGN KEY (serving_id) REFERENCES serving_sizes(id) ON DELETE SET NULL;
+
+COMMIT;
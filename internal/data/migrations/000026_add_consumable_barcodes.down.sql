@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_consumables_barcode;
+
+ALTER TABLE consumables DROP CONSTRAINT IF EXISTS consumables_brand_barcode_key;
+
+ALTER TABLE consumables DROP COLUMN IF EXISTS barcode;
@@ -0,0 +1,7 @@
+-- barcode is the consumable's GTIN zero padded to 14 digits, NULL when it has none
+ALTER TABLE consumables ADD COLUMN IF NOT EXISTS barcode VARCHAR(14);
+
+-- a barcode identifies one product of a brand
+ALTER TABLE consumables ADD CONSTRAINT consumables_brand_barcode_key UNIQUE (brand_name, barcode);
+
+CREATE INDEX IF NOT EXISTS idx_consumables_barcode ON consumables USING BTREE(barcode) WHERE barcode IS NOT NULL;
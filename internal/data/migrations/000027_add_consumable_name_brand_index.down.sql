@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_consumables_name_brand;
@@ -0,0 +1,2 @@
+-- consumables are matched ignoring case on name and brand when deduplicating imports
+CREATE INDEX IF NOT EXISTS idx_consumables_name_brand ON consumables USING BTREE(lower(name), lower(brand_name));
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_consumables_brand_name_trgm;
+DROP INDEX IF EXISTS idx_consumables_name_trgm;
+
+DROP EXTENSION IF EXISTS pg_trgm;
@@ -0,0 +1,5 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- consumables are searched by partial and misspelt names and brands with trigram matching
+CREATE INDEX IF NOT EXISTS idx_consumables_name_trgm ON consumables USING GIN(name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_consumables_brand_name_trgm ON consumables USING GIN(brand_name gin_trgm_ops);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS consumable_edits;
+
+DROP INDEX IF EXISTS idx_consumables_visibility;
+ALTER TABLE consumables DROP CONSTRAINT IF EXISTS consumables_visibility_check;
+ALTER TABLE consumables DROP COLUMN IF EXISTS visibility;
+
+ALTER TABLE users DROP COLUMN IF EXISTS is_admin;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS consumable_aliases;
@@ -0,0 +1,12 @@
+-- a merged consumable is deleted and its ID kept as an alias of the consumable it was merged into
+CREATE TABLE IF NOT EXISTS consumable_aliases (
+    alias_id INTEGER PRIMARY KEY,
+    consumable_id INTEGER NOT NULL,
+    merged_by INTEGER,
+    created_at TIMESTAMP NOT NULL DEFAULT current_timestamp
+);
+
+ALTER TABLE consumable_aliases ADD CONSTRAINT fk_consumablealias_consumable FOREIGN KEY (consumable_id) REFERENCES consumables(id) ON DELETE CASCADE;
+ALTER TABLE consumable_aliases ADD CONSTRAINT fk_consumablealias_merger FOREIGN KEY (merged_by) REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_consumablealiases_consumableid ON consumable_aliases USING BTREE(consumable_id);
@@ -0,0 +1,37 @@
+package mocks
+
+import "github.com/tconnellan/macro-tracker-backend/internal/data"
+
+type CollectionModelMock struct{}
+
+func (m CollectionModelMock) GetAllByUserID(int64) ([]*data.Collection, error) {
+	return []*data.Collection{}, nil
+}
+
+func (m CollectionModelMock) Get(int64, int64) (*data.Collection, error) {
+	return nil, data.ErrRecordNotFound
+}
+
+func (m CollectionModelMock) GetRecipes(int64, int64) ([]*data.Recipe, error) {
+	return []*data.Recipe{}, nil
+}
+
+func (m CollectionModelMock) Insert(*data.Collection) error {
+	return nil
+}
+
+func (m CollectionModelMock) Update(*data.Collection) error {
+	return nil
+}
+
+func (m CollectionModelMock) Delete(int64, int64) error {
+	return nil
+}
+
+func (m CollectionModelMock) AddRecipe(int64, int64, int64) error {
+	return nil
+}
+
+func (m CollectionModelMock) RemoveRecipe(int64, int64, int64) error {
+	return nil
+}
@@ -0,0 +1,29 @@
+package mocks
+
+import "github.com/tconnellan/macro-tracker-backend/internal/data"
+
+type ConsumableEditModelMock struct{}
+
+func (m ConsumableEditModelMock) GetPending(data.MetadataFilters) ([]*data.ConsumableEdit, data.Metadata, error) {
+	return []*data.ConsumableEdit{}, data.Metadata{}, nil
+}
+
+func (m ConsumableEditModelMock) Insert(edit *data.ConsumableEdit) error {
+	edit.ID = 1
+	edit.Status = data.EditPending
+	return nil
+}
+
+func (m ConsumableEditModelMock) Approve(ID int64, reviewerID int64) error {
+	if ID != 1 {
+		return data.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (m ConsumableEditModelMock) Reject(ID int64, reviewerID int64) error {
+	if ID != 1 {
+		return data.ErrRecordNotFound
+	}
+	return nil
+}
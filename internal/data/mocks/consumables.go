@@ -5,14 +5,73 @@ import "github.com/tconnellan/macro-tracker-backend/internal/data"
 type ConsumableModelMock struct{}
 
 func (m ConsumableModelMock) GetByID(ID int64) (*data.Consumable, error) {
-	return nil, nil
+	switch ID {
+	case 1:
+		return &data.Consumable{
+			ID:         1,
+			CreatorID:  1,
+			Name:       "consumable",
+			BrandName:  "brand",
+			Visibility: data.VisibilityPrivate,
+			Size:       100,
+			Units:      "g",
+			Macros: data.Macronutrients{
+				Carbs:    10,
+				Fats:     2,
+				Proteins: 4,
+				Alcohol:  0,
+			},
+		}, nil
+	case 2, 3, 4:
+		// 2 is shared by user 1, 3 verified and 4 private to user 2
+		visibilities := map[int64]data.ConsumableVisibility{2: data.VisibilityShared, 3: data.VisibilityVerified, 4: data.VisibilityPrivate}
+		creators := map[int64]int64{2: 1, 3: 2, 4: 2}
+		return &data.Consumable{
+			ID:         ID,
+			CreatorID:  creators[ID],
+			Name:       "consumable",
+			BrandName:  "brand",
+			Visibility: visibilities[ID],
+			Size:       100,
+			Units:      "g",
+			Macros:     data.Macronutrients{Carbs: 10},
+		}, nil
+	case 5:
+		// shared by user 1 and measured in ml without a density, so no consumable in g merges into it
+		return &data.Consumable{
+			ID:         5,
+			CreatorID:  1,
+			Name:       "consumable",
+			BrandName:  "brand",
+			Visibility: data.VisibilityShared,
+			Size:       100,
+			Units:      "ml",
+			Macros:     data.Macronutrients{Carbs: 10},
+		}, nil
+	default:
+		return nil, data.ErrRecordNotFound
+	}
+}
+
+func (m ConsumableModelMock) GetByBarcode(code string, userID int64) (*data.Consumable, error) {
+	switch data.NormaliseGTIN(code) {
+	case "04006381333931":
+		consumable, err := m.GetByID(1)
+		if err != nil {
+			return nil, err
+		}
+		consumable.Barcode = "04006381333931"
+		return consumable, nil
+	default:
+		return nil, data.ErrRecordNotFound
+	}
 }
 
 func (m ConsumableModelMock) GetByCreatorID(ID int64, filters data.ConsumableFilters) ([]*data.Consumable, data.Metadata, error) {
 	return nil, data.Metadata{}, nil
 }
 
-func (m ConsumableModelMock) Search(data.ConsumableFilters) ([]*data.Consumable, data.Metadata, error) {
+func (m ConsumableModelMock) Search(int64, data.ConsumableFilters) ([]*data.ConsumableMatch, data.Metadata, error) {
 	return nil, data.Metadata{}, nil
 }
 
@@ -20,10 +79,39 @@ func (m ConsumableModelMock) Insert(*data.Consumable) error {
 	return nil
 }
 
-func (m ConsumableModelMock) Update(*data.Consumable) error {
+func (m ConsumableModelMock) Update(*data.Consumable, int64) error {
+	return nil
+}
+
+func (m ConsumableModelMock) SetVisibility(int64, data.ConsumableVisibility) error {
 	return nil
 }
 
-func (m ConsumableModelMock) Delete(int64) error {
+func (m ConsumableModelMock) Delete(int64, int64) error {
+	return nil
+}
+
+func (m ConsumableModelMock) FindDuplicates(ID int64, userID int64) ([]*data.ConsumableDuplicate, error) {
+	consumable, err := m.GetByID(2)
+	if err != nil {
+		return nil, err
+	}
+	return []*data.ConsumableDuplicate{{Consumable: *consumable, Similarity: 0.9, Reasons: []string{"name", "brand", "macros"}}}, nil
+}
+
+func (m ConsumableModelMock) Merge(ID int64, duplicateIDs []int64, mergedBy int64) error {
+	consumable, err := m.GetByID(ID)
+	if err != nil {
+		return err
+	}
+	for _, duplicateID := range duplicateIDs {
+		duplicate, err := m.GetByID(duplicateID)
+		if err != nil {
+			return err
+		}
+		if !duplicate.CanMergeInto(consumable) {
+			return data.ErrIncompatibleMerge
+		}
+	}
 	return nil
 }
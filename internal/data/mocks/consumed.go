@@ -67,6 +67,29 @@ func (m ConsumedModelMock) GetAllByUserIDAndDate(userID int64, start time.Time,
 	}
 }
 
+func (m ConsumedModelMock) GetSummaryByUserID(userID int64, granularity string, start time.Time, end time.Time, timezone string) ([]*data.ConsumedSummary, error) {
+	timeFormat := "2006-01-02 15:04:05"
+
+	switch userID {
+	case 1:
+		return []*data.ConsumedSummary{
+			{
+				PeriodStart: MustParse(timeFormat, "2024-01-01 00:00:00"),
+				Macros: data.Macronutrients{
+					Carbs:    1,
+					Fats:     1,
+					Proteins: 1,
+					Alcohol:  1,
+				},
+				EnergyKJ:   100.1,
+				EntryCount: 1,
+			},
+		}, nil
+	default:
+		return []*data.ConsumedSummary{}, nil
+	}
+}
+
 func (m ConsumedModelMock) Insert(consumed *data.Consumed) error {
 	return nil
 }
@@ -0,0 +1,37 @@
+package mocks
+
+import "github.com/tconnellan/macro-tracker-backend/internal/data"
+
+type GoalModelMock struct{}
+
+func (m GoalModelMock) GetByUserID(userID int64) (*data.Goal, error) {
+
+	timeFormat := "2006-01-02 15:04:05"
+
+	switch userID {
+	case 1:
+		return &data.Goal{
+			ID:     1,
+			UserID: 1,
+			Macros: data.Macronutrients{
+				Carbs:    200,
+				Fats:     70,
+				Proteins: 150,
+				Alcohol:  0,
+			},
+			EnergyKJ:     8700,
+			CreatedAt:    MustParse(timeFormat, "2024-01-01 10:00:00"),
+			LastEditedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
+		}, nil
+	default:
+		return nil, data.ErrRecordNotFound
+	}
+}
+
+func (m GoalModelMock) Upsert(goal *data.Goal) error {
+	return nil
+}
+
+func (m GoalModelMock) Delete(userID int64) error {
+	return nil
+}
@@ -12,9 +12,14 @@ func NewTestModel() data.Models {
 		Tokens:           TokenModelMock{},
 		Consumed:         ConsumedModelMock{},
 		Consumables:      ConsumableModelMock{},
+		ConsumableEdits:  ConsumableEditModelMock{},
 		Recipes:          RecipeModelMock{},
 		RecipeComponents: RecipeComponentModelMock{},
 		PantryItems:      PantryItemModelMock{},
+		Goals:            GoalModelMock{},
+		ServingSizes:     ServingSizeModelMock{},
+		Tags:             TagModelMock{},
+		Collections:      CollectionModelMock{},
 	}
 }
 
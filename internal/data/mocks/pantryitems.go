@@ -8,8 +8,14 @@ func (m PantryItemModelMock) GetAllByUserID(int64) ([]*data.PantryItem, error) {
 	return nil, nil
 }
 
-func (m PantryItemModelMock) Get(int64) (*data.PantryItem, error) {
-	return nil, nil
+func (m PantryItemModelMock) Get(ID int64) (*data.PantryItem, error) {
+	switch ID {
+	case 1, 2:
+		// pantry item 1 holds consumable 1 and 2 holds consumable 2
+		return &data.PantryItem{ID: ID, UserID: 1, ConsumableId: ID, Name: "pantry item"}, nil
+	default:
+		return nil, data.ErrRecordNotFound
+	}
 }
 
 func (m PantryItemModelMock) Create(*data.PantryItem) error {
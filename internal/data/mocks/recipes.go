@@ -22,8 +22,43 @@ func (m RecipeModelMock) GetLatestByCreatorID(int64, data.RecipeFilters) ([]*dat
 	return nil, data.Metadata{}, nil
 }
 
-func (m RecipeModelMock) GetFullRecipe(int64, int64) (*data.FullRecipe, error) {
-	return nil, nil
+func (m RecipeModelMock) GetFullRecipe(ID int64, userID int64) (*data.FullRecipe, error) {
+	switch {
+	case ID == 1 && userID == 1:
+		return &data.FullRecipe{
+			Recipe: data.Recipe{
+				ID:           1,
+				Name:         "recipe",
+				CreatorID:    1,
+				IsLatest:     true,
+				CookedWeight: 200,
+			},
+			RecipeComponents: []*data.RecipeComponent{
+				{ID: 1, RecipeID: 1, PantryItemID: 1, Quantity: 100, StepNo: 1},
+			},
+			PantryItems: []*data.PantryItem{
+				{ID: 1, UserID: 1, ConsumableId: 1, Name: "pantry item"},
+			},
+			Consumables: []*data.Consumable{
+				{
+					ID:        1,
+					CreatorID: 1,
+					Name:      "consumable",
+					BrandName: "brand",
+					Size:      100,
+					Units:     "g",
+					Macros: data.Macronutrients{
+						Carbs:    1,
+						Fats:     1,
+						Proteins: 1,
+						Alcohol:  1,
+					},
+				},
+			},
+		}, nil
+	default:
+		return nil, data.ErrRecordNotFound
+	}
 }
 
 func (m RecipeModelMock) Insert(*data.Recipe) error {
@@ -53,3 +88,53 @@ func (m RecipeModelMock) GetParentRecipe(*data.Recipe) (*data.Recipe, error) {
 func (m RecipeModelMock) GetAllAncestors(*data.Recipe, data.RecipeFilters) ([]*data.Recipe, data.Metadata, error) {
 	return nil, data.Metadata{}, nil
 }
+
+func (m RecipeModelMock) GetVersionTree(int64, int64) (*data.RecipeTree, error) {
+	return nil, data.ErrRecordNotFound
+}
+
+func (m RecipeModelMock) SameLineage(ID int64, otherID int64, userID int64) (bool, error) {
+	return ID == otherID, nil
+}
+
+func (m RecipeModelMock) GetPublic(data.RecipeFilters) ([]*data.Recipe, data.Metadata, error) {
+	return []*data.Recipe{}, data.Metadata{}, nil
+}
+
+func (m RecipeModelMock) GetPublicFullRecipe(ID int64) (*data.FullRecipe, error) {
+	fullRecipe, err := m.GetFullRecipe(ID, 1)
+	if err != nil {
+		return nil, err
+	}
+	fullRecipe.Recipe.IsPublic = true
+	return fullRecipe, nil
+}
+
+func (m RecipeModelMock) SetVisibility(ID int64, userID int64, isPublic bool) error {
+	switch {
+	case ID == 1 && userID == 1:
+		return nil
+	case ID == 2 && userID == 1 && isPublic:
+		return data.ErrSubRecipeNotPublic
+	case ID == 2 && userID == 1:
+		return nil
+	default:
+		return data.ErrRecordNotFound
+	}
+}
+
+func (m RecipeModelMock) ForkFullRecipe(ID int64, userID int64) (*data.FullRecipe, error) {
+	source, err := m.GetPublicFullRecipe(ID)
+	if err != nil {
+		return nil, err
+	}
+	source.Recipe.ID = 100
+	source.Recipe.CreatorID = userID
+	source.Recipe.IsPublic = false
+	source.Recipe.ForkedFromID = ID
+	return source, nil
+}
+
+func (m RecipeModelMock) Search(int64, data.RecipeFilters) ([]*data.Recipe, data.Metadata, error) {
+	return []*data.Recipe{}, data.Metadata{}, nil
+}
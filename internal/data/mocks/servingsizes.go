@@ -0,0 +1,40 @@
+package mocks
+
+import "github.com/tconnellan/macro-tracker-backend/internal/data"
+
+type ServingSizeModelMock struct{}
+
+func (m ServingSizeModelMock) Get(ID int64) (*data.ServingSize, error) {
+	switch ID {
+	case 1:
+		return &data.ServingSize{
+			ID:           1,
+			ConsumableID: 1,
+			Name:         "1 slice",
+			Amount:       30,
+			Units:        "g",
+		}, nil
+	case 2:
+		return &data.ServingSize{
+			ID:           2,
+			ConsumableID: 2,
+			Name:         "1 cup",
+			Amount:       250,
+			Units:        "ml",
+		}, nil
+	default:
+		return nil, data.ErrRecordNotFound
+	}
+}
+
+func (m ServingSizeModelMock) GetByConsumableIDs([]int64) (map[int64][]*data.ServingSize, error) {
+	return map[int64][]*data.ServingSize{}, nil
+}
+
+func (m ServingSizeModelMock) Insert(*data.ServingSize, int64) error {
+	return nil
+}
+
+func (m ServingSizeModelMock) Delete(int64, int64, int64) error {
+	return nil
+}
@@ -0,0 +1,33 @@
+package mocks
+
+import "github.com/tconnellan/macro-tracker-backend/internal/data"
+
+type TagModelMock struct{}
+
+func (m TagModelMock) GetAllByUserID(int64) ([]*data.Tag, error) {
+	return []*data.Tag{}, nil
+}
+
+func (m TagModelMock) GetByRecipeID(int64, int64) ([]*data.Tag, error) {
+	return []*data.Tag{}, nil
+}
+
+func (m TagModelMock) Insert(*data.Tag) error {
+	return nil
+}
+
+func (m TagModelMock) Update(*data.Tag) error {
+	return nil
+}
+
+func (m TagModelMock) Delete(int64, int64) error {
+	return nil
+}
+
+func (m TagModelMock) AddRecipe(int64, int64, int64) error {
+	return nil
+}
+
+func (m TagModelMock) RemoveRecipe(int64, int64, int64) error {
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tconnellan/macro-tracker-backend/internal/conversion"
 )
 
 var (
@@ -18,6 +19,19 @@ var (
 	ErrPantryItemDoesNotExist     = errors.New("pantry item does not exist")
 	ErrChildRecipeExists          = errors.New("child recipe exists")
 	ErrRecipeDoesNotExist         = errors.New("recipe does not exists")
+	ErrConsumableDoesNotExist     = errors.New("consumable does not exist")
+	ErrIncompatibleUnits          = conversion.ErrIncompatibleUnits
+	ErrServingSizeDoesNotExist    = errors.New("serving size does not exist")
+	ErrServingSizeInUse           = errors.New("serving size is used by a recipe or consumed entry")
+	ErrSubRecipeDoesNotExist      = errors.New("sub-recipe does not exist")
+	ErrRecipeCycle                = errors.New("recipe contains itself through its sub-recipes")
+	ErrSubRecipeNotPublic         = errors.New("recipe uses sub-recipes that are not public")
+	ErrRecipeInUse                = errors.New("recipe is used as a sub-recipe")
+	ErrDuplicateTag               = errors.New("tag already exists")
+	ErrDuplicateCollection        = errors.New("collection already exists")
+	ErrDuplicateBarcode           = errors.New("barcode is already used by a consumable of the brand")
+	ErrFractionalServings         = errors.New("scaling does not give a whole number of servings")
+	ErrIncompatibleMerge          = errors.New("consumable is measured in units that cannot be converted to those of the consumable it is merged into")
 )
 
 type Models struct {
@@ -25,9 +39,14 @@ type Models struct {
 	Tokens           ITokenModel
 	Consumed         IConsumedModel
 	Consumables      IConsumableModel
+	ConsumableEdits  IConsumableEditModel
 	Recipes          IRecipeModel
 	RecipeComponents IRecipeComponentModel
 	PantryItems      IPantryItemModel
+	Goals            IGoalModel
+	ServingSizes     IServingSizeModel
+	Tags             ITagModel
+	Collections      ICollectionModel
 }
 
 func NewModel(db *pgxpool.Pool) Models {
@@ -36,9 +55,14 @@ func NewModel(db *pgxpool.Pool) Models {
 		Tokens:           TokenModel{DB: db},
 		Consumed:         ConsumedModel{DB: db},
 		Consumables:      ConsumableModel{DB: db},
+		ConsumableEdits:  ConsumableEditModel{DB: db},
 		Recipes:          RecipeModel{DB: db},
 		RecipeComponents: RecipeComponentModel{DB: db},
 		PantryItems:      PantryItemModel{DB: db},
+		Goals:            GoalModel{DB: db},
+		ServingSizes:     ServingSizeModel{DB: db},
+		Tags:             TagModel{DB: db},
+		Collections:      CollectionModel{DB: db},
 	}
 }
 
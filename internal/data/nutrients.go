@@ -0,0 +1,156 @@
+package data
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/tconnellan/macro-tracker-backend/internal/validator"
+)
+
+// Nutrient describes one entry of a NutrientPanel, amounts are recorded in Unit
+type Nutrient struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	Unit string `json:"unit"`
+}
+
+// Nutrients are the nutrients tracked beyond macronutrients. Panels are stored as JSON keyed
+// by Nutrient.Key so adding, removing or reordering nutrients needs no migration, a key must
+// never be reused for a different nutrient
+var Nutrients = [...]Nutrient{
+	{Key: "fibre", Name: "Fibre", Unit: "g"},
+	{Key: "sugars", Name: "Sugars", Unit: "g"},
+	{Key: "saturated_fat", Name: "Saturated fat", Unit: "g"},
+	{Key: "trans_fat", Name: "Trans fat", Unit: "g"},
+	{Key: "cholesterol", Name: "Cholesterol", Unit: "mg"},
+	{Key: "sodium", Name: "Sodium", Unit: "mg"},
+	{Key: "potassium", Name: "Potassium", Unit: "mg"},
+	{Key: "calcium", Name: "Calcium", Unit: "mg"},
+	{Key: "iron", Name: "Iron", Unit: "mg"},
+	{Key: "magnesium", Name: "Magnesium", Unit: "mg"},
+	{Key: "zinc", Name: "Zinc", Unit: "mg"},
+	{Key: "vitamin_a", Name: "Vitamin A", Unit: "µg"},
+	{Key: "vitamin_c", Name: "Vitamin C", Unit: "mg"},
+	{Key: "vitamin_d", Name: "Vitamin D", Unit: "µg"},
+	{Key: "vitamin_b12", Name: "Vitamin B12", Unit: "µg"},
+	{Key: "folate", Name: "Folate", Unit: "µg"},
+}
+
+// ErrUnknownNutrient is returned for a nutrient key not in Nutrients
+var ErrUnknownNutrient = errors.New("unknown nutrient")
+
+// NutrientPanel holds an amount for each of Nutrients by index, an array keeps panels comparable
+type NutrientPanel [len(Nutrients)]float64
+
+func nutrientIndex(key string) (int, bool) {
+	for i, nutrient := range Nutrients {
+		if nutrient.Key == key {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (panel NutrientPanel) Get(key string) (float64, bool) {
+	i, ok := nutrientIndex(key)
+	if !ok {
+		return 0, false
+	}
+	return panel[i], true
+}
+
+func (panel *NutrientPanel) Set(key string, amount float64) error {
+	i, ok := nutrientIndex(key)
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownNutrient, key)
+	}
+	panel[i] = amount
+	return nil
+}
+
+func (panel NutrientPanel) Add(other NutrientPanel) NutrientPanel {
+	for i := range panel {
+		panel[i] += other[i]
+	}
+	return panel
+}
+
+func (panel NutrientPanel) Subtract(other NutrientPanel) NutrientPanel {
+	for i := range panel {
+		panel[i] -= other[i]
+	}
+	return panel
+}
+
+func (panel NutrientPanel) Scale(factor float64) NutrientPanel {
+	for i := range panel {
+		panel[i] *= factor
+	}
+	return panel
+}
+
+// MarshalJSON writes the panel as an object of nutrient key to amount, nutrients without an
+// amount are left out
+func (panel NutrientPanel) MarshalJSON() ([]byte, error) {
+	amounts := map[string]float64{}
+	for i, amount := range panel {
+		if amount != 0 {
+			amounts[Nutrients[i].Key] = amount
+		}
+	}
+	return json.Marshal(amounts)
+}
+
+// UnmarshalJSON reads an object of nutrient key to amount, a key not in Nutrients is an
+// ErrUnknownNutrient so a misspelt nutrient sent to the API is not silently dropped
+func (panel *NutrientPanel) UnmarshalJSON(data []byte) error {
+	var amounts map[string]float64
+	if err := json.Unmarshal(data, &amounts); err != nil {
+		return err
+	}
+
+	*panel = NutrientPanel{}
+	for key, amount := range amounts {
+		if err := panel.Set(key, amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Scan reads a panel stored in the database. Unlike UnmarshalJSON keys not in Nutrients are
+// ignored so panels stored before a nutrient was removed can still be read
+func (panel *NutrientPanel) Scan(src any) error {
+	var data []byte
+	switch src := src.(type) {
+	case nil:
+		*panel = NutrientPanel{}
+		return nil
+	case string:
+		data = []byte(src)
+	case []byte:
+		data = src
+	default:
+		return fmt.Errorf("cannot scan %T into a nutrient panel", src)
+	}
+
+	var amounts map[string]float64
+	if err := json.Unmarshal(data, &amounts); err != nil {
+		return err
+	}
+
+	*panel = NutrientPanel{}
+	for key, amount := range amounts {
+		if i, ok := nutrientIndex(key); ok {
+			panel[i] = amount
+		}
+	}
+	return nil
+}
+
+func ValidateNutrientPanel(v *validator.Validator, panel NutrientPanel) {
+	for i, amount := range panel {
+		v.Check(amount >= 0, Nutrients[i].Key, "must be non-negative")
+	}
+}
@@ -0,0 +1,117 @@
+package data
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/tconnellan/macro-tracker-backend/internal/assert"
+	"github.com/tconnellan/macro-tracker-backend/internal/validator"
+)
+
+func TestNutrientPanelHelpers(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		json     string
+		valid    bool
+		expectOK bool
+		fibre    float64
+		sodium   float64
+	}{
+		{
+			name:     "valid panel",
+			json:     `{"fibre": 3.5, "sodium": 120}`,
+			valid:    true,
+			expectOK: true,
+			fibre:    3.5,
+			sodium:   120,
+		},
+		{
+			name:     "valid empty panel",
+			json:     `{}`,
+			valid:    true,
+			expectOK: true,
+		},
+		{
+			name:     "invalid negative nutrient",
+			json:     `{"fibre": -1}`,
+			valid:    false,
+			expectOK: true,
+			fibre:    -1,
+		},
+		{
+			name:     "invalid unknown nutrient",
+			json:     `{"fibre": 2, "fiber": 1}`,
+			expectOK: false,
+		},
+		{
+			name:     "invalid not an object",
+			json:     `[1, 2]`,
+			expectOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var panel NutrientPanel
+
+			err := json.Unmarshal([]byte(tt.json), &panel)
+			assert.Equal(t, err == nil, tt.expectOK)
+			if err != nil {
+				return
+			}
+
+			fibre, _ := panel.Get("fibre")
+			sodium, _ := panel.Get("sodium")
+			assert.Equal(t, fibre, tt.fibre)
+			assert.Equal(t, sodium, tt.sodium)
+
+			v := validator.New()
+			ValidateNutrientPanel(v, panel)
+			assert.ValidatorValid(t, v, tt.valid)
+
+			js, err := json.Marshal(panel)
+			assert.NilError(t, err)
+
+			var roundTrip NutrientPanel
+			err = json.Unmarshal(js, &roundTrip)
+			assert.NilError(t, err)
+			assert.Equal(t, roundTrip, panel)
+		})
+	}
+}
+
+func TestNutrientPanelScan(t *testing.T) {
+
+	// stored panels may hold nutrients that have since been removed
+	var panel NutrientPanel
+	err := panel.Scan(`{"fibre": 2, "unobtainium": 1}`)
+	assert.NilError(t, err)
+	fibre, _ := panel.Get("fibre")
+	assert.Equal(t, fibre, 2.0)
+
+	err = panel.Scan([]byte(`{"sodium": 5}`))
+	assert.NilError(t, err)
+	sodium, _ := panel.Get("sodium")
+	assert.Equal(t, sodium, 5.0)
+	fibre, _ = panel.Get("fibre")
+	assert.Equal(t, fibre, 0.0)
+
+	err = json.Unmarshal([]byte(`{"unobtainium": 1}`), &panel)
+	assert.ExpectError(t, err, ErrUnknownNutrient)
+}
+
+func TestNutrientPanelArithmetic(t *testing.T) {
+	var a, b NutrientPanel
+	a.Set("fibre", 2)
+	a.Set("iron", 1)
+	b.Set("fibre", 1)
+	b.Set("sodium", 100)
+
+	var expect NutrientPanel
+	expect.Set("fibre", 6)
+	expect.Set("iron", 2)
+	expect.Set("sodium", 200)
+
+	assert.Equal(t, a.Add(b).Scale(2), expect)
+}
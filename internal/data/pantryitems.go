@@ -201,3 +201,43 @@ func (m PantryItemModel) Delete(ID int64, userID int64) error {
 
 	return nil
 }
+
+// getOrCreateForConsumable returns the user's pantry item for the consumable, creating one with the given
+// name when the user has none
+func getOrCreateForConsumable(userID int64, consumableID int64, name string, db psqlDB) (*PantryItem, error) {
+	stmt := `
+	SELECT id, user_id, consumable_id, name, created_at, last_modified
+	FROM pantry_items
+	WHERE user_id = $1 AND consumable_id = $2
+	ORDER BY id ASC
+	LIMIT 1
+	`
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	var pantryItem PantryItem
+
+	err := db.QueryRow(ctx, stmt, userID, consumableID).Scan(
+		&pantryItem.ID,
+		&pantryItem.UserID,
+		&pantryItem.ConsumableId,
+		&pantryItem.Name,
+		&pantryItem.CreatedAt,
+		&pantryItem.LastEditedAt,
+	)
+	switch {
+	case err == nil:
+		return &pantryItem, nil
+	case !errors.Is(err, pgx.ErrNoRows):
+		return nil, err
+	}
+
+	pantryItem = PantryItem{UserID: userID, ConsumableId: consumableID, Name: name}
+	err = create(&pantryItem, db)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pantryItem, nil
+}
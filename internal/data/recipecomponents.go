@@ -11,17 +11,41 @@ import (
 )
 
 type RecipeComponent struct {
-	ID              int64     `json:"id"`
-	RecipeID        int64     `json:"recipe_id"`
-	PantryItemID    int64     `json:"pantry_item_id"`
-	CreatedAt       time.Time `json:"created_at"`
-	Quantity        float64   `json:"quantity"`
-	StepNo          int64     `json:"step_no"`
-	StepDescription string    `json:"step_description"`
+	ID           int64 `json:"id"`
+	RecipeID     int64 `json:"recipe_id"`
+	PantryItemID int64 `json:"pantry_item_id"`
+	// SubRecipeID when set makes the step use another recipe instead of a pantry item, Quantity is then
+	// a fraction of the sub-recipe, or a count of its servings when Units is servings
+	SubRecipeID int64     `json:"sub_recipe_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	Quantity    float64   `json:"quantity"`
+	// Units the quantity is measured in, empty for the units of the step's consumable
+	Units MeasurementUnit `json:"units"`
+	// ServingID when set makes the quantity a count of that serving size, Units must then be empty
+	ServingID       int64  `json:"serving_id"`
+	StepNo          int64  `json:"step_no"`
+	StepDescription string `json:"step_description"`
+}
+
+// isValidSubRecipeUnit reports whether a sub-recipe can be measured in units, no units means a fraction
+// of the sub-recipe and weights are of the cooked sub-recipe
+func isValidSubRecipeUnit(units MeasurementUnit) bool {
+	switch units {
+	case "", "servings", "g", "oz", "lb":
+		return true
+	default:
+		return false
+	}
 }
 
 func ValidateRecipeComponent(v *validator.Validator, recipeComponent *RecipeComponent) {
+	v.Check(recipeComponent.PantryItemID != 0 || recipeComponent.SubRecipeID != 0, "pantry_item_id", "must provide a pantry_item_id or sub_recipe_id")
+	v.Check(recipeComponent.PantryItemID == 0 || recipeComponent.SubRecipeID == 0, "sub_recipe_id", "must not be provided with a pantry_item_id")
+	v.Check(recipeComponent.SubRecipeID == 0 || recipeComponent.ServingID == 0, "serving_id", "must not be provided with a sub_recipe_id")
+	v.Check(recipeComponent.SubRecipeID == 0 || isValidSubRecipeUnit(recipeComponent.Units), "units", "must be empty, servings or a weight for a sub_recipe_id")
 	v.Check(recipeComponent.Quantity > 0, "quantity", "must be positive")
+	v.Check(recipeComponent.Units == "" || isValidMeasurementUnit(recipeComponent.Units), "units", "must be valid")
+	v.Check(recipeComponent.ServingID == 0 || recipeComponent.Units == "", "units", "must not be provided with a serving_id")
 	v.Check(recipeComponent.StepNo > 0, "step_no", "must be positive")
 	v.Check(len(recipeComponent.StepDescription) <= 1000, "step_description", "must be at must 1000")
 }
@@ -38,7 +62,7 @@ type IRecipeComponentModel interface {
 
 func (m RecipeComponentModel) Get(ID int64) (*RecipeComponent, error) {
 	stmt := `
-	SELECT id, recipe_id, pantry_item_id, created_at, quantity, step_no, step_description
+	SELECT id, recipe_id, COALESCE(pantry_item_id, 0), COALESCE(sub_recipe_id, 0), created_at, quantity, COALESCE(units, ''), COALESCE(serving_id, 0), step_no, step_description
 	FROM recipe_components
 	WHERE id = $1
 	`
@@ -52,8 +76,11 @@ func (m RecipeComponentModel) Get(ID int64) (*RecipeComponent, error) {
 		&recipeComponent.ID,
 		&recipeComponent.RecipeID,
 		&recipeComponent.PantryItemID,
+		&recipeComponent.SubRecipeID,
 		&recipeComponent.CreatedAt,
 		&recipeComponent.Quantity,
+		&recipeComponent.Units,
+		&recipeComponent.ServingID,
 		&recipeComponent.StepNo,
 		&recipeComponent.StepDescription,
 	)
@@ -72,8 +99,8 @@ func (m RecipeComponentModel) Get(ID int64) (*RecipeComponent, error) {
 
 func (m RecipeComponentModel) Insert(recipeComponent *RecipeComponent) error {
 	stmt := `
-	INSERT INTO recipe_components(recipe_id, pantry_item_id, quantity, step_no, step_description)
-	VALUES ($1, $2, $3, $4, $5)
+	INSERT INTO recipe_components(recipe_id, pantry_item_id, quantity, step_no, step_description, units, serving_id, sub_recipe_id)
+	VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8)
 	RETURNING id, created_at
 	`
 
@@ -82,10 +109,13 @@ func (m RecipeComponentModel) Insert(recipeComponent *RecipeComponent) error {
 
 	args := []any{
 		&recipeComponent.RecipeID,
-		&recipeComponent.PantryItemID,
+		nullableID(recipeComponent.PantryItemID),
 		&recipeComponent.Quantity,
 		&recipeComponent.StepNo,
 		&recipeComponent.StepDescription,
+		&recipeComponent.Units,
+		nullableID(recipeComponent.ServingID),
+		nullableID(recipeComponent.SubRecipeID),
 	}
 
 	err := m.DB.QueryRow(ctx, stmt, args...).Scan(
@@ -98,6 +128,10 @@ func (m RecipeComponentModel) Insert(recipeComponent *RecipeComponent) error {
 			return ErrRecipeDoesNotExist
 		case strings.HasPrefix(err.Error(), "ERROR: insert or update on table \"recipe_components\" violates foreign key constraint \"fk_recipecomponent_pantry_item\""):
 			return ErrPantryItemDoesNotExist
+		case strings.HasPrefix(err.Error(), "ERROR: insert or update on table \"recipe_components\" violates foreign key constraint \"fk_recipecomponent_serving\""):
+			return ErrServingSizeDoesNotExist
+		case strings.HasPrefix(err.Error(), "ERROR: insert or update on table \"recipe_components\" violates foreign key constraint \"fk_recipecomponent_sub_recipe\""):
+			return ErrSubRecipeDoesNotExist
 		}
 		return err
 	}
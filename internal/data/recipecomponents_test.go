@@ -82,6 +82,75 @@ func TestRecipeComponentHelpers(t *testing.T) {
 				StepDescription: "description",
 			},
 		},
+		{
+			name:  "valid sub-recipe component",
+			valid: true,
+			recipeComponent: RecipeComponent{
+				RecipeID:        1,
+				SubRecipeID:     2,
+				Quantity:        0.5,
+				StepNo:          1,
+				StepDescription: "description",
+			},
+		},
+		{
+			name:  "valid sub-recipe component in servings",
+			valid: true,
+			recipeComponent: RecipeComponent{
+				RecipeID:        1,
+				SubRecipeID:     2,
+				Quantity:        2,
+				Units:           "servings",
+				StepNo:          1,
+				StepDescription: "description",
+			},
+		},
+		{
+			name:  "invalid component pantry item and sub-recipe",
+			valid: false,
+			recipeComponent: RecipeComponent{
+				RecipeID:        1,
+				PantryItemID:    1,
+				SubRecipeID:     2,
+				Quantity:        1,
+				StepNo:          1,
+				StepDescription: "description",
+			},
+		},
+		{
+			name:  "invalid component neither pantry item nor sub-recipe",
+			valid: false,
+			recipeComponent: RecipeComponent{
+				RecipeID:        1,
+				Quantity:        1,
+				StepNo:          1,
+				StepDescription: "description",
+			},
+		},
+		{
+			name:  "valid sub-recipe component in grams",
+			valid: true,
+			recipeComponent: RecipeComponent{
+				RecipeID:        1,
+				SubRecipeID:     2,
+				Quantity:        100,
+				Units:           "g",
+				StepNo:          1,
+				StepDescription: "description",
+			},
+		},
+		{
+			name:  "invalid sub-recipe component in millilitres",
+			valid: false,
+			recipeComponent: RecipeComponent{
+				RecipeID:        1,
+				SubRecipeID:     2,
+				Quantity:        100,
+				Units:           "ml",
+				StepNo:          1,
+				StepDescription: "description",
+			},
+		},
 	}
 
 	for _, tt := range tests {
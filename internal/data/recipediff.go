@@ -0,0 +1,217 @@
+package data
+
+import (
+	"errors"
+	"slices"
+)
+
+// TextChange is a changed text field of a recipe
+type TextChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// StepAmount is how much of its pantry item a step uses
+type StepAmount struct {
+	Quantity  float64         `json:"quantity"`
+	Units     MeasurementUnit `json:"units"`
+	ServingID int64           `json:"serving_id"`
+}
+
+func stepAmountOf(component *RecipeComponent) StepAmount {
+	return StepAmount{Quantity: component.Quantity, Units: component.Units, ServingID: component.ServingID}
+}
+
+// StepChange describes a step that was added or removed
+type StepChange struct {
+	StepNo       int64      `json:"step_no"`
+	PantryItemID int64      `json:"pantry_item_id"`
+	SubRecipeID  int64      `json:"sub_recipe_id"`
+	Name         string     `json:"name"`
+	Amount       StepAmount `json:"amount"`
+}
+
+// StepMove describes a step kept in both versions whose position relative to the other kept steps changed
+type StepMove struct {
+	PantryItemID int64  `json:"pantry_item_id"`
+	SubRecipeID  int64  `json:"sub_recipe_id"`
+	Name         string `json:"name"`
+	FromStepNo   int64  `json:"from_step_no"`
+	ToStepNo     int64  `json:"to_step_no"`
+}
+
+// QuantityChange describes a step kept in both versions that uses a different amount of its pantry item
+type QuantityChange struct {
+	PantryItemID int64      `json:"pantry_item_id"`
+	SubRecipeID  int64      `json:"sub_recipe_id"`
+	Name         string     `json:"name"`
+	From         StepAmount `json:"from"`
+	To           StepAmount `json:"to"`
+}
+
+// RecipeDiff describes the changes needed to go from one recipe version to another, NutritionDelta is
+// the nutrition of To less that of From and is nil when either has steps whose units cannot be converted
+type RecipeDiff struct {
+	FromID          int64            `json:"from_id"`
+	ToID            int64            `json:"to_id"`
+	Name            *TextChange      `json:"name,omitempty"`
+	Notes           *TextChange      `json:"notes,omitempty"`
+	StepsAdded      []StepChange     `json:"steps_added"`
+	StepsRemoved    []StepChange     `json:"steps_removed"`
+	StepsReordered  []StepMove       `json:"steps_reordered"`
+	QuantityChanges []QuantityChange `json:"quantity_changes"`
+	NutritionDelta  *NutritionFacts  `json:"nutrition_delta,omitempty"`
+}
+
+// stepPair is a step at index From of one version matched to the step at index To of the other
+type stepPair struct {
+	From, To int
+}
+
+// stepKey identifies what a step uses, either a pantry item or a sub-recipe
+type stepKey struct {
+	PantryItemID, SubRecipeID int64
+}
+
+func stepKeyOf(component *RecipeComponent) stepKey {
+	return stepKey{PantryItemID: component.PantryItemID, SubRecipeID: component.SubRecipeID}
+}
+
+// matchSteps pairs the steps of two versions by pantry item or sub-recipe, when one is used by several
+// steps they are paired in step order. Pairs are in the order of from
+func matchSteps(from, to *FullRecipe) (pairs []stepPair, added []int, removed []int) {
+	unmatched := map[stepKey][]int{}
+	for i, component := range to.RecipeComponents {
+		unmatched[stepKeyOf(component)] = append(unmatched[stepKeyOf(component)], i)
+	}
+
+	for i, component := range from.RecipeComponents {
+		candidates := unmatched[stepKeyOf(component)]
+		if len(candidates) == 0 {
+			removed = append(removed, i)
+			continue
+		}
+		pairs = append(pairs, stepPair{From: i, To: candidates[0]})
+		unmatched[stepKeyOf(component)] = candidates[1:]
+	}
+
+	matched := map[int]bool{}
+	for _, pair := range pairs {
+		matched[pair.To] = true
+	}
+	for i := range to.RecipeComponents {
+		if !matched[i] {
+			added = append(added, i)
+		}
+	}
+
+	return pairs, added, removed
+}
+
+// stepName is the name of the pantry item or sub-recipe step i uses
+func stepName(fullRecipe *FullRecipe, i int) string {
+	if subRecipeID := fullRecipe.RecipeComponents[i].SubRecipeID; subRecipeID != 0 {
+		if subRecipe := fullRecipe.SubRecipe(subRecipeID); subRecipe != nil {
+			return subRecipe.Recipe.Name
+		}
+		return ""
+	}
+	if i < len(fullRecipe.PantryItems) {
+		return fullRecipe.PantryItems[i].Name
+	}
+	return ""
+}
+
+func stepChange(fullRecipe *FullRecipe, i int) StepChange {
+	component := fullRecipe.RecipeComponents[i]
+	return StepChange{
+		StepNo:       component.StepNo,
+		PantryItemID: component.PantryItemID,
+		SubRecipeID:  component.SubRecipeID,
+		Name:         stepName(fullRecipe, i),
+		Amount:       stepAmountOf(component),
+	}
+}
+
+// DiffFullRecipes compares two versions of a recipe, steps are expected in step order as returned by GetFullRecipe
+func DiffFullRecipes(from, to *FullRecipe) (*RecipeDiff, error) {
+	diff := &RecipeDiff{
+		FromID:          from.Recipe.ID,
+		ToID:            to.Recipe.ID,
+		StepsAdded:      []StepChange{},
+		StepsRemoved:    []StepChange{},
+		StepsReordered:  []StepMove{},
+		QuantityChanges: []QuantityChange{},
+	}
+
+	if from.Recipe.Name != to.Recipe.Name {
+		diff.Name = &TextChange{From: from.Recipe.Name, To: to.Recipe.Name}
+	}
+	if from.Recipe.Notes != to.Recipe.Notes {
+		diff.Notes = &TextChange{From: from.Recipe.Notes, To: to.Recipe.Notes}
+	}
+
+	pairs, added, removed := matchSteps(from, to)
+
+	for _, i := range added {
+		diff.StepsAdded = append(diff.StepsAdded, stepChange(to, i))
+	}
+	for _, i := range removed {
+		diff.StepsRemoved = append(diff.StepsRemoved, stepChange(from, i))
+	}
+
+	// rank of each kept step in the other version, a step has moved when its rank differs
+	toRanks := map[int]int{}
+	for rank, i := range sortedPairTargets(pairs) {
+		toRanks[i] = rank
+	}
+
+	for fromRank, pair := range pairs {
+		fromComponent := from.RecipeComponents[pair.From]
+		toComponent := to.RecipeComponents[pair.To]
+
+		if toRanks[pair.To] != fromRank {
+			diff.StepsReordered = append(diff.StepsReordered, StepMove{
+				PantryItemID: fromComponent.PantryItemID,
+				SubRecipeID:  fromComponent.SubRecipeID,
+				Name:         stepName(to, pair.To),
+				FromStepNo:   fromComponent.StepNo,
+				ToStepNo:     toComponent.StepNo,
+			})
+		}
+
+		if stepAmountOf(fromComponent) != stepAmountOf(toComponent) {
+			diff.QuantityChanges = append(diff.QuantityChanges, QuantityChange{
+				PantryItemID: fromComponent.PantryItemID,
+				SubRecipeID:  fromComponent.SubRecipeID,
+				Name:         stepName(to, pair.To),
+				From:         stepAmountOf(fromComponent),
+				To:           stepAmountOf(toComponent),
+			})
+		}
+	}
+
+	fromNutrition, fromErr := from.Nutrition()
+	toNutrition, toErr := to.Nutrition()
+	for _, err := range []error{fromErr, toErr} {
+		if err != nil && !errors.Is(err, ErrIncompatibleUnits) && !errors.Is(err, ErrServingSizeDoesNotExist) && !errors.Is(err, ErrSubRecipeDoesNotExist) {
+			return nil, err
+		}
+	}
+	if fromErr == nil && toErr == nil {
+		delta := toNutrition.Total.Subtract(fromNutrition.Total)
+		diff.NutritionDelta = &delta
+	}
+
+	return diff, nil
+}
+
+// sortedPairTargets lists the indices in the other version of the kept steps in ascending order
+func sortedPairTargets(pairs []stepPair) []int {
+	targets := make([]int, 0, len(pairs))
+	for _, pair := range pairs {
+		targets = append(targets, pair.To)
+	}
+	slices.Sort(targets)
+	return targets
+}
@@ -0,0 +1,72 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/tconnellan/macro-tracker-backend/internal/assert"
+)
+
+func TestDiffFullRecipes(t *testing.T) {
+
+	consumables := []*Consumable{
+		{ID: 1, Size: 100, Units: "g", Macros: Macronutrients{Carbs: 10, Fats: 0, Proteins: 0, Alcohol: 0}},
+		{ID: 2, Size: 100, Units: "g", Macros: Macronutrients{Carbs: 0, Fats: 10, Proteins: 0, Alcohol: 0}},
+		{ID: 3, Size: 100, Units: "g", Macros: Macronutrients{Carbs: 0, Fats: 0, Proteins: 10, Alcohol: 0}},
+	}
+
+	from := &FullRecipe{
+		Recipe: Recipe{ID: 1, Name: "Lasagne", Notes: "a recipe"},
+		RecipeComponents: []*RecipeComponent{
+			{PantryItemID: 1, Quantity: 100, StepNo: 1},
+			{PantryItemID: 2, Quantity: 100, StepNo: 2},
+			{PantryItemID: 3, Quantity: 100, StepNo: 3},
+		},
+		PantryItems: []*PantryItem{
+			{ID: 1, Name: "pasta"},
+			{ID: 2, Name: "oil"},
+			{ID: 3, Name: "mince"},
+		},
+		Consumables: consumables,
+	}
+
+	to := &FullRecipe{
+		Recipe: Recipe{ID: 2, Name: "Lasagne", Notes: "a recipe with more mince"},
+		RecipeComponents: []*RecipeComponent{
+			{PantryItemID: 3, Quantity: 200, StepNo: 1},
+			{PantryItemID: 1, Quantity: 100, StepNo: 2},
+		},
+		PantryItems: []*PantryItem{
+			{ID: 3, Name: "mince"},
+			{ID: 1, Name: "pasta"},
+		},
+		Consumables: []*Consumable{consumables[2], consumables[0]},
+	}
+
+	diff, err := DiffFullRecipes(from, to)
+	assert.NilError(t, err)
+
+	assert.Equal(t, diff.FromID, int64(1))
+	assert.Equal(t, diff.ToID, int64(2))
+	assert.Equal(t, diff.Name == nil, true)
+	assert.Equal(t, *diff.Notes, TextChange{From: "a recipe", To: "a recipe with more mince"})
+
+	assert.Equal(t, len(diff.StepsAdded), 0)
+	assert.Equal(t, len(diff.StepsRemoved), 1)
+	assert.Equal(t, diff.StepsRemoved[0], StepChange{StepNo: 2, PantryItemID: 2, Name: "oil", Amount: StepAmount{Quantity: 100}})
+
+	assert.Equal(t, len(diff.StepsReordered), 2)
+	assert.Equal(t, diff.StepsReordered[0], StepMove{PantryItemID: 1, Name: "pasta", FromStepNo: 1, ToStepNo: 2})
+	assert.Equal(t, diff.StepsReordered[1], StepMove{PantryItemID: 3, Name: "mince", FromStepNo: 3, ToStepNo: 1})
+
+	assert.Equal(t, len(diff.QuantityChanges), 1)
+	assert.Equal(t, diff.QuantityChanges[0], QuantityChange{PantryItemID: 3, Name: "mince", From: StepAmount{Quantity: 100}, To: StepAmount{Quantity: 200}})
+
+	assert.Equal(t, diff.NutritionDelta.Macros, Macronutrients{Carbs: 0, Fats: -10, Proteins: 10, Alcohol: 0})
+
+	// diffing the other way swaps additions and removals
+	diff, err = DiffFullRecipes(to, from)
+	assert.NilError(t, err)
+	assert.Equal(t, len(diff.StepsAdded), 1)
+	assert.Equal(t, len(diff.StepsRemoved), 0)
+	assert.Equal(t, diff.StepsAdded[0].PantryItemID, int64(2))
+}
@@ -1,13 +1,17 @@
 package data
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"math"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tconnellan/macro-tracker-backend/internal/conversion"
 	"github.com/tconnellan/macro-tracker-backend/internal/validator"
 )
 
@@ -20,11 +24,21 @@ type Recipe struct {
 	Notes          string    `json:"notes"`
 	ParentRecipeID int64     `json:"parent_recipe_id"`
 	IsLatest       bool      `json:"is_latest"`
+	// Servings is the number of servings the recipe yields, zero when not given
+	Servings int64 `json:"servings"`
+	// CookedWeight is the weight in grams of the finished dish, zero when not given
+	CookedWeight float64 `json:"cooked_weight"`
+	// IsPublic lets every user browse and fork the recipe
+	IsPublic bool `json:"is_public"`
+	// ForkedFromID is the public recipe this one was forked from, zero when it was not forked
+	ForkedFromID int64 `json:"forked_from_id"`
 }
 
 func ValidateRecipe(v *validator.Validator, recipe *Recipe) {
 	v.Check(recipe.Name != "", "recipe_name", "Cannot be empty")
 	v.Check(len(recipe.Name) <= 50, "recipe_name", "Must be at most 50 characters")
+	v.Check(recipe.Servings >= 0, "servings", "Must be non-negative")
+	v.Check(recipe.CookedWeight >= 0, "cooked_weight", "Must be non-negative")
 }
 
 // type PantryConsumable struct {
@@ -35,6 +49,235 @@ type FullRecipe struct {
 	RecipeComponents []*RecipeComponent `json:"recipe_components"`
 	PantryItems      []*PantryItem      `json:"pantry_items"`
 	Consumables      []*Consumable      `json:"consumables"`
+	// ServingSizes referenced by the steps, looked up by RecipeComponent.ServingID
+	ServingSizes []*ServingSize `json:"serving_sizes"`
+	// SubRecipes used as steps, looked up by RecipeComponent.SubRecipeID. Sub-recipe steps have an empty
+	// pantry item and consumable so steps stay aligned with PantryItems and Consumables
+	SubRecipes []*FullRecipe `json:"sub_recipes"`
+}
+
+func (fullRecipe *FullRecipe) ServingSize(ID int64) *ServingSize {
+	for _, servingSize := range fullRecipe.ServingSizes {
+		if servingSize.ID == ID {
+			return servingSize
+		}
+	}
+	return nil
+}
+
+func (fullRecipe *FullRecipe) SubRecipe(ID int64) *FullRecipe {
+	for _, subRecipe := range fullRecipe.SubRecipes {
+		if subRecipe.Recipe.ID == ID {
+			return subRecipe
+		}
+	}
+	return nil
+}
+
+// FractionForWeight is the fraction of the recipe in amount of units of the cooked dish, the recipe
+// must have a cooked weight and units must be a weight
+func (fullRecipe *FullRecipe) FractionForWeight(amount float64, units MeasurementUnit) (float64, error) {
+	if fullRecipe.Recipe.CookedWeight <= 0 {
+		return 0, ErrIncompatibleUnits
+	}
+
+	grams, err := conversion.Convert(amount, conversion.Unit(units), conversion.Grams, conversion.Profile{})
+	if err != nil {
+		return 0, err
+	}
+
+	return grams / fullRecipe.Recipe.CookedWeight, nil
+}
+
+// subRecipeFraction is the fraction of a sub-recipe a step uses, the quantity is either the fraction
+// itself, a number of the sub-recipe's servings or a weight of the cooked sub-recipe
+func subRecipeFraction(component *RecipeComponent, subRecipe *FullRecipe) (float64, error) {
+	switch component.Units {
+	case "":
+		return component.Quantity, nil
+	case "servings":
+		if subRecipe.Recipe.Servings <= 0 {
+			return 0, ErrIncompatibleUnits
+		}
+		return component.Quantity / float64(subRecipe.Recipe.Servings), nil
+	default:
+		return subRecipe.FractionForWeight(component.Quantity, component.Units)
+	}
+}
+
+// stepAmount is the amount and units of a step's consumable, resolving servings and defaulting
+// to the units of the consumable
+func (fullRecipe *FullRecipe) stepAmount(component *RecipeComponent, consumable *Consumable) (float64, MeasurementUnit, error) {
+	if component.ServingID != 0 {
+		servingSize := fullRecipe.ServingSize(component.ServingID)
+		if servingSize == nil || servingSize.ConsumableID != consumable.ID {
+			return 0, "", ErrServingSizeDoesNotExist
+		}
+		return component.Quantity * servingSize.Amount, servingSize.Units, nil
+	}
+
+	if component.Units == "" {
+		return component.Quantity, consumable.Units, nil
+	}
+	return component.Quantity, component.Units, nil
+}
+
+// NutritionFacts is the nutrition of some amount of a recipe or one of its steps
+type NutritionFacts struct {
+	Macros    Macronutrients `json:"macros"`
+	Nutrients NutrientPanel  `json:"nutrients"`
+	KJ        float64        `json:"kj"`
+}
+
+func newNutritionFacts(macros Macronutrients, nutrients NutrientPanel) NutritionFacts {
+	return NutritionFacts{Macros: macros, Nutrients: nutrients, KJ: macros.CalculateKJ()}
+}
+
+func (facts NutritionFacts) Add(other NutritionFacts) NutritionFacts {
+	return newNutritionFacts(facts.Macros.Add(other.Macros), facts.Nutrients.Add(other.Nutrients))
+}
+
+func (facts NutritionFacts) Subtract(other NutritionFacts) NutritionFacts {
+	return newNutritionFacts(facts.Macros.Subtract(other.Macros), facts.Nutrients.Subtract(other.Nutrients))
+}
+
+func (facts NutritionFacts) Scale(factor float64) NutritionFacts {
+	return newNutritionFacts(facts.Macros.Scale(factor), facts.Nutrients.Scale(factor))
+}
+
+// RecipeNutrition breaks down the nutrition of a recipe, Steps is in the order of RecipeComponents
+type RecipeNutrition struct {
+	Total      NutritionFacts   `json:"total"`
+	PerServing NutritionFacts   `json:"per_serving"`
+	Steps      []NutritionFacts `json:"steps"`
+}
+
+// stepNutrition is the nutrition of step i. The step's amount is converted to the units of its consumable
+// and the consumable's nutrition, given per Size, scaled by amount / Size. A sub-recipe step is its
+// fraction of the sub-recipe's total
+func (fullRecipe *FullRecipe) stepNutrition(i int) (NutritionFacts, error) {
+	component := fullRecipe.RecipeComponents[i]
+
+	if component.SubRecipeID != 0 {
+		subRecipe := fullRecipe.SubRecipe(component.SubRecipeID)
+		if subRecipe == nil {
+			return NutritionFacts{}, ErrSubRecipeDoesNotExist
+		}
+
+		fraction, err := subRecipeFraction(component, subRecipe)
+		if err != nil {
+			return NutritionFacts{}, err
+		}
+
+		nutrition, err := subRecipe.Nutrition()
+		if err != nil {
+			return NutritionFacts{}, err
+		}
+
+		return nutrition.Total.Scale(fraction), nil
+	}
+
+	consumable := fullRecipe.Consumables[i]
+
+	amount, units, err := fullRecipe.stepAmount(component, consumable)
+	if err != nil {
+		return NutritionFacts{}, err
+	}
+
+	macros, err := consumable.MacrosFor(amount, units)
+	if err != nil {
+		return NutritionFacts{}, err
+	}
+
+	nutrients, err := consumable.NutrientsFor(amount, units)
+	if err != nil {
+		return NutritionFacts{}, err
+	}
+
+	return newNutritionFacts(macros, nutrients), nil
+}
+
+// Nutrition sums the nutrition of every step, a recipe without servings is treated as a single serving
+func (fullRecipe *FullRecipe) Nutrition() (RecipeNutrition, error) {
+	nutrition := RecipeNutrition{Steps: []NutritionFacts{}}
+
+	for i := range fullRecipe.RecipeComponents {
+		if i >= len(fullRecipe.Consumables) {
+			break
+		}
+
+		step, err := fullRecipe.stepNutrition(i)
+		if err != nil {
+			return RecipeNutrition{}, err
+		}
+		nutrition.Steps = append(nutrition.Steps, step)
+		nutrition.Total = nutrition.Total.Add(step)
+	}
+
+	nutrition.PerServing = nutrition.Total.Scale(1 / float64(Max(fullRecipe.Recipe.Servings, 1)))
+
+	return nutrition, nil
+}
+
+// Scaled copies the recipe with the quantity of every step multiplied by factor. Servings are scaled
+// by the same factor so each serving stays the same size, returns ErrFractionalServings when that
+// does not give a whole number of servings
+func (fullRecipe *FullRecipe) Scaled(factor float64) (*FullRecipe, error) {
+	scaled := *fullRecipe
+
+	if scaled.Recipe.Servings > 0 {
+		servings := float64(scaled.Recipe.Servings) * factor
+		if servings < 1 || math.Abs(servings-math.Round(servings)) > 1e-9 {
+			return nil, ErrFractionalServings
+		}
+		scaled.Recipe.Servings = int64(math.Round(servings))
+	}
+	scaled.Recipe.CookedWeight *= factor
+
+	scaled.RecipeComponents = make([]*RecipeComponent, len(fullRecipe.RecipeComponents))
+	for i, component := range fullRecipe.RecipeComponents {
+		scaledComponent := *component
+		scaledComponent.Quantity *= factor
+		scaled.RecipeComponents[i] = &scaledComponent
+	}
+
+	return &scaled, nil
+}
+
+// RevertedTo creates the next version of the recipe with the content of an earlier version, the result
+// is saved as a child of fullRecipe with UpdateFullRecipe so history is kept
+func (fullRecipe *FullRecipe) RevertedTo(version *FullRecipe) *FullRecipe {
+	reverted := &FullRecipe{
+		Recipe:           fullRecipe.Recipe,
+		RecipeComponents: make([]*RecipeComponent, len(version.RecipeComponents)),
+		PantryItems:      version.PantryItems,
+		Consumables:      version.Consumables,
+		ServingSizes:     version.ServingSizes,
+		SubRecipes:       version.SubRecipes,
+	}
+
+	reverted.Recipe.ParentRecipeID = fullRecipe.Recipe.ID
+	reverted.Recipe.Name = version.Recipe.Name
+	reverted.Recipe.Notes = version.Recipe.Notes
+	reverted.Recipe.Servings = version.Recipe.Servings
+	reverted.Recipe.CookedWeight = version.Recipe.CookedWeight
+
+	for i, component := range version.RecipeComponents {
+		revertedComponent := *component
+		revertedComponent.RecipeID = fullRecipe.Recipe.ID
+		reverted.RecipeComponents[i] = &revertedComponent
+	}
+
+	return reverted
+}
+
+// TotalMacros sums the macros of every step
+func (fullRecipe *FullRecipe) TotalMacros() (Macronutrients, error) {
+	nutrition, err := fullRecipe.Nutrition()
+	if err != nil {
+		return Macronutrients{}, err
+	}
+	return nutrition.Total.Macros, nil
 }
 
 func ValidateComponentConsumableList(v *validator.Validator, recipeID int64, recipeComponents []*RecipeComponent, pantryItems []*PantryItem, consumables []*Consumable) {
@@ -83,18 +326,106 @@ func ValidateFullRecipe(v *validator.Validator, fullRecipe *FullRecipe) {
 	ValidateComponentConsumableList(v, fullRecipe.Recipe.ID, fullRecipe.RecipeComponents, fullRecipe.PantryItems, fullRecipe.Consumables)
 	for _, recipeComponent := range fullRecipe.RecipeComponents {
 		ValidateRecipeComponent(v, recipeComponent)
+		v.Check(recipeComponent.SubRecipeID == 0 || recipeComponent.SubRecipeID != fullRecipe.Recipe.ID, "sub_recipe_id", "must not be the recipe itself")
 	}
-	for _, pantryItem := range fullRecipe.PantryItems {
-		ValidatePantryItem(v, pantryItem)
+	// sub-recipe steps have no pantry item or consumable of their own
+	isSubRecipeStep := func(i int) bool {
+		return i < len(fullRecipe.RecipeComponents) && fullRecipe.RecipeComponents[i].SubRecipeID != 0
 	}
-	for _, consumable := range fullRecipe.Consumables {
-		ValidateConsumable(v, consumable)
+	for i, pantryItem := range fullRecipe.PantryItems {
+		if !isSubRecipeStep(i) {
+			ValidatePantryItem(v, pantryItem)
+		}
+	}
+	for i, consumable := range fullRecipe.Consumables {
+		if !isSubRecipeStep(i) {
+			ValidateConsumable(v, consumable)
+		}
+	}
+	for i, component := range fullRecipe.RecipeComponents {
+		if i >= len(fullRecipe.Consumables) {
+			break
+		}
+		if component.SubRecipeID != 0 {
+			// sub-recipes are only known once the recipe has been read back
+			if subRecipe := fullRecipe.SubRecipe(component.SubRecipeID); subRecipe != nil {
+				_, err := subRecipeFraction(component, subRecipe)
+				v.Check(err == nil, "units", fmt.Sprintf("step %d must measure its sub-recipe as a fraction, in servings or by a cooked weight the sub-recipe has", component.StepNo))
+			}
+			continue
+		}
+		amount, units, err := fullRecipe.stepAmount(component, fullRecipe.Consumables[i])
+		if err != nil {
+			v.AddError("serving_id", fmt.Sprintf("serving size of step %d must belong to its consumable", component.StepNo))
+			continue
+		}
+		_, err = fullRecipe.Consumables[i].ConvertToUnits(amount, units)
+		v.Check(err == nil, "units", fmt.Sprintf("units of step %d cannot be converted to the units of its consumable", component.StepNo))
 	}
 }
 
 type RecipeFilters struct {
 	Metadata   MetadataFilters
 	NameSearch string
+	// LatestOnly leaves out versions that have been replaced by a newer version
+	LatestOnly bool
+	// ConsumableID and PantryItemID match recipes using them in a step or a sub-recipe, zero matches any
+	ConsumableID int64
+	PantryItemID int64
+	// nutrition ranges are per serving, a zero bound is not applied
+	MinProtein float64
+	MaxProtein float64
+	MinEnergy  float64
+	MaxEnergy  float64
+	// Tags names tags the recipe's lineage must all have
+	Tags []string
+}
+
+// MaxRecipePageSize is the largest page of recipes listed, and the default so a user's recipes come back in
+// one page as they did before the list was searchable
+const MaxRecipePageSize = 1000
+
+func ValidateRecipeFilters(v *validator.Validator, f RecipeFilters) {
+	validateMetadataFilters(v, f.Metadata, MaxRecipePageSize)
+	v.Check(f.ConsumableID >= 0, "consumable_id", "must be positive")
+	v.Check(f.PantryItemID >= 0, "pantry_item_id", "must be positive")
+	v.Check(f.MinProtein >= 0, "min_protein", "must be non-negative")
+	v.Check(f.MaxProtein >= 0, "max_protein", "must be non-negative")
+	v.Check(f.MaxProtein == 0 || f.MaxProtein >= f.MinProtein, "max_protein", "must be at least min_protein")
+	v.Check(f.MinEnergy >= 0, "min_energy", "must be non-negative")
+	v.Check(f.MaxEnergy >= 0, "max_energy", "must be non-negative")
+	v.Check(f.MaxEnergy == 0 || f.MaxEnergy >= f.MinEnergy, "max_energy", "must be at least min_energy")
+	v.Check(len(f.Tags) <= 20, "tags", "must be maximum 20 tags")
+}
+
+// tagNames returns the distinct tag names, never nil so the query can compare its length
+func (r RecipeFilters) tagNames() []string {
+	names := []string{}
+	for _, name := range r.Tags {
+		name = strings.TrimSpace(name)
+		if name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// filtersNutrition reports whether any nutrition range is set
+func (r RecipeFilters) filtersNutrition() bool {
+	return r.MinProtein != 0 || r.MaxProtein != 0 || r.MinEnergy != 0 || r.MaxEnergy != 0
+}
+
+// sortColumn maps the sort to a column of recipes or, for energy, the energy per serving calculated
+// in the query
+func (r RecipeFilters) sortColumn() string {
+	switch column := r.Metadata.sortColumn(); column {
+	case "name":
+		return "recipe_name"
+	case "energy":
+		return "serving_kj"
+	default:
+		return column
+	}
 }
 
 func (r RecipeFilters) getSearchVariable() string {
@@ -118,6 +449,13 @@ type IRecipeModel interface {
 	Delete(int64) error
 	GetParentRecipe(*Recipe) (*Recipe, error)
 	GetAllAncestors(*Recipe, RecipeFilters) ([]*Recipe, Metadata, error)
+	GetVersionTree(int64, int64) (*RecipeTree, error)
+	SameLineage(int64, int64, int64) (bool, error)
+	GetPublic(RecipeFilters) ([]*Recipe, Metadata, error)
+	GetPublicFullRecipe(int64) (*FullRecipe, error)
+	SetVisibility(int64, int64, bool) error
+	ForkFullRecipe(int64, int64) (*FullRecipe, error)
+	Search(int64, RecipeFilters) ([]*Recipe, Metadata, error)
 }
 
 type RecipeModel struct {
@@ -126,7 +464,7 @@ type RecipeModel struct {
 
 func (m RecipeModel) Get(ID int64) (*Recipe, error) {
 	stmt := `
-	SELECT id, recipe_name, creator_id, created_at, last_edited_at, notes, COALESCE(parent_recipe_id, 0), is_latest
+	SELECT id, recipe_name, creator_id, created_at, last_edited_at, notes, COALESCE(parent_recipe_id, 0), is_latest, COALESCE(servings, 0), COALESCE(cooked_weight, 0), is_public, COALESCE(forked_from_id, 0)
 	FROM recipes
 	WHERE id = $1
 	`
@@ -145,6 +483,10 @@ func (m RecipeModel) Get(ID int64) (*Recipe, error) {
 		&recipe.Notes,
 		&recipe.ParentRecipeID,
 		&recipe.IsLatest,
+		&recipe.Servings,
+		&recipe.CookedWeight,
+		&recipe.IsPublic,
+		&recipe.ForkedFromID,
 	)
 
 	if err != nil {
@@ -161,7 +503,7 @@ func (m RecipeModel) Get(ID int64) (*Recipe, error) {
 
 func (m RecipeModel) GetByCreatorID(ID int64, filters RecipeFilters) ([]*Recipe, Metadata, error) {
 	stmt := fmt.Sprintf(`
-	SELECT COUNT(*) OVER(), id, recipe_name, creator_id, created_at, last_edited_at, notes, COALESCE(parent_recipe_id, 0), is_latest
+	SELECT COUNT(*) OVER(), id, recipe_name, creator_id, created_at, last_edited_at, notes, COALESCE(parent_recipe_id, 0), is_latest, COALESCE(servings, 0), COALESCE(cooked_weight, 0), is_public, COALESCE(forked_from_id, 0)
 	FROM recipes
 	WHERE creator_id = $1
 	  AND ($2 = '' or recipe_name ILIKE $2)
@@ -194,6 +536,10 @@ func (m RecipeModel) GetByCreatorID(ID int64, filters RecipeFilters) ([]*Recipe,
 			&recipe.Notes,
 			&recipe.ParentRecipeID,
 			&recipe.IsLatest,
+			&recipe.Servings,
+			&recipe.CookedWeight,
+			&recipe.IsPublic,
+			&recipe.ForkedFromID,
 		)
 		if err != nil {
 			return nil, Metadata{}, err
@@ -210,7 +556,7 @@ func (m RecipeModel) GetByCreatorID(ID int64, filters RecipeFilters) ([]*Recipe,
 
 func (m RecipeModel) GetLatestByCreatorID(ID int64, filters RecipeFilters) ([]*Recipe, Metadata, error) {
 	stmt := fmt.Sprintf(`
-	SELECT COUNT(*) OVER(), id, recipe_name, creator_id, created_at, last_edited_at, notes, COALESCE(parent_recipe_id, 0), is_latest
+	SELECT COUNT(*) OVER(), id, recipe_name, creator_id, created_at, last_edited_at, notes, COALESCE(parent_recipe_id, 0), is_latest, COALESCE(servings, 0), COALESCE(cooked_weight, 0), is_public, COALESCE(forked_from_id, 0)
 	FROM recipes
 	WHERE creator_id = $1 AND is_latest = TRUE
 	  AND ($2 = '' or recipe_name ILIKE $2)
@@ -243,6 +589,10 @@ func (m RecipeModel) GetLatestByCreatorID(ID int64, filters RecipeFilters) ([]*R
 			&recipe.Notes,
 			&recipe.ParentRecipeID,
 			&recipe.IsLatest,
+			&recipe.Servings,
+			&recipe.CookedWeight,
+			&recipe.IsPublic,
+			&recipe.ForkedFromID,
 		)
 		if err != nil {
 			return nil, Metadata{}, err
@@ -258,13 +608,6 @@ func (m RecipeModel) GetLatestByCreatorID(ID int64, filters RecipeFilters) ([]*R
 }
 
 func (m RecipeModel) GetFullRecipe(ID int64, userID int64) (*FullRecipe, error) {
-	// join recipe on componets first, then join components on consumables
-	stmtRecipe := `
-	SELECT id, recipe_name, creator_id, created_at, last_edited_at, notes, COALESCE(parent_recipe_id, 0), is_latest
-	FROM recipes
-	WHERE id = $1 AND creator_id = $2
-	`
-
 	ctx, cancel := GetDefaultTimeoutContext()
 	defer cancel()
 
@@ -274,9 +617,28 @@ func (m RecipeModel) GetFullRecipe(ID int64, userID int64) (*FullRecipe, error)
 	}
 	defer txn.Rollback(ctx)
 
+	fullRecipe, err := getFullRecipe(ctx, txn, ID, userID, map[int64]bool{})
+	if err != nil {
+		return nil, err
+	}
+	txn.Commit(ctx)
+
+	return fullRecipe, nil
+}
+
+// getFullRecipe reads a recipe and, recursively, the sub-recipes used by its steps. expanding holds the
+// recipes being read above this one, a recipe found again while it is being expanded is a cycle
+func getFullRecipe(ctx context.Context, db psqlDB, ID int64, userID int64, expanding map[int64]bool) (*FullRecipe, error) {
+	// join recipe on componets first, then join components on consumables
+	stmtRecipe := `
+	SELECT id, recipe_name, creator_id, created_at, last_edited_at, notes, COALESCE(parent_recipe_id, 0), is_latest, COALESCE(servings, 0), COALESCE(cooked_weight, 0), is_public, COALESCE(forked_from_id, 0)
+	FROM recipes
+	WHERE id = $1 AND creator_id = $2
+	`
+
 	var recipe Recipe
 
-	if err := txn.QueryRow(ctx, stmtRecipe, ID, userID).Scan(
+	if err := db.QueryRow(ctx, stmtRecipe, ID, userID).Scan(
 		&recipe.ID,
 		&recipe.Name,
 		&recipe.CreatorID,
@@ -285,6 +647,10 @@ func (m RecipeModel) GetFullRecipe(ID int64, userID int64) (*FullRecipe, error)
 		&recipe.Notes,
 		&recipe.ParentRecipeID,
 		&recipe.IsLatest,
+		&recipe.Servings,
+		&recipe.CookedWeight,
+		&recipe.IsPublic,
+		&recipe.ForkedFromID,
 	); err != nil {
 		switch {
 		case errors.Is(err, pgx.ErrNoRows):
@@ -294,16 +660,19 @@ func (m RecipeModel) GetFullRecipe(ID int64, userID int64) (*FullRecipe, error)
 		}
 	}
 
+	// sub-recipe steps have no pantry item or consumable, their columns are read as zero values
 	stmtComponents := `
-	SELECT RC.id, RC.recipe_id, RC.pantry_item_id, RC.created_at, RC.quantity, RC.step_no, RC.step_description, P.id, P.user_id, P.consumable_id, P.name, P.created_at, P.last_modified, C.id, C.creator_id, C.created_at, C.name, C.brand_name, C.size, C.units, C.carbs, C.fats, C.proteins, C.alcohol
+	SELECT RC.id, RC.recipe_id, COALESCE(RC.pantry_item_id, 0), COALESCE(RC.sub_recipe_id, 0), RC.created_at, RC.quantity, COALESCE(RC.units, ''), COALESCE(RC.serving_id, 0), RC.step_no, RC.step_description, 
+	       COALESCE(P.id, 0), COALESCE(P.user_id, 0), COALESCE(P.consumable_id, 0), COALESCE(P.name, ''), P.created_at, P.last_modified, 
+	       COALESCE(C.id, 0), COALESCE(C.creator_id, 0), C.created_at, COALESCE(C.name, ''), COALESCE(C.brand_name, ''), COALESCE(C.barcode, ''), COALESCE(C.visibility, ''), COALESCE(C.size, 0), COALESCE(C.units, ''), COALESCE(C.carbs, 0), COALESCE(C.fats, 0), COALESCE(C.proteins, 0), COALESCE(C.alcohol, 0), COALESCE(C.density, 0), COALESCE(C.unit_weight, 0), COALESCE(C.serving_weight, 0), COALESCE(C.nutrients, '{}')
 	FROM recipe_components RC 
-	     INNER JOIN pantry_items P ON RC.pantry_item_id = P.id
-		 INNER JOIN consumables C ON P.consumable_id = C.id
+	     LEFT JOIN pantry_items P ON RC.pantry_item_id = P.id
+		 LEFT JOIN consumables C ON P.consumable_id = C.id
 	WHERE RC.recipe_id = $1
 	ORDER BY RC.step_no ASC
 	`
 
-	rows, err := txn.Query(ctx, stmtComponents, ID)
+	rows, err := db.Query(ctx, stmtComponents, ID)
 	if err != nil {
 		return nil, err
 	}
@@ -317,35 +686,54 @@ func (m RecipeModel) GetFullRecipe(ID int64, userID int64) (*FullRecipe, error)
 		var component RecipeComponent
 		var pantryItem PantryItem
 		var consumable Consumable
+		var pantryItemCreatedAt, pantryItemLastEditedAt, consumableCreatedAt *time.Time
 		err = rows.Scan(
 			&component.ID,
 			&component.RecipeID,
 			&component.PantryItemID,
+			&component.SubRecipeID,
 			&component.CreatedAt,
 			&component.Quantity,
+			&component.Units,
+			&component.ServingID,
 			&component.StepNo,
 			&component.StepDescription,
 			&pantryItem.ID,
 			&pantryItem.UserID,
 			&pantryItem.ConsumableId,
 			&pantryItem.Name,
-			&pantryItem.CreatedAt,
-			&pantryItem.LastEditedAt,
+			&pantryItemCreatedAt,
+			&pantryItemLastEditedAt,
 			&consumable.ID,
 			&consumable.CreatorID,
-			&consumable.CreatedAt,
+			&consumableCreatedAt,
 			&consumable.Name,
 			&consumable.BrandName,
+			&consumable.Barcode,
+			&consumable.Visibility,
 			&consumable.Size,
 			&consumable.Units,
 			&consumable.Macros.Carbs,
 			&consumable.Macros.Fats,
 			&consumable.Macros.Proteins,
 			&consumable.Macros.Alcohol,
+			&consumable.Density,
+			&consumable.UnitWeight,
+			&consumable.ServingWeight,
+			&consumable.Nutrients,
 		)
 		if err != nil {
 			return nil, err
 		}
+		if pantryItemCreatedAt != nil {
+			pantryItem.CreatedAt = *pantryItemCreatedAt
+		}
+		if pantryItemLastEditedAt != nil {
+			pantryItem.LastEditedAt = *pantryItemLastEditedAt
+		}
+		if consumableCreatedAt != nil {
+			consumable.CreatedAt = *consumableCreatedAt
+		}
 		components = append(components, &component)
 		consumables = append(consumables, &consumable)
 		pantryItems = append(pantryItems, &pantryItem)
@@ -354,10 +742,39 @@ func (m RecipeModel) GetFullRecipe(ID int64, userID int64) (*FullRecipe, error)
 	if err = rows.Err(); err != nil {
 		return nil, err
 	}
-	txn.Commit(ctx)
+	rows.Close()
+
+	servingSizes, err := getServingSizesByRecipeID(ctx, db, ID)
+	if err != nil {
+		return nil, err
+	}
+
+	fullRecipe := &FullRecipe{Recipe: recipe, RecipeComponents: components, PantryItems: pantryItems, Consumables: consumables, ServingSizes: servingSizes, SubRecipes: []*FullRecipe{}}
+
+	expanding[ID] = true
+	defer delete(expanding, ID)
+
+	for _, component := range components {
+		if component.SubRecipeID == 0 || fullRecipe.SubRecipe(component.SubRecipeID) != nil {
+			continue
+		}
+		if expanding[component.SubRecipeID] {
+			return nil, ErrRecipeCycle
+		}
 
-	return &FullRecipe{Recipe: recipe, RecipeComponents: components, PantryItems: pantryItems, Consumables: consumables}, nil
+		subRecipe, err := getFullRecipe(ctx, db, component.SubRecipeID, userID, expanding)
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrRecordNotFound):
+				return nil, ErrSubRecipeDoesNotExist
+			default:
+				return nil, err
+			}
+		}
+		fullRecipe.SubRecipes = append(fullRecipe.SubRecipes, subRecipe)
+	}
 
+	return fullRecipe, nil
 }
 
 func (m RecipeModel) Insert(recipe *Recipe) error {
@@ -366,8 +783,8 @@ func (m RecipeModel) Insert(recipe *Recipe) error {
 
 func insertRecipe(recipe *Recipe, db psqlDB) error {
 	stmt := `
-	INSERT INTO recipes (recipe_name, creator_id, notes, parent_recipe_id, is_latest)
-	VALUES ($1, $2, $3, $4, $5)
+	INSERT INTO recipes (recipe_name, creator_id, notes, parent_recipe_id, is_latest, servings, cooked_weight, is_public, forked_from_id)
+	VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0), NULLIF($7::DOUBLE PRECISION, 0), $8, $9)
 	RETURNING id, created_at, last_edited_at
 	`
 
@@ -381,7 +798,7 @@ func insertRecipe(recipe *Recipe, db psqlDB) error {
 		actualParentID = recipe.ParentRecipeID
 	}
 
-	err := db.QueryRow(ctx, stmt, recipe.Name, recipe.CreatorID, recipe.Notes, actualParentID, recipe.IsLatest).Scan(&recipe.ID, &recipe.CreatedAt, &recipe.LastEditedAt)
+	err := db.QueryRow(ctx, stmt, recipe.Name, recipe.CreatorID, recipe.Notes, actualParentID, recipe.IsLatest, recipe.Servings, recipe.CookedWeight, recipe.IsPublic, nullableID(recipe.ForkedFromID)).Scan(&recipe.ID, &recipe.CreatedAt, &recipe.LastEditedAt)
 
 	if err != nil {
 		switch {
@@ -389,6 +806,8 @@ func insertRecipe(recipe *Recipe, db psqlDB) error {
 			return ErrReferencedUserDoesNotExist
 		case strings.HasPrefix(err.Error(), "ERROR: insert or update on table \"recipes\" violates foreign key constraint \"recipe_child_parent_id\""):
 			return ErrParentRecipeDoesNotExist
+		case strings.HasPrefix(err.Error(), "ERROR: insert or update on table \"recipes\" violates foreign key constraint \"fk_recipe_forked_from\""):
+			return ErrRecipeDoesNotExist
 		default:
 			return err
 		}
@@ -442,22 +861,34 @@ func insertFullRecipe(fullRecipe *FullRecipe, db psqlDB) error {
 	ctx, cancel := GetDefaultTimeoutContext()
 	defer cancel()
 
+	err = checkSubRecipesOwned(ctx, db, fullRecipe)
+	if err != nil {
+		return err
+	}
+
 	// insert recipe components, will fail if pantry items don't already exist
 	_, err = db.CopyFrom(ctx, pgx.Identifier{"recipe_components"},
-		[]string{"recipe_id", "pantry_item_id", "quantity", "step_no", "step_description"},
+		[]string{"recipe_id", "pantry_item_id", "quantity", "step_no", "step_description", "units", "serving_id", "sub_recipe_id"},
 		pgx.CopyFromSlice(len(fullRecipe.RecipeComponents), func(i int) ([]any, error) {
 			return []any{
 				fullRecipe.Recipe.ID,
-				fullRecipe.RecipeComponents[i].PantryItemID,
+				nullableID(fullRecipe.RecipeComponents[i].PantryItemID),
 				fullRecipe.RecipeComponents[i].Quantity,
 				fullRecipe.RecipeComponents[i].StepNo,
 				fullRecipe.RecipeComponents[i].StepDescription,
+				nullableUnits(fullRecipe.RecipeComponents[i].Units),
+				nullableID(fullRecipe.RecipeComponents[i].ServingID),
+				nullableID(fullRecipe.RecipeComponents[i].SubRecipeID),
 			}, nil
 		}))
 	if err != nil {
 		switch {
 		case strings.HasPrefix(err.Error(), "ERROR: insert or update on table \"recipe_components\" violates foreign key constraint \"fk_recipecomponent_pantry_item\""):
 			return ErrPantryItemDoesNotExist
+		case strings.HasPrefix(err.Error(), "ERROR: insert or update on table \"recipe_components\" violates foreign key constraint \"fk_recipecomponent_serving\""):
+			return ErrServingSizeDoesNotExist
+		case strings.HasPrefix(err.Error(), "ERROR: insert or update on table \"recipe_components\" violates foreign key constraint \"fk_recipecomponent_sub_recipe\""):
+			return ErrSubRecipeDoesNotExist
 		}
 		return err
 	}
@@ -465,6 +896,36 @@ func insertFullRecipe(fullRecipe *FullRecipe, db psqlDB) error {
 	return nil
 }
 
+// checkSubRecipesOwned requires every sub-recipe of the recipe to be created by the recipe's creator
+func checkSubRecipesOwned(ctx context.Context, db psqlDB, fullRecipe *FullRecipe) error {
+	var subRecipeIDs []int64
+	for _, component := range fullRecipe.RecipeComponents {
+		if component.SubRecipeID != 0 && !slices.Contains(subRecipeIDs, component.SubRecipeID) {
+			subRecipeIDs = append(subRecipeIDs, component.SubRecipeID)
+		}
+	}
+	if len(subRecipeIDs) == 0 {
+		return nil
+	}
+
+	stmt := `
+	SELECT COUNT(*)
+	FROM recipes
+	WHERE id = ANY($1) AND creator_id = $2
+	`
+
+	var owned int
+	err := db.QueryRow(ctx, stmt, subRecipeIDs, fullRecipe.Recipe.CreatorID).Scan(&owned)
+	if err != nil {
+		return err
+	}
+	if owned != len(subRecipeIDs) {
+		return ErrSubRecipeDoesNotExist
+	}
+
+	return nil
+}
+
 func (m RecipeModel) Update(recipe *Recipe) error {
 	return updateRecipe(recipe, m.DB)
 }
@@ -472,14 +933,14 @@ func (m RecipeModel) Update(recipe *Recipe) error {
 func updateRecipe(recipe *Recipe, conn psqlDB) error {
 	stmt := `
 	UPDATE recipes
-	SET recipe_name = $2, last_edited_at = current_timestamp, notes = $3, is_latest = $4
+	SET recipe_name = $2, last_edited_at = current_timestamp, notes = $3, is_latest = $4, servings = NULLIF($5, 0), cooked_weight = NULLIF($6::DOUBLE PRECISION, 0), is_public = $7
 	WHERE id = $1
 	`
 
 	ctx, cancel := GetDefaultTimeoutContext()
 	defer cancel()
 
-	result, err := conn.Exec(ctx, stmt, recipe.ID, recipe.Name, recipe.Notes, recipe.IsLatest)
+	result, err := conn.Exec(ctx, stmt, recipe.ID, recipe.Name, recipe.Notes, recipe.IsLatest, recipe.Servings, recipe.CookedWeight, recipe.IsPublic)
 	if err != nil {
 		return err
 	}
@@ -494,6 +955,8 @@ func updateRecipe(recipe *Recipe, conn psqlDB) error {
 }
 
 // not good name, maybe createChildOfFullRecipe()
+// the new version replaces its ParentRecipeID, which must be the creator's latest version, otherwise
+// ErrRecordNotFound is returned. The recipe's own ID is ignored and set to that of the new version
 func (m RecipeModel) UpdateFullRecipe(fullRecipe *FullRecipe) error {
 
 	fullRecipe.Recipe.IsLatest = false
@@ -507,12 +970,15 @@ func (m RecipeModel) UpdateFullRecipe(fullRecipe *FullRecipe) error {
 	}
 	defer txn.Rollback(ctx)
 
-	err = updateRecipe(&fullRecipe.Recipe, txn)
+	// visibility belongs to the lineage, the new version is shared if the version it replaces was
+	fullRecipe.Recipe.IsPublic, err = retireRecipe(fullRecipe.Recipe.ParentRecipeID, fullRecipe.Recipe.CreatorID, txn)
 	if err != nil {
 		return err
 	}
 
 	fullRecipe.Recipe.IsLatest = true
+	// only the first version of a fork records the recipe it was forked from
+	fullRecipe.Recipe.ForkedFromID = 0
 
 	err = insertFullRecipe(fullRecipe, txn)
 	if err != nil {
@@ -537,6 +1003,33 @@ func (m RecipeModel) UpdateFullRecipe(fullRecipe *FullRecipe) error {
 	return nil
 }
 
+// retireRecipe marks the creator's latest version of a recipe as no longer the latest, leaving the rest
+// of it as it was, and reports whether it is public
+func retireRecipe(ID int64, creatorID int64, conn psqlDB) (bool, error) {
+	stmt := `
+	UPDATE recipes
+	SET is_latest = false
+	WHERE id = $1 AND creator_id = $2 AND is_latest
+	RETURNING is_public
+	`
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	var isPublic bool
+	err := conn.QueryRow(ctx, stmt, ID, creatorID).Scan(&isPublic)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return false, ErrRecordNotFound
+		default:
+			return false, err
+		}
+	}
+
+	return isPublic, nil
+}
+
 func (m RecipeModel) Delete(ID int64) error {
 
 	stmtRecipeComponent := `
@@ -568,6 +1061,8 @@ func (m RecipeModel) Delete(ID int64) error {
 		switch {
 		case strings.HasPrefix(err.Error(), "ERROR: update or delete on table \"recipes\" violates foreign key constraint \"recipe_child_parent_id\" on table \"recipes\""):
 			return ErrChildRecipeExists
+		case strings.HasPrefix(err.Error(), "ERROR: update or delete on table \"recipes\" violates foreign key constraint \"fk_recipecomponent_sub_recipe\" on table \"recipe_components\""):
+			return ErrRecipeInUse
 		}
 		return err
 	}
@@ -594,7 +1089,7 @@ func getParentRecipe(childRecipe *Recipe, db psqlDB) (*Recipe, error) {
 		return nil, ErrRecordNotFound
 	}
 	stmt := `
-	SELECT id, recipe_name, creator_id, created_at, last_edited_at, notes, COALESCE(parent_recipe_id, 0), is_latest
+	SELECT id, recipe_name, creator_id, created_at, last_edited_at, notes, COALESCE(parent_recipe_id, 0), is_latest, COALESCE(servings, 0), COALESCE(cooked_weight, 0), is_public, COALESCE(forked_from_id, 0)
 	FROM recipes
 	WHERE id = $1
 	`
@@ -613,6 +1108,10 @@ func getParentRecipe(childRecipe *Recipe, db psqlDB) (*Recipe, error) {
 		&parentRecipe.Notes,
 		&parentRecipe.ParentRecipeID,
 		&parentRecipe.IsLatest,
+		&parentRecipe.Servings,
+		&parentRecipe.CookedWeight,
+		&parentRecipe.IsPublic,
+		&parentRecipe.ForkedFromID,
 	}
 
 	err := db.QueryRow(ctx, stmt, childRecipe.ParentRecipeID).Scan(args...)
@@ -631,18 +1130,18 @@ func getParentRecipe(childRecipe *Recipe, db psqlDB) (*Recipe, error) {
 func (m RecipeModel) GetAllAncestors(childRecipe *Recipe, filters RecipeFilters) ([]*Recipe, Metadata, error) {
 
 	stmt := fmt.Sprintf(`
-	WITH RECURSIVE ancestors(id, recipe_name, creator_id, created_at, last_edited_at, notes, parent_recipe_id, is_latest) AS (
-		SELECT id, recipe_name, creator_id, created_at, last_edited_at, notes, COALESCE(parent_recipe_id, 0) AS parent_recipe_id, is_latest
+	WITH RECURSIVE ancestors(id, recipe_name, creator_id, created_at, last_edited_at, notes, parent_recipe_id, is_latest, servings, cooked_weight, is_public, forked_from_id) AS (
+		SELECT id, recipe_name, creator_id, created_at, last_edited_at, notes, COALESCE(parent_recipe_id, 0) AS parent_recipe_id, is_latest, COALESCE(servings, 0) AS servings, COALESCE(cooked_weight, 0) AS cooked_weight, is_public, COALESCE(forked_from_id, 0) AS forked_from_id
 		FROM recipes
 		WHERE id = $1
 		UNION
-		SELECT R.id, R.recipe_name, R.creator_id, R.created_at, R.last_edited_at, R.notes, COALESCE(R.parent_recipe_id, 0) AS parent_recipe_id, R.is_latest
+		SELECT R.id, R.recipe_name, R.creator_id, R.created_at, R.last_edited_at, R.notes, COALESCE(R.parent_recipe_id, 0) AS parent_recipe_id, R.is_latest, COALESCE(R.servings, 0) AS servings, COALESCE(R.cooked_weight, 0) AS cooked_weight, R.is_public, COALESCE(R.forked_from_id, 0) AS forked_from_id
 		FROM recipes R INNER JOIN ancestors A ON R.id = A.parent_recipe_id
 	), counted_ancestors AS (
-		SELECT COUNT(*) OVER() as total_count, id, recipe_name, creator_id, created_at, last_edited_at, notes, parent_recipe_id, is_latest
+		SELECT COUNT(*) OVER() as total_count, id, recipe_name, creator_id, created_at, last_edited_at, notes, parent_recipe_id, is_latest, servings, cooked_weight, is_public, forked_from_id
 		FROM ancestors
 	)
-	SELECT total_count, id, recipe_name, creator_id, created_at, last_edited_at, notes, parent_recipe_id, is_latest
+	SELECT total_count, id, recipe_name, creator_id, created_at, last_edited_at, notes, parent_recipe_id, is_latest, servings, cooked_weight, is_public, forked_from_id
 	FROM counted_ancestors
 	ORDER BY %s %s, id ASC
 	LIMIT $2
@@ -674,6 +1173,10 @@ func (m RecipeModel) GetAllAncestors(childRecipe *Recipe, filters RecipeFilters)
 			&ancestor.Notes,
 			&ancestor.ParentRecipeID,
 			&ancestor.IsLatest,
+			&ancestor.Servings,
+			&ancestor.CookedWeight,
+			&ancestor.IsPublic,
+			&ancestor.ForkedFromID,
 		)
 		if err != nil {
 			return nil, Metadata{}, err
@@ -691,3 +1194,513 @@ func (m RecipeModel) GetAllAncestors(childRecipe *Recipe, filters RecipeFilters)
 
 	return ancestors, calculateMetadata(recordCount, filters.Metadata.Page, filters.Metadata.PageSize), nil
 }
+
+// SameLineage reports whether two of the user's recipes are versions of the same recipe, sharing the
+// first version found by walking parent_recipe_id
+func (m RecipeModel) SameLineage(ID int64, otherID int64, userID int64) (bool, error) {
+	stmt := `
+	WITH RECURSIVE ` + recipeLineages + `
+	SELECT COUNT(DISTINCT root_id) = 1 AND COUNT(*) = CASE WHEN $2::INTEGER = $3::INTEGER THEN 1 ELSE 2 END
+	FROM lineages
+	WHERE id = $2 OR id = $3
+	`
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	var same bool
+	err := m.DB.QueryRow(ctx, stmt, userID, ID, otherID).Scan(&same)
+	if err != nil {
+		return false, err
+	}
+
+	return same, nil
+}
+
+// GetVersionTree finds the first version of the recipe by walking up its parents, then returns every version
+// descended from it. Only versions created by userID are followed
+func (m RecipeModel) GetVersionTree(ID int64, userID int64) (*RecipeTree, error) {
+	stmt := `
+	WITH RECURSIVE ancestors(id, parent_recipe_id) AS (
+		SELECT id, parent_recipe_id
+		FROM recipes
+		WHERE id = $1 AND creator_id = $2
+		UNION
+		SELECT R.id, R.parent_recipe_id
+		FROM recipes R INNER JOIN ancestors A ON R.id = A.parent_recipe_id
+		WHERE R.creator_id = $2
+	), descendants(id) AS (
+		SELECT id
+		FROM ancestors
+		WHERE parent_recipe_id IS NULL OR parent_recipe_id NOT IN (SELECT id FROM ancestors)
+		UNION
+		SELECT R.id
+		FROM recipes R INNER JOIN descendants D ON R.parent_recipe_id = D.id
+		WHERE R.creator_id = $2
+	)
+	SELECT id, recipe_name, creator_id, created_at, last_edited_at, notes, COALESCE(parent_recipe_id, 0), is_latest, COALESCE(servings, 0), COALESCE(cooked_weight, 0), is_public, COALESCE(forked_from_id, 0)
+	FROM recipes
+	WHERE id IN (SELECT id FROM descendants)
+	ORDER BY created_at ASC, id ASC
+	`
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	rows, err := m.DB.Query(ctx, stmt, ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recipes []*Recipe
+
+	for rows.Next() {
+		var recipe Recipe
+
+		err = rows.Scan(
+			&recipe.ID,
+			&recipe.Name,
+			&recipe.CreatorID,
+			&recipe.CreatedAt,
+			&recipe.LastEditedAt,
+			&recipe.Notes,
+			&recipe.ParentRecipeID,
+			&recipe.IsLatest,
+			&recipe.Servings,
+			&recipe.CookedWeight,
+			&recipe.IsPublic,
+			&recipe.ForkedFromID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		recipes = append(recipes, &recipe)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return BuildRecipeTree(recipes)
+}
+
+// usesPrivateSubRecipe is a recursive query naming in private_uses(recipe_id) each recipe using a sub-recipe,
+// directly or through other sub-recipes, that is not public
+const usesPrivateSubRecipe = `uses(recipe_id, sub_recipe_id) AS (
+		SELECT recipe_id, sub_recipe_id
+		FROM recipe_components
+		WHERE sub_recipe_id IS NOT NULL
+		UNION
+		SELECT U.recipe_id, RC.sub_recipe_id
+		FROM uses U INNER JOIN recipe_components RC ON RC.recipe_id = U.sub_recipe_id
+		WHERE RC.sub_recipe_id IS NOT NULL
+	), private_uses(recipe_id) AS (
+		SELECT DISTINCT U.recipe_id
+		FROM uses U INNER JOIN recipes S ON S.id = U.sub_recipe_id
+		WHERE S.is_public = FALSE
+	)`
+
+// GetPublic returns the latest version of every public recipe, leaving out recipes using a sub-recipe
+// that is not public
+func (m RecipeModel) GetPublic(filters RecipeFilters) ([]*Recipe, Metadata, error) {
+	stmt := fmt.Sprintf(`
+	WITH RECURSIVE %s
+	SELECT COUNT(*) OVER(), id, recipe_name, creator_id, created_at, last_edited_at, notes, COALESCE(parent_recipe_id, 0), is_latest, COALESCE(servings, 0), COALESCE(cooked_weight, 0), is_public, COALESCE(forked_from_id, 0)
+	FROM recipes
+	WHERE is_public = TRUE AND is_latest = TRUE
+	  AND id NOT IN (SELECT recipe_id FROM private_uses)
+	  AND ($1 = '' or recipe_name ILIKE $1)
+	ORDER BY %s %s, id ASC
+	LIMIT $2
+	OFFSET $3
+	`, usesPrivateSubRecipe, filters.Metadata.sortColumn(), filters.Metadata.sortDirection())
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	rows, err := m.DB.Query(ctx, stmt, filters.getSearchVariable(), filters.Metadata.pageLimit(), filters.Metadata.pageOffset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	var recordCount int = 0
+	recipes := []*Recipe{}
+
+	for rows.Next() {
+		var recipe Recipe
+		err = rows.Scan(
+			&recordCount,
+			&recipe.ID,
+			&recipe.Name,
+			&recipe.CreatorID,
+			&recipe.CreatedAt,
+			&recipe.LastEditedAt,
+			&recipe.Notes,
+			&recipe.ParentRecipeID,
+			&recipe.IsLatest,
+			&recipe.Servings,
+			&recipe.CookedWeight,
+			&recipe.IsPublic,
+			&recipe.ForkedFromID,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		recipes = append(recipes, &recipe)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return recipes, calculateMetadata(recordCount, filters.Metadata.Page, filters.Metadata.PageSize), nil
+}
+
+// GetPublicFullRecipe reads a public recipe of any user, along with the sub-recipes it uses
+func (m RecipeModel) GetPublicFullRecipe(ID int64) (*FullRecipe, error) {
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	txn, err := m.DB.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.TxIsoLevel(pgx.ReadCommitted), AccessMode: pgx.ReadOnly, DeferrableMode: pgx.NotDeferrable})
+	if err != nil {
+		return nil, err
+	}
+	defer txn.Rollback(ctx)
+
+	fullRecipe, err := getPublicFullRecipe(ctx, txn, ID)
+	if err != nil {
+		return nil, err
+	}
+	txn.Commit(ctx)
+
+	return fullRecipe, nil
+}
+
+// getPublicFullRecipe reads a public recipe as its creator would. A recipe using a sub-recipe that is not
+// public is not found, as its sub-recipes would be shared with it
+func getPublicFullRecipe(ctx context.Context, db psqlDB, ID int64) (*FullRecipe, error) {
+	stmt := `
+	WITH RECURSIVE ` + usesPrivateSubRecipe + `
+	SELECT creator_id
+	FROM recipes
+	WHERE id = $1 AND is_public = TRUE
+	  AND id NOT IN (SELECT recipe_id FROM private_uses)
+	`
+
+	var creatorID int64
+	err := db.QueryRow(ctx, stmt, ID).Scan(&creatorID)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return getFullRecipe(ctx, db, ID, creatorID, map[int64]bool{})
+}
+
+// SetVisibility makes every version of one of the user's recipes public or private. A recipe can only be
+// made public when the sub-recipes its latest version uses are public, ErrSubRecipeNotPublic otherwise
+func (m RecipeModel) SetVisibility(ID int64, userID int64, isPublic bool) error {
+	checkStmt := `
+	WITH RECURSIVE ` + recipeLineages + `, ` + usesPrivateSubRecipe + `
+	SELECT EXISTS (
+		SELECT 1
+		FROM lineages L INNER JOIN lineages V ON V.root_id = L.root_id
+		     INNER JOIN recipes R ON R.id = V.id
+		     INNER JOIN private_uses P ON P.recipe_id = R.id
+		WHERE L.id = $2 AND R.is_latest = TRUE
+	)
+	`
+
+	stmt := `
+	WITH RECURSIVE ` + recipeLineages + `
+	UPDATE recipes
+	SET is_public = $3
+	WHERE creator_id = $1 AND id IN (
+		SELECT V.id
+		FROM lineages L INNER JOIN lineages V ON V.root_id = L.root_id
+		WHERE L.id = $2
+	)
+	`
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	if isPublic {
+		var usesPrivate bool
+		err := m.DB.QueryRow(ctx, checkStmt, userID, ID).Scan(&usesPrivate)
+		if err != nil {
+			return err
+		}
+		if usesPrivate {
+			return ErrSubRecipeNotPublic
+		}
+	}
+
+	result, err := m.DB.Exec(ctx, stmt, userID, ID, isPublic)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// ForkFullRecipe copies a public recipe into the user's account as a new private recipe. The user's
+// pantry items are used for the steps, a pantry item is created for each consumable the user has none for.
+// Returns ErrRecordNotFound when a step uses a consumable the user cannot see
+func (m RecipeModel) ForkFullRecipe(ID int64, userID int64) (*FullRecipe, error) {
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	txn, err := m.DB.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted, AccessMode: pgx.ReadWrite, DeferrableMode: pgx.NotDeferrable})
+	if err != nil {
+		return nil, err
+	}
+	defer txn.Rollback(ctx)
+
+	source, err := getPublicFullRecipe(ctx, txn, ID)
+	if err != nil {
+		return nil, err
+	}
+
+	forkID, err := forkFullRecipe(source, userID, map[int64]int64{}, txn)
+	if err != nil {
+		return nil, err
+	}
+
+	err = txn.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.GetFullRecipe(forkID, userID)
+}
+
+// forkFullRecipe copies the recipe, after the sub-recipes it uses, into the user's account. forked maps the
+// recipes already copied to their copies so a sub-recipe used more than once is copied once
+func forkFullRecipe(source *FullRecipe, userID int64, forked map[int64]int64, db psqlDB) (int64, error) {
+	if forkID, ok := forked[source.Recipe.ID]; ok {
+		return forkID, nil
+	}
+
+	fork := &FullRecipe{
+		Recipe: Recipe{
+			Name:         source.Recipe.Name,
+			CreatorID:    userID,
+			Notes:        source.Recipe.Notes,
+			IsLatest:     true,
+			Servings:     source.Recipe.Servings,
+			CookedWeight: source.Recipe.CookedWeight,
+			ForkedFromID: source.Recipe.ID,
+		},
+		ServingSizes: source.ServingSizes,
+		SubRecipes:   []*FullRecipe{},
+	}
+
+	for i, component := range source.RecipeComponents {
+		step := *component
+		step.ID = 0
+		step.RecipeID = 0
+		pantryItem := &PantryItem{}
+
+		if component.SubRecipeID != 0 {
+			subRecipe := source.SubRecipe(component.SubRecipeID)
+			if subRecipe == nil {
+				return 0, ErrSubRecipeDoesNotExist
+			}
+			subRecipeID, err := forkFullRecipe(subRecipe, userID, forked, db)
+			if err != nil {
+				return 0, err
+			}
+			step.SubRecipeID = subRecipeID
+		} else {
+			// the user's pantry items only point at consumables they can see
+			if !source.Consumables[i].VisibleTo(userID) {
+				return 0, ErrRecordNotFound
+			}
+			var err error
+			pantryItem, err = getOrCreateForConsumable(userID, source.PantryItems[i].ConsumableId, source.PantryItems[i].Name, db)
+			if err != nil {
+				return 0, err
+			}
+			step.PantryItemID = pantryItem.ID
+		}
+
+		fork.RecipeComponents = append(fork.RecipeComponents, &step)
+		fork.PantryItems = append(fork.PantryItems, pantryItem)
+		fork.Consumables = append(fork.Consumables, source.Consumables[i])
+	}
+
+	err := insertFullRecipe(fork, db)
+	if err != nil {
+		return 0, err
+	}
+	forked[source.Recipe.ID] = fork.Recipe.ID
+
+	return fork.Recipe.ID, nil
+}
+
+// gramsPerSQL is conversion's mass of one of unit in SQL for a food with the given density, unit weight
+// and serving weight, it is NULL when the unit cannot be converted
+func gramsPerSQL(unit string, density string, unitWeight string, servingWeight string) string {
+	ounce, _ := conversion.Convert(1, conversion.Ounces, conversion.Grams, conversion.Profile{})
+	pound, _ := conversion.Convert(1, conversion.Pounds, conversion.Grams, conversion.Profile{})
+
+	return fmt.Sprintf(`(CASE %s WHEN 'g' THEN 1 WHEN 'oz' THEN %g WHEN 'lb' THEN %g WHEN 'ml' THEN NULLIF(%s, 0) WHEN 'units' THEN NULLIF(%s, 0) WHEN 'servings' THEN NULLIF(%s, 0) END)`,
+		unit, ounce, pound, density, unitWeight, servingWeight)
+}
+
+// servingNutritionSQL is a recursive query naming in serving_nutrition(recipe_id, serving_proteins, serving_kj)
+// the protein and energy per serving of each recipe of the user in $1, calculated as FullRecipe.Nutrition
+// does. Steps of sub-recipes are expanded with the fraction of the sub-recipe used, and both values are
+// NULL when a step's amount cannot be converted
+var servingNutritionSQL = fmt.Sprintf(`expanded(recipe_id, sub_recipe_id, pantry_item_id, serving_id, quantity, units, multiplier, sub_fraction, depth) AS (
+		SELECT RC.recipe_id, RC.sub_recipe_id, RC.pantry_item_id, RC.serving_id, RC.quantity, RC.units, 1::DOUBLE PRECISION, %[1]s, 0
+		FROM recipe_components RC
+		     INNER JOIN recipes R ON RC.recipe_id = R.id
+		     LEFT JOIN recipes S ON RC.sub_recipe_id = S.id
+		WHERE R.creator_id = $1
+		UNION ALL
+		SELECT E.recipe_id, RC.sub_recipe_id, RC.pantry_item_id, RC.serving_id, RC.quantity, RC.units, E.multiplier * E.sub_fraction, %[1]s, E.depth + 1
+		FROM expanded E
+		     INNER JOIN recipe_components RC ON RC.recipe_id = E.sub_recipe_id
+		     LEFT JOIN recipes S ON RC.sub_recipe_id = S.id
+		WHERE E.depth < 32
+	), amounts(recipe_id, multiplier, amount, units, consumable_units, size, density, unit_weight, serving_weight, carbs, fats, proteins, alcohol) AS (
+		SELECT E.recipe_id, E.multiplier,
+		       CASE WHEN E.serving_id IS NULL THEN E.quantity ELSE E.quantity * SS.amount END,
+		       CASE WHEN E.serving_id IS NULL THEN COALESCE(E.units, C.units) ELSE SS.units END,
+		       C.units, C.size, C.density, C.unit_weight, C.serving_weight, C.carbs, C.fats, C.proteins, C.alcohol
+		FROM expanded E
+		     INNER JOIN pantry_items P ON E.pantry_item_id = P.id
+		     INNER JOIN consumables C ON P.consumable_id = C.id
+		     LEFT JOIN serving_sizes SS ON E.serving_id = SS.id AND SS.consumable_id = C.id
+	), steps(recipe_id, factor, carbs, fats, proteins, alcohol) AS (
+		SELECT recipe_id, multiplier * sub_fraction, 0, 0, 0, 0
+		FROM expanded
+		WHERE sub_recipe_id IS NOT NULL
+		UNION ALL
+		SELECT recipe_id, multiplier * (CASE WHEN units = consumable_units THEN amount ELSE amount * %[2]s / %[3]s END) / size, carbs, fats, proteins, alcohol
+		FROM amounts
+	), totals(recipe_id, known, carbs, fats, proteins, alcohol) AS (
+		SELECT recipe_id, bool_and(factor IS NOT NULL), SUM(factor * carbs), SUM(factor * fats), SUM(factor * proteins), SUM(factor * alcohol)
+		FROM steps
+		GROUP BY recipe_id
+	), serving_nutrition(recipe_id, serving_proteins, serving_kj) AS (
+		SELECT R.id,
+		       CASE WHEN T.known IS NOT FALSE THEN COALESCE(T.proteins, 0) / GREATEST(COALESCE(R.servings, 0), 1) END,
+		       CASE WHEN T.known IS NOT FALSE THEN COALESCE(%[4]s, 0) / GREATEST(COALESCE(R.servings, 0), 1) END
+		FROM recipes R LEFT JOIN totals T ON T.recipe_id = R.id
+		WHERE R.creator_id = $1
+	)`,
+	`(CASE WHEN RC.sub_recipe_id IS NULL THEN NULL
+		       WHEN RC.units IS NULL THEN RC.quantity
+		       WHEN RC.units = 'servings' THEN RC.quantity / NULLIF(S.servings, 0)
+		       ELSE RC.quantity * `+gramsPerSQL("RC.units", "0", "0", "0")+` / NULLIF(S.cooked_weight, 0) END)::DOUBLE PRECISION`,
+	gramsPerSQL("units", "density", "unit_weight", "serving_weight"),
+	gramsPerSQL("consumable_units", "density", "unit_weight", "serving_weight"),
+	strings.NewReplacer("carbs", "T.carbs", "fats", "T.fats", "proteins", "T.proteins", "alcohol", "T.alcohol").Replace(energySQL),
+)
+
+// Search returns the user's recipes matching the filters. Nutrition per serving is calculated in the query
+// so nutrition ranges and sorting by energy are paged by the database. Recipes whose nutrition cannot be
+// calculated never match a nutrition range and sort last
+func (m RecipeModel) Search(userID int64, filters RecipeFilters) ([]*Recipe, Metadata, error) {
+	stmt := fmt.Sprintf(`
+	WITH RECURSIVE contains(recipe_id, pantry_item_id, consumable_id) AS (
+		SELECT RC.recipe_id, RC.pantry_item_id, P.consumable_id
+		FROM recipe_components RC
+		     INNER JOIN pantry_items P ON RC.pantry_item_id = P.id
+		     INNER JOIN recipes R ON RC.recipe_id = R.id
+		WHERE R.creator_id = $1
+		UNION
+		SELECT RC.recipe_id, C.pantry_item_id, C.consumable_id
+		FROM recipe_components RC INNER JOIN contains C ON RC.sub_recipe_id = C.recipe_id
+	), %s, %s
+	SELECT COUNT(*) OVER(), id, recipe_name, creator_id, created_at, last_edited_at, notes, COALESCE(parent_recipe_id, 0), is_latest, COALESCE(servings, 0), COALESCE(cooked_weight, 0), is_public, COALESCE(forked_from_id, 0)
+	FROM recipes R LEFT JOIN serving_nutrition N ON N.recipe_id = R.id
+	WHERE creator_id = $1
+	  AND ($2 = FALSE OR is_latest = TRUE)
+	  AND ($3 = '' OR recipe_name ILIKE $3)
+	  AND ($4 = 0 OR EXISTS (SELECT 1 FROM contains C WHERE C.recipe_id = R.id AND C.consumable_id = $4))
+	  AND ($5 = 0 OR EXISTS (SELECT 1 FROM contains C WHERE C.recipe_id = R.id AND C.pantry_item_id = $5))
+	  AND (cardinality($8::TEXT[]) = 0 OR cardinality($8::TEXT[]) = (
+	      SELECT COUNT(*)
+	      FROM lineages L
+	           INNER JOIN recipe_tags RT ON RT.recipe_id = L.root_id
+	           INNER JOIN tags T ON RT.tag_id = T.id
+	      WHERE L.id = R.id AND T.user_id = $1 AND T.name = ANY($8)))
+	  AND ($9 = FALSE OR (
+	      N.serving_proteins >= $10::DOUBLE PRECISION AND ($11::DOUBLE PRECISION = 0 OR N.serving_proteins <= $11::DOUBLE PRECISION) AND
+	      N.serving_kj >= $12::DOUBLE PRECISION AND ($13::DOUBLE PRECISION = 0 OR N.serving_kj <= $13::DOUBLE PRECISION)))
+	ORDER BY %s %s NULLS LAST, id ASC
+	LIMIT $6
+	OFFSET $7
+	`, recipeLineages, servingNutritionSQL, filters.sortColumn(), filters.Metadata.sortDirection())
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	args := []any{
+		userID,
+		filters.LatestOnly,
+		filters.getSearchVariable(),
+		filters.ConsumableID,
+		filters.PantryItemID,
+		filters.Metadata.pageLimit(),
+		filters.Metadata.pageOffset(),
+		filters.tagNames(),
+		filters.filtersNutrition(),
+		filters.MinProtein,
+		filters.MaxProtein,
+		filters.MinEnergy,
+		filters.MaxEnergy,
+	}
+
+	rows, err := m.DB.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	var recordCount int = 0
+	recipes := []*Recipe{}
+
+	for rows.Next() {
+		var recipe Recipe
+		err = rows.Scan(
+			&recordCount,
+			&recipe.ID,
+			&recipe.Name,
+			&recipe.CreatorID,
+			&recipe.CreatedAt,
+			&recipe.LastEditedAt,
+			&recipe.Notes,
+			&recipe.ParentRecipeID,
+			&recipe.IsLatest,
+			&recipe.Servings,
+			&recipe.CookedWeight,
+			&recipe.IsPublic,
+			&recipe.ForkedFromID,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		recipes = append(recipes, &recipe)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return recipes, calculateMetadata(recordCount, filters.Metadata.Page, filters.Metadata.PageSize), nil
+}
@@ -883,6 +883,398 @@ func TestFullRecipeHelpers(t *testing.T) {
 	}
 }
 
+func TestFullRecipeTotalMacros(t *testing.T) {
+
+	tests := []struct {
+		name         string
+		fullRecipe   FullRecipe
+		expectMacros Macronutrients
+		expectError  error
+	}{
+		{
+			name: "single step",
+			fullRecipe: FullRecipe{
+				RecipeComponents: []*RecipeComponent{
+					{Quantity: 50, StepNo: 1},
+				},
+				Consumables: []*Consumable{
+					{Size: 100, Units: "g", Macros: Macronutrients{Carbs: 10, Fats: 4, Proteins: 2, Alcohol: 0}},
+				},
+			},
+			expectMacros: Macronutrients{Carbs: 5, Fats: 2, Proteins: 1, Alcohol: 0},
+		},
+		{
+			name: "multiple steps",
+			fullRecipe: FullRecipe{
+				RecipeComponents: []*RecipeComponent{
+					{Quantity: 200, StepNo: 1},
+					{Quantity: 10, StepNo: 2},
+				},
+				Consumables: []*Consumable{
+					{Size: 100, Units: "g", Macros: Macronutrients{Carbs: 10, Fats: 4, Proteins: 2, Alcohol: 0}},
+					{Size: 5, Units: "ml", Macros: Macronutrients{Carbs: 0, Fats: 0, Proteins: 0, Alcohol: 1}},
+				},
+			},
+			expectMacros: Macronutrients{Carbs: 20, Fats: 8, Proteins: 4, Alcohol: 2},
+		},
+		{
+			name: "step in other units",
+			fullRecipe: FullRecipe{
+				RecipeComponents: []*RecipeComponent{
+					{Quantity: 2, Units: "units", StepNo: 1},
+				},
+				Consumables: []*Consumable{
+					{Size: 100, Units: "g", UnitWeight: 150, Macros: Macronutrients{Carbs: 10, Fats: 4, Proteins: 2, Alcohol: 0}},
+				},
+			},
+			expectMacros: Macronutrients{Carbs: 30, Fats: 12, Proteins: 6, Alcohol: 0},
+		},
+		{
+			name: "step in servings",
+			fullRecipe: FullRecipe{
+				RecipeComponents: []*RecipeComponent{
+					{Quantity: 2, ServingID: 1, StepNo: 1},
+				},
+				Consumables: []*Consumable{
+					{ID: 4, Size: 100, Units: "g", Macros: Macronutrients{Carbs: 40, Fats: 2, Proteins: 8, Alcohol: 0}},
+				},
+				ServingSizes: []*ServingSize{
+					{ID: 1, ConsumableID: 4, Name: "1 slice", Amount: 40, Units: "g"},
+				},
+			},
+			expectMacros: Macronutrients{Carbs: 32, Fats: 1.6, Proteins: 6.4, Alcohol: 0},
+		},
+		{
+			name: "step serving of other consumable",
+			fullRecipe: FullRecipe{
+				RecipeComponents: []*RecipeComponent{
+					{Quantity: 2, ServingID: 1, StepNo: 1},
+				},
+				Consumables: []*Consumable{
+					{ID: 5, Size: 100, Units: "g", Macros: Macronutrients{Carbs: 40, Fats: 2, Proteins: 8, Alcohol: 0}},
+				},
+				ServingSizes: []*ServingSize{
+					{ID: 1, ConsumableID: 4, Name: "1 slice", Amount: 40, Units: "g"},
+				},
+			},
+			expectError: ErrServingSizeDoesNotExist,
+		},
+		{
+			name: "step units not convertible",
+			fullRecipe: FullRecipe{
+				RecipeComponents: []*RecipeComponent{
+					{Quantity: 100, Units: "ml", StepNo: 1},
+				},
+				Consumables: []*Consumable{
+					{Size: 100, Units: "g", Macros: Macronutrients{Carbs: 10, Fats: 4, Proteins: 2, Alcohol: 0}},
+				},
+			},
+			expectError: ErrIncompatibleUnits,
+		},
+		{
+			name:         "no steps",
+			fullRecipe:   FullRecipe{},
+			expectMacros: Macronutrients{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			macros, err := tt.fullRecipe.TotalMacros()
+
+			assert.ExpectError(t, err, tt.expectError)
+			if err != nil {
+				return
+			}
+
+			assert.Equal(t, macros, tt.expectMacros)
+		})
+	}
+}
+
+func TestFullRecipeNutrition(t *testing.T) {
+
+	var flour, milk NutrientPanel
+	flour.Set("fibre", 3)
+	flour.Set("iron", 1.5)
+	milk.Set("calcium", 120)
+
+	var flourStep, milkStep, total, perServing NutrientPanel
+	flourStep.Set("fibre", 6)
+	flourStep.Set("iron", 3)
+	milkStep.Set("calcium", 300)
+	total.Set("fibre", 6)
+	total.Set("iron", 3)
+	total.Set("calcium", 300)
+	perServing.Set("fibre", 1.5)
+	perServing.Set("iron", 0.75)
+	perServing.Set("calcium", 75)
+
+	fullRecipe := FullRecipe{
+		Recipe: Recipe{Servings: 4},
+		RecipeComponents: []*RecipeComponent{
+			{Quantity: 200, StepNo: 1},
+			{Quantity: 1, ServingID: 1, StepNo: 2},
+		},
+		Consumables: []*Consumable{
+			{ID: 1, Size: 100, Units: "g", Macros: Macronutrients{Carbs: 70, Fats: 1, Proteins: 10, Alcohol: 0}, Nutrients: flour},
+			{ID: 2, Size: 100, Units: "ml", Macros: Macronutrients{Carbs: 4, Fats: 2, Proteins: 4, Alcohol: 0}, Nutrients: milk},
+		},
+		ServingSizes: []*ServingSize{
+			{ID: 1, ConsumableID: 2, Name: "1 cup", Amount: 250, Units: "ml"},
+		},
+	}
+
+	nutrition, err := fullRecipe.Nutrition()
+	assert.NilError(t, err)
+
+	assert.Equal(t, len(nutrition.Steps), 2)
+	assert.Equal(t, nutrition.Steps[0].Macros, Macronutrients{Carbs: 140, Fats: 2, Proteins: 20, Alcohol: 0})
+	assert.Equal(t, nutrition.Steps[0].Nutrients, flourStep)
+	assert.Equal(t, nutrition.Steps[1].Macros, Macronutrients{Carbs: 10, Fats: 5, Proteins: 10, Alcohol: 0})
+	assert.Equal(t, nutrition.Steps[1].Nutrients, milkStep)
+
+	assert.Equal(t, nutrition.Total.Macros, Macronutrients{Carbs: 150, Fats: 7, Proteins: 30, Alcohol: 0})
+	assert.Equal(t, nutrition.Total.Nutrients, total)
+	assert.Equal(t, nutrition.Total.KJ, nutrition.Total.Macros.CalculateKJ())
+
+	assert.Equal(t, nutrition.PerServing.Macros, Macronutrients{Carbs: 37.5, Fats: 1.75, Proteins: 7.5, Alcohol: 0})
+	assert.Equal(t, nutrition.PerServing.Nutrients, perServing)
+
+	fullRecipe.Recipe.Servings = 0
+	nutrition, err = fullRecipe.Nutrition()
+	assert.NilError(t, err)
+	assert.Equal(t, nutrition.PerServing, nutrition.Total)
+}
+
+func TestFullRecipeNutritionSubRecipes(t *testing.T) {
+
+	dough := &FullRecipe{
+		Recipe: Recipe{ID: 2, Name: "pizza dough", Servings: 4},
+		RecipeComponents: []*RecipeComponent{
+			{RecipeID: 2, PantryItemID: 1, Quantity: 400, StepNo: 1},
+		},
+		PantryItems: []*PantryItem{{ID: 1, Name: "flour"}},
+		Consumables: []*Consumable{
+			{ID: 1, Size: 100, Units: "g", Macros: Macronutrients{Carbs: 70, Fats: 1, Proteins: 10, Alcohol: 0}},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		component    RecipeComponent
+		subRecipes   []*FullRecipe
+		expectMacros Macronutrients
+		expectError  error
+	}{
+		{
+			name:         "fraction of sub-recipe",
+			component:    RecipeComponent{RecipeID: 1, SubRecipeID: 2, Quantity: 0.5, StepNo: 2},
+			subRecipes:   []*FullRecipe{dough},
+			expectMacros: Macronutrients{Carbs: 150, Fats: 4, Proteins: 30, Alcohol: 0},
+		},
+		{
+			name:         "servings of sub-recipe",
+			component:    RecipeComponent{RecipeID: 1, SubRecipeID: 2, Quantity: 1, Units: "servings", StepNo: 2},
+			subRecipes:   []*FullRecipe{dough},
+			expectMacros: Macronutrients{Carbs: 80, Fats: 3, Proteins: 20, Alcohol: 0},
+		},
+		{
+			name:        "sub-recipe not loaded",
+			component:   RecipeComponent{RecipeID: 1, SubRecipeID: 2, Quantity: 1, StepNo: 2},
+			expectError: ErrSubRecipeDoesNotExist,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			component := tt.component
+			pizza := FullRecipe{
+				Recipe: Recipe{ID: 1, Name: "margherita pizza"},
+				RecipeComponents: []*RecipeComponent{
+					{RecipeID: 1, PantryItemID: 2, Quantity: 100, StepNo: 1},
+					&component,
+				},
+				PantryItems: []*PantryItem{{ID: 2, Name: "mozzarella"}, {}},
+				Consumables: []*Consumable{
+					{ID: 2, Size: 100, Units: "g", Macros: Macronutrients{Carbs: 10, Fats: 2, Proteins: 10, Alcohol: 0}},
+					{},
+				},
+				SubRecipes: tt.subRecipes,
+			}
+
+			nutrition, err := pizza.Nutrition()
+
+			assert.ExpectError(t, err, tt.expectError)
+			if err != nil {
+				return
+			}
+
+			assert.Equal(t, nutrition.Total.Macros, tt.expectMacros)
+		})
+	}
+}
+
+func TestFullRecipeFractionForWeight(t *testing.T) {
+
+	tests := []struct {
+		name           string
+		cookedWeight   float64
+		amount         float64
+		units          MeasurementUnit
+		expectFraction float64
+		expectError    error
+	}{
+		{
+			name:           "grams of cooked dish",
+			cookedWeight:   800,
+			amount:         200,
+			units:          "g",
+			expectFraction: 0.25,
+		},
+		{
+			name:         "no cooked weight",
+			cookedWeight: 0,
+			amount:       200,
+			units:        "g",
+			expectError:  ErrIncompatibleUnits,
+		},
+		{
+			name:         "volume of cooked dish",
+			cookedWeight: 800,
+			amount:       200,
+			units:        "ml",
+			expectError:  ErrIncompatibleUnits,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fullRecipe := FullRecipe{Recipe: Recipe{CookedWeight: tt.cookedWeight}}
+
+			fraction, err := fullRecipe.FractionForWeight(tt.amount, tt.units)
+
+			assert.ExpectError(t, err, tt.expectError)
+			if err != nil {
+				return
+			}
+
+			assert.Equal(t, fraction, tt.expectFraction)
+		})
+	}
+}
+
+func TestFullRecipeScaled(t *testing.T) {
+
+	tests := []struct {
+		name             string
+		servings         int64
+		factor           float64
+		expectServings   int64
+		expectQuantities []float64
+		expectError      error
+	}{
+		{
+			name:             "scale up",
+			servings:         4,
+			factor:           1.5,
+			expectServings:   6,
+			expectQuantities: []float64{300, 1.5},
+		},
+		{
+			name:             "scale down",
+			servings:         4,
+			factor:           0.5,
+			expectServings:   2,
+			expectQuantities: []float64{100, 0.5},
+		},
+		{
+			name:        "fractional servings",
+			servings:    4,
+			factor:      0.3,
+			expectError: ErrFractionalServings,
+		},
+		{
+			name:        "less than one serving",
+			servings:    1,
+			factor:      0.5,
+			expectError: ErrFractionalServings,
+		},
+		{
+			name:             "scale without servings",
+			servings:         0,
+			factor:           2,
+			expectServings:   0,
+			expectQuantities: []float64{400, 2},
+		},
+		{
+			name:             "scale without servings by a fraction",
+			servings:         0,
+			factor:           0.3,
+			expectServings:   0,
+			expectQuantities: []float64{60, 0.3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fullRecipe := FullRecipe{
+				Recipe: Recipe{ID: 1, Servings: tt.servings},
+				RecipeComponents: []*RecipeComponent{
+					{RecipeID: 1, Quantity: 200, StepNo: 1},
+					{RecipeID: 1, Quantity: 1, ServingID: 1, StepNo: 2},
+				},
+			}
+
+			scaled, err := fullRecipe.Scaled(tt.factor)
+			assert.ExpectError(t, err, tt.expectError)
+			if err != nil {
+				return
+			}
+
+			assert.Equal(t, scaled.Recipe.Servings, tt.expectServings)
+			for i, component := range scaled.RecipeComponents {
+				assert.Equal(t, component.Quantity, tt.expectQuantities[i])
+			}
+
+			// the original recipe is left unchanged
+			assert.Equal(t, fullRecipe.Recipe.Servings, tt.servings)
+			assert.Equal(t, fullRecipe.RecipeComponents[0].Quantity, 200.0)
+		})
+	}
+}
+
+func TestFullRecipeRevertedTo(t *testing.T) {
+
+	current := FullRecipe{
+		Recipe: Recipe{ID: 3, Name: "Lasagne", CreatorID: 1, Notes: "a bad edit", ParentRecipeID: 2, IsLatest: true, Servings: 6},
+		RecipeComponents: []*RecipeComponent{
+			{ID: 5, RecipeID: 3, PantryItemID: 2, Quantity: 500, StepNo: 1},
+		},
+	}
+	version := FullRecipe{
+		Recipe: Recipe{ID: 1, Name: "Lasagne", CreatorID: 1, Notes: "a recipe", Servings: 4},
+		RecipeComponents: []*RecipeComponent{
+			{ID: 1, RecipeID: 1, PantryItemID: 1, Quantity: 4, StepNo: 1},
+			{ID: 2, RecipeID: 1, PantryItemID: 2, Quantity: 5, StepNo: 2},
+		},
+		PantryItems: []*PantryItem{{ID: 1}, {ID: 2}},
+		Consumables: []*Consumable{{ID: 17}, {ID: 18}},
+	}
+
+	reverted := current.RevertedTo(&version)
+
+	assert.Equal(t, reverted.Recipe, Recipe{ID: 3, Name: "Lasagne", CreatorID: 1, Notes: "a recipe", ParentRecipeID: 3, IsLatest: true, Servings: 4})
+	assert.Equal(t, len(reverted.RecipeComponents), 2)
+	for i, component := range reverted.RecipeComponents {
+		assert.Equal(t, component.RecipeID, int64(3))
+		assert.Equal(t, component.PantryItemID, version.RecipeComponents[i].PantryItemID)
+		assert.Equal(t, component.Quantity, version.RecipeComponents[i].Quantity)
+	}
+	assert.Equal(t, version.RecipeComponents[0].RecipeID, int64(1))
+	assert.Equal(t, len(reverted.PantryItems), 2)
+}
+
 func TestRecipeModelGet(t *testing.T) {
 
 	if testing.Short() {
@@ -1772,13 +2164,14 @@ func TestRecipeModelGetFullRecipe(t *testing.T) {
 				},
 				Consumables: []*Consumable{
 					{
-						ID:        17,
-						CreatorID: 4,
-						CreatedAt: time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
-						Name:      "Lasagne Pasta Large",
-						BrandName: "San Remo",
-						Size:      62.5,
-						Units:     "g",
+						ID:         17,
+						CreatorID:  4,
+						CreatedAt:  time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
+						Name:       "Lasagne Pasta Large",
+						BrandName:  "San Remo",
+						Visibility: VisibilityShared,
+						Size:       62.5,
+						Units:      "g",
 						Macros: Macronutrients{
 							Carbs:    46.6,
 							Fats:     0.9,
@@ -1787,13 +2180,14 @@ func TestRecipeModelGetFullRecipe(t *testing.T) {
 						},
 					},
 					{
-						ID:        18,
-						CreatorID: 4,
-						CreatedAt: time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
-						Name:      "No Added Hormone Beef 5 Star Extra Trim Mince",
-						BrandName: "Coles",
-						Size:      100,
-						Units:     "g",
+						ID:         18,
+						CreatorID:  4,
+						CreatedAt:  time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
+						Name:       "No Added Hormone Beef 5 Star Extra Trim Mince",
+						BrandName:  "Coles",
+						Visibility: VisibilityShared,
+						Size:       100,
+						Units:      "g",
 						Macros: Macronutrients{
 							Carbs:    .5,
 							Fats:     2,
@@ -1996,12 +2390,13 @@ func TestRecipeModelInsertFullRecipe(t *testing.T) {
 				},
 				Consumables: []*Consumable{
 					{
-						CreatorID: 4,
-						CreatedAt: time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
-						Name:      "Lasagne Pasta Large",
-						BrandName: "San Remo",
-						Size:      62.5,
-						Units:     "g",
+						CreatorID:  4,
+						CreatedAt:  time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
+						Name:       "Lasagne Pasta Large",
+						BrandName:  "San Remo",
+						Visibility: VisibilityShared,
+						Size:       62.5,
+						Units:      "g",
 						Macros: Macronutrients{
 							Carbs:    46.6,
 							Fats:     0.9,
@@ -2010,12 +2405,13 @@ func TestRecipeModelInsertFullRecipe(t *testing.T) {
 						},
 					},
 					{
-						CreatorID: 4,
-						CreatedAt: time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
-						Name:      "No Added Hormone Beef 5 Star Extra Trim Mince",
-						BrandName: "Coles",
-						Size:      100,
-						Units:     "g",
+						CreatorID:  4,
+						CreatedAt:  time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
+						Name:       "No Added Hormone Beef 5 Star Extra Trim Mince",
+						BrandName:  "Coles",
+						Visibility: VisibilityShared,
+						Size:       100,
+						Units:      "g",
 						Macros: Macronutrients{
 							Carbs:    .5,
 							Fats:     2,
@@ -2075,12 +2471,13 @@ func TestRecipeModelInsertFullRecipe(t *testing.T) {
 				},
 				Consumables: []*Consumable{
 					{
-						CreatorID: 4,
-						CreatedAt: time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
-						Name:      "Lasagne Pasta Large",
-						BrandName: "San Remo",
-						Size:      62.5,
-						Units:     "g",
+						CreatorID:  4,
+						CreatedAt:  time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
+						Name:       "Lasagne Pasta Large",
+						BrandName:  "San Remo",
+						Visibility: VisibilityShared,
+						Size:       62.5,
+						Units:      "g",
 						Macros: Macronutrients{
 							Carbs:    46.6,
 							Fats:     0.9,
@@ -2089,12 +2486,13 @@ func TestRecipeModelInsertFullRecipe(t *testing.T) {
 						},
 					},
 					{
-						CreatorID: 4,
-						CreatedAt: time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
-						Name:      "No Added Hormone Beef 5 Star Extra Trim Mince",
-						BrandName: "Coles",
-						Size:      100,
-						Units:     "g",
+						CreatorID:  4,
+						CreatedAt:  time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
+						Name:       "No Added Hormone Beef 5 Star Extra Trim Mince",
+						BrandName:  "Coles",
+						Visibility: VisibilityShared,
+						Size:       100,
+						Units:      "g",
 						Macros: Macronutrients{
 							Carbs:    .5,
 							Fats:     2,
@@ -2154,12 +2552,13 @@ func TestRecipeModelInsertFullRecipe(t *testing.T) {
 				},
 				Consumables: []*Consumable{
 					{
-						CreatorID: 4,
-						CreatedAt: time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
-						Name:      "Lasagne Pasta Large",
-						BrandName: "San Remo",
-						Size:      62.5,
-						Units:     "g",
+						CreatorID:  4,
+						CreatedAt:  time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
+						Name:       "Lasagne Pasta Large",
+						BrandName:  "San Remo",
+						Visibility: VisibilityShared,
+						Size:       62.5,
+						Units:      "g",
 						Macros: Macronutrients{
 							Carbs:    46.6,
 							Fats:     0.9,
@@ -2168,12 +2567,13 @@ func TestRecipeModelInsertFullRecipe(t *testing.T) {
 						},
 					},
 					{
-						CreatorID: 4,
-						CreatedAt: time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
-						Name:      "No Added Hormone Beef 5 Star Extra Trim Mince",
-						BrandName: "Coles",
-						Size:      100,
-						Units:     "g",
+						CreatorID:  4,
+						CreatedAt:  time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
+						Name:       "No Added Hormone Beef 5 Star Extra Trim Mince",
+						BrandName:  "Coles",
+						Visibility: VisibilityShared,
+						Size:       100,
+						Units:      "g",
 						Macros: Macronutrients{
 							Carbs:    .5,
 							Fats:     2,
@@ -2309,7 +2709,7 @@ func TestRecipeModelUpdateFullRecipe(t *testing.T) {
 					CreatedAt:      time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
 					LastEditedAt:   time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
 					Notes:          "a recipe with modification",
-					ParentRecipeID: 0,
+					ParentRecipeID: 1,
 					IsLatest:       true,
 				},
 				RecipeComponents: []*RecipeComponent{
@@ -2348,12 +2748,13 @@ func TestRecipeModelUpdateFullRecipe(t *testing.T) {
 				},
 				Consumables: []*Consumable{
 					{
-						CreatorID: 4,
-						CreatedAt: time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
-						Name:      "Lasagne Pasta Large",
-						BrandName: "San Remo",
-						Size:      62.5,
-						Units:     "g",
+						CreatorID:  4,
+						CreatedAt:  time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
+						Name:       "Lasagne Pasta Large",
+						BrandName:  "San Remo",
+						Visibility: VisibilityShared,
+						Size:       62.5,
+						Units:      "g",
 						Macros: Macronutrients{
 							Carbs:    46.6,
 							Fats:     0.9,
@@ -2362,12 +2763,13 @@ func TestRecipeModelUpdateFullRecipe(t *testing.T) {
 						},
 					},
 					{
-						CreatorID: 4,
-						CreatedAt: time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
-						Name:      "No Added Hormone Beef 5 Star Extra Trim Mince",
-						BrandName: "Coles",
-						Size:      100,
-						Units:     "g",
+						CreatorID:  4,
+						CreatedAt:  time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
+						Name:       "No Added Hormone Beef 5 Star Extra Trim Mince",
+						BrandName:  "Coles",
+						Visibility: VisibilityShared,
+						Size:       100,
+						Units:      "g",
 						Macros: Macronutrients{
 							Carbs:    .5,
 							Fats:     2,
@@ -2380,7 +2782,7 @@ func TestRecipeModelUpdateFullRecipe(t *testing.T) {
 		},
 		{
 			name:        "update invalid fullrecipe bad recipe user",
-			expectError: ErrReferencedUserDoesNotExist,
+			expectError: ErrRecordNotFound,
 			newFullRecipe: FullRecipe{
 				Recipe: Recipe{
 					ID:             1,
@@ -2389,7 +2791,7 @@ func TestRecipeModelUpdateFullRecipe(t *testing.T) {
 					CreatedAt:      time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
 					LastEditedAt:   time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
 					Notes:          "a recipe",
-					ParentRecipeID: 0,
+					ParentRecipeID: 1,
 					IsLatest:       true,
 				},
 				RecipeComponents: []*RecipeComponent{
@@ -2428,12 +2830,13 @@ func TestRecipeModelUpdateFullRecipe(t *testing.T) {
 				},
 				Consumables: []*Consumable{
 					{
-						CreatorID: 4,
-						CreatedAt: time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
-						Name:      "Lasagne Pasta Large",
-						BrandName: "San Remo",
-						Size:      62.5,
-						Units:     "g",
+						CreatorID:  4,
+						CreatedAt:  time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
+						Name:       "Lasagne Pasta Large",
+						BrandName:  "San Remo",
+						Visibility: VisibilityShared,
+						Size:       62.5,
+						Units:      "g",
 						Macros: Macronutrients{
 							Carbs:    46.6,
 							Fats:     0.9,
@@ -2442,12 +2845,13 @@ func TestRecipeModelUpdateFullRecipe(t *testing.T) {
 						},
 					},
 					{
-						CreatorID: 4,
-						CreatedAt: time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
-						Name:      "No Added Hormone Beef 5 Star Extra Trim Mince",
-						BrandName: "Coles",
-						Size:      100,
-						Units:     "g",
+						CreatorID:  4,
+						CreatedAt:  time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
+						Name:       "No Added Hormone Beef 5 Star Extra Trim Mince",
+						BrandName:  "Coles",
+						Visibility: VisibilityShared,
+						Size:       100,
+						Units:      "g",
 						Macros: Macronutrients{
 							Carbs:    .5,
 							Fats:     2,
@@ -2469,7 +2873,7 @@ func TestRecipeModelUpdateFullRecipe(t *testing.T) {
 					CreatedAt:      time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
 					LastEditedAt:   time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
 					Notes:          "a recipe",
-					ParentRecipeID: 0,
+					ParentRecipeID: 1,
 					IsLatest:       true,
 				},
 				RecipeComponents: []*RecipeComponent{
@@ -2508,12 +2912,13 @@ func TestRecipeModelUpdateFullRecipe(t *testing.T) {
 				},
 				Consumables: []*Consumable{
 					{
-						CreatorID: 4,
-						CreatedAt: time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
-						Name:      "Lasagne Pasta Large",
-						BrandName: "San Remo",
-						Size:      62.5,
-						Units:     "g",
+						CreatorID:  4,
+						CreatedAt:  time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
+						Name:       "Lasagne Pasta Large",
+						BrandName:  "San Remo",
+						Visibility: VisibilityShared,
+						Size:       62.5,
+						Units:      "g",
 						Macros: Macronutrients{
 							Carbs:    46.6,
 							Fats:     0.9,
@@ -2522,12 +2927,13 @@ func TestRecipeModelUpdateFullRecipe(t *testing.T) {
 						},
 					},
 					{
-						CreatorID: 4,
-						CreatedAt: time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
-						Name:      "No Added Hormone Beef 5 Star Extra Trim Mince",
-						BrandName: "Coles",
-						Size:      100,
-						Units:     "g",
+						CreatorID:  4,
+						CreatedAt:  time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
+						Name:       "No Added Hormone Beef 5 Star Extra Trim Mince",
+						BrandName:  "Coles",
+						Visibility: VisibilityShared,
+						Size:       100,
+						Units:      "g",
 						Macros: Macronutrients{
 							Carbs:    .5,
 							Fats:     2,
@@ -2569,6 +2975,47 @@ func TestRecipeModelUpdateFullRecipe(t *testing.T) {
 	}
 }
 
+func TestRecipeModelUpdateFullRecipeParent(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db, err := newTestDB(t, "recipe")
+	if err != nil {
+		t.Fatal(fmt.Errorf("Failed test db setup: %w", err))
+	}
+	m := RecipeModel{db}
+
+	fullRecipe, err := m.GetFullRecipe(1, 1)
+	assert.NilError(t, err)
+
+	// another user cannot replace the recipe
+	stolen := *fullRecipe
+	stolen.Recipe.CreatorID = 2
+	stolen.Recipe.ParentRecipeID = 1
+	err = m.UpdateFullRecipe(&stolen)
+	assert.ExpectError(t, err, ErrRecordNotFound)
+
+	latest, err := m.GetFullRecipe(1, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, latest.Recipe.IsLatest, true)
+
+	// the ID in the recipe is ignored, only the parent is retired
+	child := *fullRecipe
+	child.Recipe.ID = 2
+	child.Recipe.ParentRecipeID = 1
+	err = m.UpdateFullRecipe(&child)
+	assert.NilError(t, err)
+	assert.Equal(t, child.Recipe.ParentRecipeID, int64(1))
+
+	// a version that has already been replaced cannot be replaced again
+	again := *fullRecipe
+	again.Recipe.ParentRecipeID = 1
+	err = m.UpdateFullRecipe(&again)
+	assert.ExpectError(t, err, ErrRecordNotFound)
+}
+
 func TestRecipeModelDelete(t *testing.T) {
 
 	if testing.Short() {
@@ -2942,3 +3389,547 @@ func TestRecipeModelGetAllAncestors(t *testing.T) {
 		})
 	}
 }
+
+func TestRecipeModelSameLineage(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	tests := []struct {
+		name       string
+		ID         int64
+		otherID    int64
+		userID     int64
+		expectSame bool
+	}{
+		{
+			name:       "parent and child",
+			ID:         9,
+			otherID:    10,
+			userID:     4,
+			expectSame: true,
+		},
+		{
+			name:       "same version",
+			ID:         2,
+			otherID:    2,
+			userID:     2,
+			expectSame: true,
+		},
+		{
+			name:       "different recipes",
+			ID:         2,
+			otherID:    3,
+			userID:     2,
+			expectSame: false,
+		},
+		{
+			name:       "another users version",
+			ID:         1,
+			otherID:    8,
+			userID:     2,
+			expectSame: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			db, err := newTestDB(t, "recipes")
+			if err != nil {
+				t.Fatal(fmt.Errorf("Failed test db setup: %w", err))
+			}
+
+			m := RecipeModel{db}
+
+			same, err := m.SameLineage(tt.ID, tt.otherID, tt.userID)
+			assert.NilError(t, err)
+			assert.Equal(t, same, tt.expectSame)
+		})
+	}
+}
+
+func TestRecipeModelGetVersionTree(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	tests := []struct {
+		name          string
+		expectError   error
+		ID            int64
+		userID        int64
+		expectRootID  int64
+		expectCurrent int64
+	}{
+		{
+			name:          "get tree from tip",
+			ID:            10,
+			userID:        4,
+			expectRootID:  9,
+			expectCurrent: 10,
+		},
+		{
+			name:          "get tree from root",
+			ID:            9,
+			userID:        4,
+			expectRootID:  9,
+			expectCurrent: 10,
+		},
+		{
+			name:          "get tree stops at versions of other users",
+			ID:            8,
+			userID:        2,
+			expectRootID:  8,
+			expectCurrent: 8,
+		},
+		{
+			name:        "get tree of other users recipe",
+			expectError: ErrRecordNotFound,
+			ID:          1,
+			userID:      2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			db, err := newTestDB(t, "recipe")
+			if err != nil {
+				t.Fatal(fmt.Errorf("Failed test db setup: %w", err))
+			}
+			m := RecipeModel{db}
+
+			tree, err := m.GetVersionTree(tt.ID, tt.userID)
+
+			assert.ExpectError(t, err, tt.expectError)
+			if err != nil {
+				return
+			}
+
+			assert.Equal(t, tree.Root.Recipe.ID, tt.expectRootID)
+			assert.Equal(t, tree.CurrentID, tt.expectCurrent)
+		})
+	}
+}
+
+func TestRecipeModelInsertFullRecipeSubRecipes(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	tests := []struct {
+		name        string
+		expectError error
+		subRecipeID int64
+	}{
+		{
+			name:        "insert recipe with own sub-recipe",
+			subRecipeID: 7,
+		},
+		{
+			name:        "insert recipe with other users sub-recipe",
+			expectError: ErrSubRecipeDoesNotExist,
+			subRecipeID: 1,
+		},
+		{
+			name:        "insert recipe with missing sub-recipe",
+			expectError: ErrSubRecipeDoesNotExist,
+			subRecipeID: 999999,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			db, err := newTestDB(t, "recipe")
+			if err != nil {
+				t.Fatal(fmt.Errorf("Failed test db setup: %w", err))
+			}
+			m := RecipeModel{db}
+
+			fullRecipe := FullRecipe{
+				Recipe: Recipe{Name: "nested", CreatorID: 3, IsLatest: true},
+				RecipeComponents: []*RecipeComponent{
+					{SubRecipeID: tt.subRecipeID, Quantity: 0.5, StepNo: 1, StepDescription: "step 1"},
+				},
+				PantryItems: []*PantryItem{{}},
+				Consumables: []*Consumable{{}},
+			}
+
+			err = m.InsertFullRecipe(&fullRecipe)
+
+			assert.ExpectError(t, err, tt.expectError)
+			if err != nil {
+				return
+			}
+
+			assert.Equal(t, fullRecipe.RecipeComponents[0].SubRecipeID, tt.subRecipeID)
+			assert.Equal(t, len(fullRecipe.SubRecipes), 1)
+			assert.Equal(t, fullRecipe.SubRecipes[0].Recipe.ID, tt.subRecipeID)
+			assert.Equal(t, len(fullRecipe.SubRecipes[0].RecipeComponents), 1)
+		})
+	}
+}
+
+func TestRecipeModelGetPublic(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db, err := newTestDB(t, "recipe")
+	if err != nil {
+		t.Fatal(fmt.Errorf("Failed test db setup: %w", err))
+	}
+	m := RecipeModel{db}
+
+	err = m.SetVisibility(1, 1, true)
+	assert.NilError(t, err)
+
+	err = m.SetVisibility(2, 1, true)
+	assert.ExpectError(t, err, ErrRecordNotFound)
+
+	filters := RecipeFilters{Metadata: MetadataFilters{Page: 1, PageSize: 20, Sort: "id", SortSafeList: []string{"id"}}, NameSearch: "lasa"}
+
+	recipes, _, err := m.GetPublic(filters)
+	assert.NilError(t, err)
+	assert.Equal(t, len(recipes), 1)
+	assert.Equal(t, recipes[0].ID, 1)
+	assert.Equal(t, recipes[0].IsPublic, true)
+
+	_, err = m.GetPublicFullRecipe(2)
+	assert.ExpectError(t, err, ErrRecordNotFound)
+}
+
+func TestRecipeModelSetVisibility(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db, err := newTestDB(t, "recipe")
+	if err != nil {
+		t.Fatal(fmt.Errorf("Failed test db setup: %w", err))
+	}
+	m := RecipeModel{db}
+
+	// a new version of a public recipe is public, and making it private hides every version
+	err = m.SetVisibility(1, 1, true)
+	assert.NilError(t, err)
+
+	fullRecipe, err := m.GetFullRecipe(1, 1)
+	assert.NilError(t, err)
+	fullRecipe.Recipe.ParentRecipeID = fullRecipe.Recipe.ID
+	err = m.UpdateFullRecipe(fullRecipe)
+	assert.NilError(t, err)
+	assert.Equal(t, fullRecipe.Recipe.IsPublic, true)
+
+	err = m.SetVisibility(fullRecipe.Recipe.ID, 1, false)
+	assert.NilError(t, err)
+
+	_, err = m.GetPublicFullRecipe(1)
+	assert.ExpectError(t, err, ErrRecordNotFound)
+	_, err = m.GetPublicFullRecipe(fullRecipe.Recipe.ID)
+	assert.ExpectError(t, err, ErrRecordNotFound)
+
+	// a recipe is only public while the sub-recipes it uses are
+	nested := FullRecipe{
+		Recipe: Recipe{Name: "nested", CreatorID: 3, IsLatest: true},
+		RecipeComponents: []*RecipeComponent{
+			{SubRecipeID: 7, Quantity: 0.5, StepNo: 1, StepDescription: "step 1"},
+		},
+		PantryItems: []*PantryItem{{}},
+		Consumables: []*Consumable{{}},
+	}
+	err = m.InsertFullRecipe(&nested)
+	assert.NilError(t, err)
+
+	err = m.SetVisibility(nested.Recipe.ID, 3, true)
+	assert.ExpectError(t, err, ErrSubRecipeNotPublic)
+
+	err = m.SetVisibility(7, 3, true)
+	assert.NilError(t, err)
+	err = m.SetVisibility(nested.Recipe.ID, 3, true)
+	assert.NilError(t, err)
+
+	_, err = m.GetPublicFullRecipe(nested.Recipe.ID)
+	assert.NilError(t, err)
+
+	err = m.SetVisibility(7, 3, false)
+	assert.NilError(t, err)
+
+	_, err = m.GetPublicFullRecipe(nested.Recipe.ID)
+	assert.ExpectError(t, err, ErrRecordNotFound)
+	_, err = m.ForkFullRecipe(nested.Recipe.ID, 1)
+	assert.ExpectError(t, err, ErrRecordNotFound)
+
+	filters := RecipeFilters{Metadata: MetadataFilters{Page: 1, PageSize: 20, Sort: "id", SortSafeList: []string{"id"}}}
+	recipes, _, err := m.GetPublic(filters)
+	assert.NilError(t, err)
+	assert.Equal(t, len(recipes), 0)
+}
+
+func TestRecipeModelForkFullRecipe(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	tests := []struct {
+		name              string
+		expectError       error
+		ID                int64
+		userID            int64
+		expectPantryItems []int64
+		hideConsumable    int64
+	}{
+		{
+			name:   "fork other users public recipe",
+			ID:     1,
+			userID: 3,
+		},
+		{
+			name:              "fork own public recipe reuses pantry items",
+			ID:                1,
+			userID:            1,
+			expectPantryItems: []int64{1, 2},
+		},
+		{
+			name:        "fork private recipe",
+			expectError: ErrRecordNotFound,
+			ID:          2,
+			userID:      3,
+		},
+		{
+			name:           "fork recipe using another users private consumable",
+			expectError:    ErrRecordNotFound,
+			ID:             1,
+			userID:         3,
+			hideConsumable: 17,
+		},
+		{
+			name:           "fork recipe using own private consumable",
+			ID:             1,
+			userID:         4,
+			hideConsumable: 17,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			db, err := newTestDB(t, "recipe")
+			if err != nil {
+				t.Fatal(fmt.Errorf("Failed test db setup: %w", err))
+			}
+			m := RecipeModel{db}
+
+			err = m.SetVisibility(1, 1, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.hideConsumable != 0 {
+				err = ConsumableModel{db}.SetVisibility(tt.hideConsumable, VisibilityPrivate)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			fork, err := m.ForkFullRecipe(tt.ID, tt.userID)
+
+			assert.ExpectError(t, err, tt.expectError)
+			if err != nil {
+				return
+			}
+
+			assert.Equal(t, fork.Recipe.CreatorID, tt.userID)
+			assert.Equal(t, fork.Recipe.ForkedFromID, tt.ID)
+			assert.Equal(t, fork.Recipe.IsPublic, false)
+			assert.Equal(t, len(fork.RecipeComponents), 2)
+			for i, pantryItem := range fork.PantryItems {
+				assert.Equal(t, pantryItem.UserID, tt.userID)
+				assert.Equal(t, fork.Consumables[i].ID, []int64{17, 18}[i])
+				if tt.expectPantryItems != nil {
+					assert.Equal(t, pantryItem.ID, tt.expectPantryItems[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRecipeFilters(t *testing.T) {
+
+	metadata := MetadataFilters{Page: 1, PageSize: 20, Sort: "id", SortSafeList: []string{"id", "name", "-energy"}}
+
+	tests := []struct {
+		name             string
+		valid            bool
+		filters          RecipeFilters
+		expectNutritions bool
+	}{
+		{
+			name:             "valid no ranges",
+			valid:            true,
+			filters:          RecipeFilters{Metadata: metadata},
+			expectNutritions: false,
+		},
+		{
+			name:             "valid protein range",
+			valid:            true,
+			filters:          RecipeFilters{Metadata: metadata, MinProtein: 10, MaxProtein: 20},
+			expectNutritions: true,
+		},
+		{
+			name:             "valid energy max",
+			valid:            true,
+			filters:          RecipeFilters{Metadata: metadata, MaxEnergy: 1000},
+			expectNutritions: true,
+		},
+		{
+			name:    "invalid max below min",
+			valid:   false,
+			filters: RecipeFilters{Metadata: metadata, MinEnergy: 2000, MaxEnergy: 1000},
+		},
+		{
+			name:    "invalid negative protein",
+			valid:   false,
+			filters: RecipeFilters{Metadata: metadata, MinProtein: -1},
+		},
+		{
+			name:    "invalid negative consumable",
+			valid:   false,
+			filters: RecipeFilters{Metadata: metadata, ConsumableID: -1},
+		},
+		{
+			name:    "valid largest page",
+			valid:   true,
+			filters: RecipeFilters{Metadata: MetadataFilters{Page: 1, PageSize: MaxRecipePageSize, Sort: "id", SortSafeList: []string{"id"}}},
+		},
+		{
+			name:    "invalid page too large",
+			valid:   false,
+			filters: RecipeFilters{Metadata: MetadataFilters{Page: 1, PageSize: MaxRecipePageSize + 1, Sort: "id", SortSafeList: []string{"id"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			v := validator.New()
+
+			ValidateRecipeFilters(v, tt.filters)
+			assert.ValidatorValid(t, v, tt.valid)
+			if !tt.valid {
+				return
+			}
+
+			assert.Equal(t, tt.filters.filtersNutrition(), tt.expectNutritions)
+		})
+	}
+
+	t.Run("sort columns", func(t *testing.T) {
+		byName := RecipeFilters{Metadata: metadata}
+		byName.Metadata.Sort = "name"
+		assert.Equal(t, byName.sortColumn(), "recipe_name")
+
+		byEnergy := RecipeFilters{Metadata: metadata}
+		byEnergy.Metadata.Sort = "-energy"
+		assert.Equal(t, byEnergy.sortColumn(), "serving_kj")
+	})
+}
+
+func TestRecipeModelSearch(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	safeList := []string{"id", "name", "created_at", "last_edited_at", "energy", "-id", "-name", "-created_at", "-last_edited_at", "-energy"}
+
+	tests := []struct {
+		name      string
+		userID    int64
+		filters   RecipeFilters
+		expectIDs []int64
+	}{
+		{
+			name:      "search by name",
+			userID:    2,
+			filters:   RecipeFilters{NameSearch: "recipe"},
+			expectIDs: []int64{2, 3, 5, 8},
+		},
+		{
+			name:      "search by consumable",
+			userID:    1,
+			filters:   RecipeFilters{ConsumableID: 17},
+			expectIDs: []int64{1},
+		},
+		{
+			name:      "search by consumable of other users recipe",
+			userID:    3,
+			filters:   RecipeFilters{ConsumableID: 17},
+			expectIDs: []int64{},
+		},
+		{
+			name:      "search by pantry item",
+			userID:    3,
+			filters:   RecipeFilters{PantryItemID: 3},
+			expectIDs: []int64{7},
+		},
+		{
+			name:      "sort by energy",
+			userID:    2,
+			filters:   RecipeFilters{Metadata: MetadataFilters{Sort: "energy"}},
+			expectIDs: []int64{2, 3, 5, 6, 8},
+		},
+		{
+			name:      "filter by protein",
+			userID:    2,
+			filters:   RecipeFilters{MinProtein: 1},
+			expectIDs: []int64{},
+		},
+		{
+			name:      "filter by protein per serving",
+			userID:    1,
+			filters:   RecipeFilters{MinProtein: 1.5, MaxProtein: 1.6},
+			expectIDs: []int64{1},
+		},
+		{
+			name:      "filter by energy per serving",
+			userID:    1,
+			filters:   RecipeFilters{MaxEnergy: 50},
+			expectIDs: []int64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			db, err := newTestDB(t, "recipe")
+			if err != nil {
+				t.Fatal(fmt.Errorf("Failed test db setup: %w", err))
+			}
+			m := RecipeModel{db}
+
+			tt.filters.Metadata.Page = 1
+			tt.filters.Metadata.PageSize = 20
+			tt.filters.Metadata.SortSafeList = safeList
+			if tt.filters.Metadata.Sort == "" {
+				tt.filters.Metadata.Sort = "id"
+			}
+
+			recipes, _, err := m.Search(tt.userID, tt.filters)
+			assert.NilError(t, err)
+
+			assert.Equal(t, len(recipes), len(tt.expectIDs))
+			for i, recipe := range recipes {
+				assert.Equal(t, recipe.ID, tt.expectIDs[i])
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package data
+
+import "errors"
+
+// RecipeTreeNode is one version of a recipe in a version tree, a tip is a version without children
+type RecipeTreeNode struct {
+	Recipe    Recipe            `json:"recipe"`
+	IsTip     bool              `json:"is_tip"`
+	IsCurrent bool              `json:"is_current"`
+	Children  []*RecipeTreeNode `json:"children"`
+}
+
+// RecipeTree is every version descended from the first version of a recipe. Versions can branch when
+// an older version is given a child, the current version is the most recently created tip that is latest
+type RecipeTree struct {
+	Root      *RecipeTreeNode `json:"root"`
+	CurrentID int64           `json:"current_id"`
+	TipIDs    []int64         `json:"tip_ids"`
+}
+
+// BuildRecipeTree arranges versions into a tree, exactly one version must have a parent outside of recipes
+func BuildRecipeTree(recipes []*Recipe) (*RecipeTree, error) {
+	nodes := map[int64]*RecipeTreeNode{}
+	for _, recipe := range recipes {
+		nodes[recipe.ID] = &RecipeTreeNode{Recipe: *recipe, Children: []*RecipeTreeNode{}}
+	}
+
+	tree := &RecipeTree{TipIDs: []int64{}}
+
+	// children are added in the order of recipes so siblings keep that order
+	for _, recipe := range recipes {
+		node := nodes[recipe.ID]
+		parent, ok := nodes[recipe.ParentRecipeID]
+		if !ok {
+			if tree.Root != nil {
+				return nil, errors.New("recipe versions have more than one root")
+			}
+			tree.Root = node
+			continue
+		}
+		parent.Children = append(parent.Children, node)
+	}
+
+	if tree.Root == nil {
+		return nil, ErrRecordNotFound
+	}
+
+	var current *RecipeTreeNode
+	isNewer := func(a, b *RecipeTreeNode) bool {
+		if a.Recipe.IsLatest != b.Recipe.IsLatest {
+			return a.Recipe.IsLatest
+		}
+		if !a.Recipe.CreatedAt.Equal(b.Recipe.CreatedAt) {
+			return a.Recipe.CreatedAt.After(b.Recipe.CreatedAt)
+		}
+		return a.Recipe.ID > b.Recipe.ID
+	}
+
+	for _, recipe := range recipes {
+		node := nodes[recipe.ID]
+		if len(node.Children) > 0 {
+			continue
+		}
+		node.IsTip = true
+		tree.TipIDs = append(tree.TipIDs, node.Recipe.ID)
+		if current == nil || isNewer(node, current) {
+			current = node
+		}
+	}
+
+	current.IsCurrent = true
+	tree.CurrentID = current.Recipe.ID
+
+	return tree, nil
+}
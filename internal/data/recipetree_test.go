@@ -0,0 +1,83 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tconnellan/macro-tracker-backend/internal/assert"
+)
+
+func TestBuildRecipeTree(t *testing.T) {
+
+	at := func(day int) time.Time {
+		return time.Date(2024, time.January, day, 10, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name          string
+		recipes       []*Recipe
+		expectError   bool
+		expectRootID  int64
+		expectCurrent int64
+		expectTips    []int64
+	}{
+		{
+			name: "linear history",
+			recipes: []*Recipe{
+				{ID: 1, CreatedAt: at(1)},
+				{ID: 2, ParentRecipeID: 1, CreatedAt: at(2)},
+				{ID: 3, ParentRecipeID: 2, CreatedAt: at(3), IsLatest: true},
+			},
+			expectRootID:  1,
+			expectCurrent: 3,
+			expectTips:    []int64{3},
+		},
+		{
+			name: "branched history prefers most recent latest tip",
+			recipes: []*Recipe{
+				{ID: 1, CreatedAt: at(1)},
+				{ID: 2, ParentRecipeID: 1, CreatedAt: at(2), IsLatest: true},
+				{ID: 3, ParentRecipeID: 1, CreatedAt: at(3), IsLatest: true},
+				{ID: 4, ParentRecipeID: 2, CreatedAt: at(4)},
+			},
+			expectRootID:  1,
+			expectCurrent: 3,
+			expectTips:    []int64{3, 4},
+		},
+		{
+			name: "root parent outside of tree",
+			recipes: []*Recipe{
+				{ID: 5, ParentRecipeID: 1, CreatedAt: at(1), IsLatest: true},
+			},
+			expectRootID:  5,
+			expectCurrent: 5,
+			expectTips:    []int64{5},
+		},
+		{
+			name: "multiple roots",
+			recipes: []*Recipe{
+				{ID: 1, CreatedAt: at(1)},
+				{ID: 2, CreatedAt: at(2)},
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, err := BuildRecipeTree(tt.recipes)
+
+			assert.Equal(t, err != nil, tt.expectError)
+			if err != nil {
+				return
+			}
+
+			assert.Equal(t, tree.Root.Recipe.ID, tt.expectRootID)
+			assert.Equal(t, tree.CurrentID, tt.expectCurrent)
+			assert.Equal(t, len(tree.TipIDs), len(tt.expectTips))
+			for i := range tt.expectTips {
+				assert.Equal(t, tree.TipIDs[i], tt.expectTips[i])
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tconnellan/macro-tracker-backend/internal/validator"
+)
+
+// ServingSize is a named portion of a consumable such as "1 slice", measured as Amount of Units
+type ServingSize struct {
+	ID           int64           `json:"id"`
+	ConsumableID int64           `json:"consumable_id"`
+	Name         string          `json:"name"`
+	Amount       float64         `json:"amount"`
+	Units        MeasurementUnit `json:"units"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+func ValidateServingSize(v *validator.Validator, servingSize *ServingSize) {
+	v.Check(servingSize.Name != "", "name", "must be provided")
+	v.Check(len(servingSize.Name) <= 50, "name", "must be maximum 50 characters")
+	v.Check(servingSize.Amount > 0, "amount", "must be positive")
+	v.Check(isValidMeasurementUnit(servingSize.Units), "units", "must be valid")
+}
+
+type ServingSizeModel struct {
+	DB *pgxpool.Pool
+}
+
+type IServingSizeModel interface {
+	Get(int64) (*ServingSize, error)
+	GetByConsumableIDs([]int64) (map[int64][]*ServingSize, error)
+	Insert(*ServingSize, int64) error
+	Delete(int64, int64, int64) error
+}
+
+func (m ServingSizeModel) Get(ID int64) (*ServingSize, error) {
+	stmt := `
+	SELECT id, consumable_id, name, amount, units, created_at
+	FROM serving_sizes
+	WHERE id = $1
+	`
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	var servingSize ServingSize
+
+	err := m.DB.QueryRow(ctx, stmt, ID).Scan(
+		&servingSize.ID,
+		&servingSize.ConsumableID,
+		&servingSize.Name,
+		&servingSize.Amount,
+		&servingSize.Units,
+		&servingSize.CreatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &servingSize, nil
+}
+
+// GetByConsumableIDs returns the serving sizes of each consumable keyed by consumable id,
+// consumables without serving sizes are absent from the map
+func (m ServingSizeModel) GetByConsumableIDs(consumableIDs []int64) (map[int64][]*ServingSize, error) {
+	stmt := `
+	SELECT id, consumable_id, name, amount, units, created_at
+	FROM serving_sizes
+	WHERE consumable_id = ANY($1)
+	ORDER BY consumable_id ASC, id ASC
+	`
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	servingSizes, err := readServingSizeRows(ctx, m.DB, stmt, consumableIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byConsumable := map[int64][]*ServingSize{}
+	for _, servingSize := range servingSizes {
+		byConsumable[servingSize.ConsumableID] = append(byConsumable[servingSize.ConsumableID], servingSize)
+	}
+
+	return byConsumable, nil
+}
+
+// getServingSizesByRecipeID returns the serving sizes used by the steps of a recipe
+func getServingSizesByRecipeID(ctx context.Context, db psqlDB, recipeID int64) ([]*ServingSize, error) {
+	stmt := `
+	SELECT id, consumable_id, name, amount, units, created_at
+	FROM serving_sizes
+	WHERE id IN (SELECT serving_id FROM recipe_components WHERE recipe_id = $1)
+	ORDER BY id ASC
+	`
+
+	return readServingSizeRows(ctx, db, stmt, recipeID)
+}
+
+func readServingSizeRows(ctx context.Context, db psqlDB, stmt string, args ...any) ([]*ServingSize, error) {
+	rows, err := db.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	servingSizes := []*ServingSize{}
+
+	for rows.Next() {
+		var servingSize ServingSize
+		err = rows.Scan(
+			&servingSize.ID,
+			&servingSize.ConsumableID,
+			&servingSize.Name,
+			&servingSize.Amount,
+			&servingSize.Units,
+			&servingSize.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		servingSizes = append(servingSizes, &servingSize)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return servingSizes, nil
+}
+
+// Insert adds a serving size to a consumable created by userID
+func (m ServingSizeModel) Insert(servingSize *ServingSize, userID int64) error {
+	stmt := `
+	INSERT INTO serving_sizes (consumable_id, name, amount, units)
+	SELECT id, $2, $3, $4
+	FROM consumables
+	WHERE id = $1 AND creator_id = $5
+	RETURNING id, created_at
+	`
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	args := []any{
+		servingSize.ConsumableID,
+		servingSize.Name,
+		servingSize.Amount,
+		servingSize.Units,
+		userID,
+	}
+
+	err := m.DB.QueryRow(ctx, stmt, args...).Scan(&servingSize.ID, &servingSize.CreatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return ErrConsumableDoesNotExist
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete removes a serving size from a consumable created by userID, serving sizes used
+// by a recipe step or consumed entry cannot be deleted
+func (m ServingSizeModel) Delete(ID int64, consumableID int64, userID int64) error {
+	stmt := `
+	DELETE FROM serving_sizes S
+	USING consumables C
+	WHERE S.id = $1 AND S.consumable_id = $2 AND C.id = S.consumable_id AND C.creator_id = $3
+	`
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	result, err := m.DB.Exec(ctx, stmt, ID, consumableID, userID)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "ERROR: update or delete on table \"serving_sizes\" violates foreign key constraint \"fk_recipecomponent_serving\""):
+			return ErrServingSizeInUse
+		case strings.HasPrefix(err.Error(), "ERROR: update or delete on table \"serving_sizes\" violates foreign key constraint \"fk_consumed_servingid\""):
+			return ErrServingSizeInUse
+		default:
+			return err
+		}
+	}
+
+	rows := result.RowsAffected()
+
+	if rows == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,243 @@
+package data
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tconnellan/macro-tracker-backend/internal/assert"
+	"github.com/tconnellan/macro-tracker-backend/internal/validator"
+)
+
+func TestServingSizeHelpers(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		valid       bool
+		servingSize ServingSize
+	}{
+		{
+			name:  "valid serving size",
+			valid: true,
+			servingSize: ServingSize{
+				ConsumableID: 1,
+				Name:         "1 slice",
+				Amount:       40,
+				Units:        "g",
+			},
+		},
+		{
+			name:  "invalid serving size empty name",
+			valid: false,
+			servingSize: ServingSize{
+				ConsumableID: 1,
+				Name:         "",
+				Amount:       40,
+				Units:        "g",
+			},
+		},
+		{
+			name:  "invalid serving size zero amount",
+			valid: false,
+			servingSize: ServingSize{
+				ConsumableID: 1,
+				Name:         "1 slice",
+				Amount:       0,
+				Units:        "g",
+			},
+		},
+		{
+			name:  "invalid serving size bad units",
+			valid: false,
+			servingSize: ServingSize{
+				ConsumableID: 1,
+				Name:         "1 slice",
+				Amount:       40,
+				Units:        "slices",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+
+			ValidateServingSize(v, &tt.servingSize)
+			assert.ValidatorValid(t, v, tt.valid)
+		})
+	}
+}
+
+func TestServingSizeModelGetByConsumableIDs(t *testing.T) {
+
+	timeFormat := "2006-01-02 15:04:05"
+
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	tests := []struct {
+		name          string
+		consumableIDs []int64
+		expect        map[int64][]*ServingSize
+	}{
+		{
+			name:          "multiple consumables",
+			consumableIDs: []int64{1, 4, 12},
+			expect: map[int64][]*ServingSize{
+				4: {
+					{ID: 1, ConsumableID: 4, Name: "1 slice", Amount: 40, Units: "g", CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00")},
+					{ID: 2, ConsumableID: 4, Name: "1 loaf", Amount: 700, Units: "g", CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00")},
+				},
+				12: {
+					{ID: 3, ConsumableID: 12, Name: "1 cup", Amount: 250, Units: "ml", CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00")},
+				},
+			},
+		},
+		{
+			name:          "no serving sizes",
+			consumableIDs: []int64{1},
+			expect:        map[int64][]*ServingSize{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			db, err := newTestDB(t, "serving_sizes")
+			if err != nil {
+				t.Fatal(fmt.Errorf("Failed test db setup: %w", err))
+			}
+			m := ServingSizeModel{db}
+
+			servingSizes, err := m.GetByConsumableIDs(tt.consumableIDs)
+			assert.NilError(t, err)
+
+			assert.Equal(t, len(servingSizes), len(tt.expect))
+			for consumableID, expect := range tt.expect {
+				assert.SliceEqual(t, servingSizes[consumableID], expect)
+			}
+		})
+	}
+}
+
+func TestServingSizeModelInsert(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	tests := []struct {
+		name        string
+		servingSize ServingSize
+		userID      int64
+		expectError error
+	}{
+		{
+			name: "insert for own consumable",
+			servingSize: ServingSize{
+				ConsumableID: 1,
+				Name:         "1 cup",
+				Amount:       90,
+				Units:        "g",
+			},
+			userID:      1,
+			expectError: nil,
+		},
+		{
+			name: "insert for other user's consumable",
+			servingSize: ServingSize{
+				ConsumableID: 12,
+				Name:         "1 glass",
+				Amount:       200,
+				Units:        "ml",
+			},
+			userID:      1,
+			expectError: ErrConsumableDoesNotExist,
+		},
+		{
+			name: "insert for non existent consumable",
+			servingSize: ServingSize{
+				ConsumableID: 99999,
+				Name:         "1 glass",
+				Amount:       200,
+				Units:        "ml",
+			},
+			userID:      1,
+			expectError: ErrConsumableDoesNotExist,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			db, err := newTestDB(t, "serving_sizes")
+			if err != nil {
+				t.Fatal(fmt.Errorf("Failed test db setup: %w", err))
+			}
+			m := ServingSizeModel{db}
+
+			err = m.Insert(&tt.servingSize, tt.userID)
+
+			assert.ExpectError(t, err, tt.expectError)
+			if err != nil {
+				return
+			}
+
+			servingSize, err := m.Get(tt.servingSize.ID)
+			assert.NilError(t, err)
+			assert.Equal(t, *servingSize, tt.servingSize)
+		})
+	}
+}
+
+func TestServingSizeModelDelete(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	tests := []struct {
+		name         string
+		ID           int64
+		consumableID int64
+		userID       int64
+		expectError  error
+	}{
+		{
+			name:         "delete own serving size",
+			ID:           1,
+			consumableID: 4,
+			userID:       1,
+			expectError:  nil,
+		},
+		{
+			name:         "delete other user's serving size",
+			ID:           3,
+			consumableID: 12,
+			userID:       1,
+			expectError:  ErrRecordNotFound,
+		},
+		{
+			name:         "delete serving size of other consumable",
+			ID:           1,
+			consumableID: 1,
+			userID:       1,
+			expectError:  ErrRecordNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			db, err := newTestDB(t, "serving_sizes")
+			if err != nil {
+				t.Fatal(fmt.Errorf("Failed test db setup: %w", err))
+			}
+			m := ServingSizeModel{db}
+
+			err = m.Delete(tt.ID, tt.consumableID, tt.userID)
+
+			assert.ExpectError(t, err, tt.expectError)
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package data
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tconnellan/macro-tracker-backend/internal/validator"
+)
+
+// Tag is a user defined label such as "breakfast" put on recipe lineages, tagging any version of a
+// recipe tags every version of it
+type Tag struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func ValidateTag(v *validator.Validator, tag *Tag) {
+	v.Check(strings.TrimSpace(tag.Name) != "", "name", "must be provided")
+	v.Check(len(strings.TrimSpace(tag.Name)) <= 50, "name", "must be maximum 50 characters")
+}
+
+type TagModel struct {
+	DB *pgxpool.Pool
+}
+
+type ITagModel interface {
+	GetAllByUserID(int64) ([]*Tag, error)
+	GetByRecipeID(int64, int64) ([]*Tag, error)
+	Insert(*Tag) error
+	Update(*Tag) error
+	Delete(int64, int64) error
+	AddRecipe(int64, int64, int64) error
+	RemoveRecipe(int64, int64, int64) error
+}
+
+func (m TagModel) GetAllByUserID(userID int64) ([]*Tag, error) {
+	stmt := `
+	SELECT id, user_id, name, created_at
+	FROM tags
+	WHERE user_id = $1
+	ORDER BY name ASC, id ASC
+	`
+
+	return m.readTagRows(stmt, userID)
+}
+
+// GetByRecipeID returns the tags on the lineage of one of the user's recipes
+func (m TagModel) GetByRecipeID(recipeID int64, userID int64) ([]*Tag, error) {
+	stmt := `
+	WITH RECURSIVE ` + recipeLineages + `
+	SELECT T.id, T.user_id, T.name, T.created_at
+	FROM tags T
+	     INNER JOIN recipe_tags RT ON RT.tag_id = T.id
+	     INNER JOIN lineages L ON L.root_id = RT.recipe_id
+	WHERE L.id = $2 AND T.user_id = $1
+	ORDER BY T.name ASC, T.id ASC
+	`
+
+	return m.readTagRows(stmt, userID, recipeID)
+}
+
+func (m TagModel) readTagRows(stmt string, args ...any) ([]*Tag, error) {
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	rows, err := m.DB.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []*Tag{}
+
+	for rows.Next() {
+		var tag Tag
+		err = rows.Scan(
+			&tag.ID,
+			&tag.UserID,
+			&tag.Name,
+			&tag.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, &tag)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// Insert stores the tag with its name trimmed, as names are trimmed when filtering recipes by them
+func (m TagModel) Insert(tag *Tag) error {
+	stmt := `
+	INSERT INTO tags (user_id, name)
+	VALUES ($1, $2)
+	RETURNING id, created_at
+	`
+
+	tag.Name = strings.TrimSpace(tag.Name)
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	err := m.DB.QueryRow(ctx, stmt, tag.UserID, tag.Name).Scan(&tag.ID, &tag.CreatedAt)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), `ERROR: duplicate key value violates unique constraint "tags_user_name_key"`):
+			return ErrDuplicateTag
+		case strings.HasPrefix(err.Error(), "ERROR: insert or update on table \"tags\" violates foreign key constraint \"fk_tag_user\""):
+			return ErrReferencedUserDoesNotExist
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Update renames one of the user's tags
+func (m TagModel) Update(tag *Tag) error {
+	stmt := `
+	UPDATE tags
+	SET name = $3
+	WHERE id = $1 AND user_id = $2
+	RETURNING created_at
+	`
+
+	tag.Name = strings.TrimSpace(tag.Name)
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	err := m.DB.QueryRow(ctx, stmt, tag.ID, tag.UserID, tag.Name).Scan(&tag.CreatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return ErrRecordNotFound
+		case strings.HasPrefix(err.Error(), `ERROR: duplicate key value violates unique constraint "tags_user_name_key"`):
+			return ErrDuplicateTag
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete removes one of the user's tags from every recipe and deletes it
+func (m TagModel) Delete(ID int64, userID int64) error {
+	stmt := `
+	DELETE FROM tags
+	WHERE id = $1 AND user_id = $2
+	`
+
+	ctx, cancel := GetDefaultTimeoutContext()
+	defer cancel()
+
+	result, err := m.DB.Exec(ctx, stmt, ID, userID)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// AddRecipe tags the lineage of one of the user's recipes, tagging a lineage twice has no effect
+func (m TagModel) AddRecipe(ID int64, recipeID int64, userID int64) error {
+	stmt := `
+	WITH RECURSIVE ` + recipeLineages + `
+	INSERT INTO recipe_tags (tag_id, recipe_id)
+	SELECT T.id, L.root_id
+	FROM tags T CROSS JOIN lineages L
+	WHERE T.id = $2 AND T.user_id = $1 AND L.id = $3
+	`
+
+	return addToLineage(m.DB, stmt, userID, ID, recipeID)
+}
+
+// RemoveRecipe takes the tag off the lineage of one of the user's recipes
+func (m TagModel) RemoveRecipe(ID int64, recipeID int64, userID int64) error {
+	stmt := `
+	WITH RECURSIVE ` + recipeLineages + `
+	DELETE FROM recipe_tags RT
+	USING tags T, lineages L
+	WHERE RT.tag_id = T.id AND RT.recipe_id = L.root_id
+	  AND T.id = $2 AND T.user_id = $1 AND L.id = $3
+	`
+
+	return removeFromLineage(m.DB, stmt, userID, ID, recipeID)
+}
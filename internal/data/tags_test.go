@@ -0,0 +1,166 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tconnellan/macro-tracker-backend/internal/assert"
+	"github.com/tconnellan/macro-tracker-backend/internal/validator"
+)
+
+func TestValidateTag(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		valid bool
+		tag   Tag
+	}{
+		{
+			name:  "valid tag",
+			valid: true,
+			tag:   Tag{UserID: 1, Name: "breakfast"},
+		},
+		{
+			name:  "invalid blank name",
+			valid: false,
+			tag:   Tag{UserID: 1, Name: "  "},
+		},
+		{
+			name:  "invalid name too long",
+			valid: false,
+			tag:   Tag{UserID: 1, Name: strings.Repeat("a", 51)},
+		},
+		{
+			name:  "valid padded name",
+			valid: true,
+			tag:   Tag{UserID: 1, Name: " " + strings.Repeat("a", 50) + " "},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			v := validator.New()
+
+			ValidateTag(v, &tt.tag)
+			assert.ValidatorValid(t, v, tt.valid)
+		})
+	}
+}
+
+func TestTagModelAddRecipe(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	tests := []struct {
+		name        string
+		expectError error
+		recipeID    int64
+		userID      int64
+	}{
+		{
+			name:     "tag latest version",
+			recipeID: 10,
+			userID:   4,
+		},
+		{
+			name:     "tag first version",
+			recipeID: 9,
+			userID:   4,
+		},
+		{
+			name:        "tag other users recipe",
+			expectError: ErrRecordNotFound,
+			recipeID:    1,
+			userID:      4,
+		},
+		{
+			name:        "tag with other users tag",
+			expectError: ErrRecordNotFound,
+			recipeID:    1,
+			userID:      1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			db, err := newTestDB(t, "tag")
+			if err != nil {
+				t.Fatal(fmt.Errorf("Failed test db setup: %w", err))
+			}
+			m := TagModel{db}
+
+			tag := Tag{UserID: 4, Name: "high protein"}
+			err = m.Insert(&tag)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = m.AddRecipe(tag.ID, tt.recipeID, tt.userID)
+
+			assert.ExpectError(t, err, tt.expectError)
+			if err != nil {
+				return
+			}
+
+			// tagging twice has no effect
+			err = m.AddRecipe(tag.ID, tt.recipeID, tt.userID)
+			assert.NilError(t, err)
+
+			// every version of the lineage has the tag
+			for _, recipeID := range []int64{9, 10} {
+				tags, err := m.GetByRecipeID(recipeID, tt.userID)
+				assert.NilError(t, err)
+				assert.Equal(t, len(tags), 1)
+				assert.Equal(t, tags[0].ID, tag.ID)
+			}
+
+			recipes, _, err := RecipeModel{db}.Search(tt.userID, RecipeFilters{
+				Metadata: MetadataFilters{Page: 1, PageSize: 20, Sort: "id", SortSafeList: []string{"id"}},
+				Tags:     []string{"high protein"},
+			})
+			assert.NilError(t, err)
+			assert.Equal(t, len(recipes), 2)
+
+			err = m.RemoveRecipe(tag.ID, 10, tt.userID)
+			assert.NilError(t, err)
+
+			tags, err := m.GetByRecipeID(9, tt.userID)
+			assert.NilError(t, err)
+			assert.Equal(t, len(tags), 0)
+		})
+	}
+}
+
+func TestTagModelInsert(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db, err := newTestDB(t, "tag")
+	if err != nil {
+		t.Fatal(fmt.Errorf("Failed test db setup: %w", err))
+	}
+	m := TagModel{db}
+
+	err = m.Insert(&Tag{UserID: 1, Name: "breakfast"})
+	assert.NilError(t, err)
+
+	err = m.Insert(&Tag{UserID: 1, Name: "breakfast"})
+	assert.ExpectError(t, err, ErrDuplicateTag)
+
+	err = m.Insert(&Tag{UserID: 1, Name: " breakfast "})
+	assert.ExpectError(t, err, ErrDuplicateTag)
+
+	err = m.Insert(&Tag{UserID: 2, Name: "breakfast"})
+	assert.NilError(t, err)
+
+	tags, err := m.GetAllByUserID(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(tags), 1)
+}
@@ -42,6 +42,11 @@ INSERT INTO consumables (creator_id, created_at, name, brand_name, size, units,
 (4, '2024-01-01 10:00:00', 'No Added Hormone Beef 5 Star Extra Trim Mince', 'Coles', 100, 'g', 0.5, 2, 21.3, 0);
 
 
+INSERT INTO serving_sizes (consumable_id, name, amount, units, created_at) VALUES
+(4, '1 slice', 40, 'g', '2024-01-01 10:00:00'),
+(4, '1 loaf', 700, 'g', '2024-01-01 10:00:00'),
+(12, '1 cup', 250, 'ml', '2024-01-01 10:00:00');
+
 INSERT INTO recipes (recipe_name, creator_id, created_at, last_edited_at, notes, parent_recipe_id, is_latest) VALUES 
 ('Lasagne', 1, '2024-01-01 10:00:00', '2024-01-01 10:00:00', 'a recipe', NULL, 'true'),
 ('recipe2', 2, '2024-01-01 10:00:00', '2024-01-01 10:00:00', 'a recipe 2', NULL, 'true'),
@@ -65,13 +70,17 @@ INSERT INTO recipe_components (recipe_id, pantry_item_id, created_at, quantity,
 (7, 3, '2024-01-01 10:00:00', 5, 1, 'step 3');
 
 INSERT INTO consumed (user_id, recipe_id, quantity, carbs, fats, proteins, alcohol, consumed_at, created_at, last_edited_at, notes) VALUES
-(1, 1, 1, 1, 1, 1, 1, '2024-01-01 10:00:00', '2024-01-01 10:00:00', '2024-01-01 10:00:00', 'notes'),
-(1, 2, 5, 7, 7, 7, 1, '2024-01-01 10:00:00', '2024-01-01 10:00:00', '2024-01-01 10:00:00', 'notes 2'),
-(1, 2, 8, 3, 2, 1, 0, '2024-01-01 10:00:00', '2024-01-01 10:00:00', '2024-01-01 10:00:00', 'notes 3'),
-(2, 2, 8, 3, 2, 1, 0, '2024-01-01 10:00:00', '2024-01-01 10:00:00', '2024-01-01 10:00:00', 'notes 4'),
-(3, 1, 1, 1, 1, 1, 1, '2024-01-01 10:00:00', '2024-01-01 10:00:00', '2024-01-01 10:00:00', 'notes'),
-(3, 2, 5, 7, 7, 7, 1, '2024-01-01 10:01:00', '2024-01-01 10:00:00', '2024-01-01 10:00:00', 'notes 2'),
-(3, 2, 8, 3, 2, 1, 0, '2024-01-01 10:10:00', '2024-01-01 10:00:00', '2024-01-01 10:00:00', 'notes 3'),
-(3, 2, 8, 3, 2, 1, 0, '2024-01-02 10:00:00', '2024-01-01 10:00:00', '2024-01-01 10:00:00', 'notes 3');
+(1, 1, 1, 1, 1, 1, 1, '2024-01-01 10:00:00+00', '2024-01-01 10:00:00', '2024-01-01 10:00:00', 'notes'),
+(1, 2, 5, 7, 7, 7, 1, '2024-01-01 10:00:00+00', '2024-01-01 10:00:00', '2024-01-01 10:00:00', 'notes 2'),
+(1, 2, 8, 3, 2, 1, 0, '2024-01-01 10:00:00+00', '2024-01-01 10:00:00', '2024-01-01 10:00:00', 'notes 3'),
+(2, 2, 8, 3, 2, 1, 0, '2024-01-01 10:00:00+00', '2024-01-01 10:00:00', '2024-01-01 10:00:00', 'notes 4'),
+(3, 1, 1, 1, 1, 1, 1, '2024-01-01 10:00:00+00', '2024-01-01 10:00:00', '2024-01-01 10:00:00', 'notes'),
+(3, 2, 5, 7, 7, 7, 1, '2024-01-01 10:01:00+00', '2024-01-01 10:00:00', '2024-01-01 10:00:00', 'notes 2'),
+(3, 2, 8, 3, 2, 1, 0, '2024-01-01 10:10:00+00', '2024-01-01 10:00:00', '2024-01-01 10:00:00', 'notes 3'),
+(3, 2, 8, 3, 2, 1, 0, '2024-01-02 10:00:00+00', '2024-01-01 10:00:00', '2024-01-01 10:00:00', 'notes 3');
+
+INSERT INTO goals (user_id, carbs, fats, proteins, alcohol, energy_kj, created_at, last_edited_at) VALUES
+(1, 200, 70, 150, 0, 8700, '2024-01-01 10:00:00', '2024-01-01 10:00:00'),
+(3, 250, 80, 120, 10, 9500, '2024-01-01 10:00:00', '2024-01-01 10:00:00');
 
 COMMIT;
\ No newline at end of file
@@ -16,6 +16,8 @@ type User struct {
 	CreatedAt time.Time `json:"created_at"`
 	Username  string    `json:"username"`
 	Email     string    `json:"email"`
+	Timezone  string    `json:"timezone"`
+	IsAdmin   bool      `json:"is_admin"`
 	Password  password  `json:"-"`
 	Version   int       `json:"-"`
 }
@@ -31,6 +33,20 @@ func (u *User) IsAnonymous() bool {
 	return u == AnonymousUser
 }
 
+// Location returns the user's timezone, users without a valid timezone are treated as UTC
+func (u *User) Location() *time.Location {
+	if u.Timezone == "" || u.Timezone == "Local" {
+		return time.UTC
+	}
+
+	loc, err := time.LoadLocation(u.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
 func (p *password) Set(plaintextPassword string) error {
 	hash, err := bcrypt.GenerateFromPassword([]byte(plaintextPassword), 12)
 	if err != nil {
@@ -69,12 +85,23 @@ func ValidatePasswordPlaintext(v *validator.Validator, password string) {
 	v.Check(len(password) <= 72, "password", "must not be more than 72 bytes long")
 }
 
+func ValidateTimezone(v *validator.Validator, timezone string) {
+	v.Check(timezone != "", "timezone", "must be provided")
+	// LoadLocation accepts "Local" as the server's timezone, which Postgres does not know
+	_, err := time.LoadLocation(timezone)
+	v.Check(err == nil && timezone != "Local", "timezone", "must be a valid IANA timezone")
+}
+
 func ValidateUser(v *validator.Validator, user *User) {
 	v.Check(user.Username != "", "name", "must be provided")
 	v.Check(len(user.Username) <= 500, "name", "must not be more than 500 bytes long")
 
 	ValidateEmail(v, user.Email)
 
+	if user.Timezone != "" {
+		ValidateTimezone(v, user.Timezone)
+	}
+
 	if user.Password.plaintext != nil {
 		ValidatePasswordPlaintext(v, *user.Password.plaintext)
 	}
@@ -102,16 +129,16 @@ type IUserModel interface {
 
 func (m UserModel) Insert(user *User) error {
 	query := `
-INSERT INTO users (username, email, password_hash)
-VALUES ($1, $2, $3)
-RETURNING id, created_at, version`
+INSERT INTO users (username, email, password_hash, timezone)
+VALUES ($1, $2, $3, COALESCE(NULLIF($4, ''), 'UTC'))
+RETURNING id, created_at, timezone, version`
 
-	args := []any{user.Username, user.Email, user.Password.hash}
+	args := []any{user.Username, user.Email, user.Password.hash, user.Timezone}
 
 	ctx, cancel := GetDefaultTimeoutContext()
 	defer cancel()
 
-	err := m.DB.QueryRow(ctx, query, args...).Scan(&user.ID, &user.CreatedAt, &user.Version)
+	err := m.DB.QueryRow(ctx, query, args...).Scan(&user.ID, &user.CreatedAt, &user.Timezone, &user.Version)
 	if err != nil {
 		switch {
 		case strings.HasPrefix(err.Error(), `ERROR: duplicate key value violates unique constraint "users_email_key"`):
@@ -138,7 +165,7 @@ func (m UserModel) Exists(id int) (bool, error) {
 
 func (m UserModel) GetByEmail(email string) (*User, error) {
 	query := `
-SELECT id, created_at, username, email, password_hash, version
+SELECT id, created_at, username, email, timezone, is_admin, password_hash, version
 FROM users
 WHERE email = $1`
 	var user User
@@ -149,6 +176,8 @@ WHERE email = $1`
 		&user.CreatedAt,
 		&user.Username,
 		&user.Email,
+		&user.Timezone,
+		&user.IsAdmin,
 		&user.Password.hash,
 		&user.Version,
 	)
@@ -187,7 +216,7 @@ func (m UserModel) Authenticate(email string, password string) (*User, error) {
 func (m UserModel) Update(user *User) error {
 	query := `
 UPDATE users
-SET username = $1, email = $2, password_hash = $3, version = version + 1
+SET username = $1, email = $2, password_hash = $3, timezone = COALESCE(NULLIF($6, ''), timezone), version = version + 1
 WHERE id = $4 AND version = $5
 RETURNING version`
 	args := []any{
@@ -196,6 +225,7 @@ RETURNING version`
 		user.Password.hash,
 		user.ID,
 		user.Version,
+		user.Timezone,
 	}
 	ctx, cancel := GetDefaultTimeoutContext()
 	defer cancel()
@@ -216,7 +246,7 @@ RETURNING version`
 func (m UserModel) GetForToken(tokenScope string, tokenPlaintext string) (*User, error) {
 
 	query := `
-	SELECT U.id, U.created_at, U.username, U.email, U.password_hash, U.version
+	SELECT U.id, U.created_at, U.username, U.email, U.timezone, U.is_admin, U.password_hash, U.version
 	FROM users U INNER JOIN tokens T ON U.id = T.user_id
 	WHERE T.hash = $1 AND T.scope = $2 AND T.expiry > $3;
 	`
@@ -233,6 +263,8 @@ func (m UserModel) GetForToken(tokenScope string, tokenPlaintext string) (*User,
 		&user.CreatedAt,
 		&user.Username,
 		&user.Email,
+		&user.Timezone,
+		&user.IsAdmin,
 		&user.Password.hash,
 		&user.Version,
 	)
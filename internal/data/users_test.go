@@ -136,6 +136,48 @@ func TestUserHelpers(t *testing.T) {
 				Version:   1,
 			},
 		},
+		{
+			name:  "valid user with timezone",
+			pass:  "password1",
+			valid: true,
+			user: User{
+				ID:        1,
+				CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
+				Username:  "john doe",
+				Email:     "John.Doe@gmail.com",
+				Timezone:  "Australia/Melbourne",
+				Password:  password{},
+				Version:   1,
+			},
+		},
+		{
+			name:  "invalid timezone",
+			pass:  "password1",
+			valid: false,
+			user: User{
+				ID:        1,
+				CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
+				Username:  "john doe",
+				Email:     "John.Doe@gmail.com",
+				Timezone:  "Mars/Olympus_Mons",
+				Password:  password{},
+				Version:   1,
+			},
+		},
+		{
+			name:  "invalid timezone local",
+			pass:  "password1",
+			valid: false,
+			user: User{
+				ID:        1,
+				CreatedAt: MustParse(timeFormat, "2024-01-01 10:00:00"),
+				Username:  "john doe",
+				Email:     "John.Doe@gmail.com",
+				Timezone:  "Local",
+				Password:  password{},
+				Version:   1,
+			},
+		},
 		{
 			name:  "invalid username empty",
 			pass:  "password1",
@@ -204,6 +246,44 @@ func TestUserHelpers(t *testing.T) {
 	}
 }
 
+func TestUserLocation(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		timezone string
+		want     string
+	}{
+		{
+			name:     "no timezone",
+			timezone: "",
+			want:     "UTC",
+		},
+		{
+			name:     "valid timezone",
+			timezone: "America/New_York",
+			want:     "America/New_York",
+		},
+		{
+			name:     "invalid timezone",
+			timezone: "Not/A_Zone",
+			want:     "UTC",
+		},
+		{
+			name:     "local timezone",
+			timezone: "Local",
+			want:     "UTC",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := User{Timezone: tt.timezone}
+
+			assert.Equal(t, user.Location().String(), tt.want)
+		})
+	}
+}
+
 func TestEmailHelpers(t *testing.T) {
 
 	tests := []struct {
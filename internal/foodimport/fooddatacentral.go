@@ -0,0 +1,128 @@
+package foodimport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// fdcNutrients maps FoodData Central nutrient numbers to amounts, FoodData Central records them per
+// 100 g in the units the amounts are tracked in
+var fdcNutrients = map[string]string{
+	"205": "carbs",
+	"204": "fats",
+	"203": "proteins",
+	"221": "alcohol",
+	"291": "fibre",
+	"269": "sugars",
+	"606": "saturated_fat",
+	"605": "trans_fat",
+	"601": "cholesterol",
+	"307": "sodium",
+	"306": "potassium",
+	"301": "calcium",
+	"303": "iron",
+	"304": "magnesium",
+	"309": "zinc",
+	"320": "vitamin_a",
+	"401": "vitamin_c",
+	"328": "vitamin_d",
+	"418": "vitamin_b12",
+	"417": "folate",
+}
+
+// fdcGenericBrand is the brand of foundation and survey foods, which have none
+const fdcGenericBrand = "USDA"
+
+type fdcFood struct {
+	Description   string `json:"description"`
+	BrandName     string `json:"brandName"`
+	BrandOwner    string `json:"brandOwner"`
+	GTINUPC       string `json:"gtinUpc"`
+	FoodNutrients []struct {
+		Nutrient struct {
+			Number string `json:"number"`
+		} `json:"nutrient"`
+		Amount float64 `json:"amount"`
+	} `json:"foodNutrients"`
+}
+
+// fdcReader reads a FoodData Central JSON download, an object holding one array of foods such as
+// BrandedFoods or FoundationFoods
+type fdcReader struct {
+	decoder *json.Decoder
+	started bool
+	row     int64
+}
+
+func newFoodDataCentralReader(r io.Reader) *fdcReader {
+	return &fdcReader{decoder: json.NewDecoder(r)}
+}
+
+// start skips to the first element of the array of foods
+func (r *fdcReader) start() error {
+	for {
+		token, err := r.decoder.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return errors.New("no array of foods found")
+			}
+			return err
+		}
+		if token == json.Delim('[') {
+			return nil
+		}
+	}
+}
+
+func (r *fdcReader) Read() (*Food, error) {
+	if !r.started {
+		err := r.start()
+		if err != nil {
+			return nil, err
+		}
+		r.started = true
+	}
+
+	if !r.decoder.More() {
+		return nil, io.EOF
+	}
+
+	r.row++
+
+	var food fdcFood
+	err := r.decoder.Decode(&food)
+	if err != nil {
+		// a value of the wrong type is skipped over, the next food can still be read
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, &RowError{Row: r.row, Err: fmt.Errorf("%s has the wrong type", typeErr.Field)}
+		}
+		return nil, err
+	}
+
+	brand := food.BrandName
+	if brand == "" {
+		brand = food.BrandOwner
+	}
+	if brand == "" {
+		brand = fdcGenericBrand
+	}
+
+	result := &Food{
+		Row:     r.row,
+		Code:    food.GTINUPC,
+		Name:    food.Description,
+		Brand:   brand,
+		Amounts: map[string]float64{},
+	}
+
+	for _, foodNutrient := range food.FoodNutrients {
+		if key, ok := fdcNutrients[foodNutrient.Nutrient.Number]; ok {
+			result.Amounts[key] = foodNutrient.Amount
+		}
+	}
+
+	return result, nil
+}
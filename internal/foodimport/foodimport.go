@@ -0,0 +1,146 @@
+// Package foodimport reads nutrition database dumps into consumables, Open Food Facts CSV and JSONL
+// exports and USDA FoodData Central JSON downloads. Dumps are read one food at a time so they never
+// have to fit in memory.
+package foodimport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tconnellan/macro-tracker-backend/internal/data"
+	"github.com/tconnellan/macro-tracker-backend/internal/validator"
+)
+
+var ErrUnknownFormat = errors.New("unknown dump format")
+
+type Format string
+
+const (
+	OpenFoodFactsCSV   Format = "off-csv"
+	OpenFoodFactsJSONL Format = "off-jsonl"
+	FoodDataCentral    Format = "fdc-json"
+)
+
+// Food is one food of a dump with its nutrition per 100 g. Amounts are keyed by carbs, fats, proteins,
+// alcohol and the keys of data.Nutrients, in grams for macronutrients and in each nutrient's unit
+// otherwise
+type Food struct {
+	// Row is the line of a CSV or JSONL dump or the position in a JSON download, counting from 1
+	Row     int64
+	Code    string
+	Name    string
+	Brand   string
+	Amounts map[string]float64
+}
+
+// RowError is a food of a dump that could not be read, reading continues with the next food
+type RowError struct {
+	Row int64
+	Err error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("row %d: %s", e.Row, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// Reader reads the foods of a dump
+type Reader interface {
+	// Read returns the next food and io.EOF after the last. A food that cannot be read returns a
+	// *RowError
+	Read() (*Food, error)
+}
+
+// NewReader reads a dump in format from r
+func NewReader(format Format, r io.Reader) (Reader, error) {
+	switch format {
+	case OpenFoodFactsCSV:
+		return newOpenFoodFactsCSVReader(r)
+	case OpenFoodFactsJSONL:
+		return newOpenFoodFactsJSONLReader(r), nil
+	case FoodDataCentral:
+		return newFoodDataCentralReader(r), nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// FormatOf guesses the format of a dump from its file extension, FoodData Central is downloaded as
+// .json and Open Food Facts as .csv or .jsonl
+func FormatOf(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv", ".tsv":
+		return OpenFoodFactsCSV, nil
+	case ".jsonl", ".ndjson":
+		return OpenFoodFactsJSONL, nil
+	case ".json":
+		return FoodDataCentral, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+var nonDigits = regexp.MustCompile(`\D`)
+
+// Consumable maps a food onto a consumable of the creator, sized per 100 g. The first of several
+// comma separated brands is kept, and a code that is not a valid GTIN is left out rather than
+// rejecting the food. The error gives the reason a food is rejected
+func (food *Food) Consumable(creatorID int64) (*data.Consumable, error) {
+	brand, _, _ := strings.Cut(food.Brand, ",")
+
+	consumable := &data.Consumable{
+		CreatorID: creatorID,
+		Name:      strings.Join(strings.Fields(food.Name), " "),
+		BrandName: strings.Join(strings.Fields(brand), " "),
+		Size:      100,
+		Units:     "g",
+		Macros: data.Macronutrients{
+			Carbs:    food.Amounts["carbs"],
+			Fats:     food.Amounts["fats"],
+			Proteins: food.Amounts["proteins"],
+			Alcohol:  food.Amounts["alcohol"],
+		},
+	}
+
+	if code := nonDigits.ReplaceAllString(food.Code, ""); data.ValidGTIN(code) {
+		consumable.Barcode = data.NormaliseGTIN(code)
+	}
+
+	for _, nutrient := range data.Nutrients {
+		if amount, ok := food.Amounts[nutrient.Key]; ok {
+			consumable.Nutrients.Set(nutrient.Key, amount)
+		}
+	}
+
+	v := validator.New()
+	data.ValidateConsumable(v, consumable)
+	if !v.Valid() {
+		return nil, validationError(v.Errors)
+	}
+
+	return consumable, nil
+}
+
+// validationError joins validation errors into one reason, ordered by field
+func validationError(errs map[string]string) error {
+	fields := make([]string, 0, len(errs))
+	for field := range errs {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	reasons := make([]string, 0, len(fields))
+	for _, field := range fields {
+		reasons = append(reasons, field+" "+errs[field])
+	}
+
+	return errors.New(strings.Join(reasons, "; "))
+}
@@ -0,0 +1,256 @@
+package foodimport
+
+import (
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/tconnellan/macro-tracker-backend/internal/assert"
+)
+
+// readAll reads every food of a dump, rows that cannot be read are returned separately
+func readAll(t *testing.T, reader Reader) ([]*Food, []*RowError) {
+	t.Helper()
+
+	foods := []*Food{}
+	rowErrs := []*RowError{}
+	for {
+		food, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return foods, rowErrs
+		}
+
+		var rowErr *RowError
+		switch {
+		case errors.As(err, &rowErr):
+			rowErrs = append(rowErrs, rowErr)
+		case err != nil:
+			t.Fatal(err)
+		default:
+			foods = append(foods, food)
+		}
+	}
+}
+
+func closeTo(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestOpenFoodFactsCSV(t *testing.T) {
+
+	dump := "code\tproduct_name\tbrands\tproteins_100g\tsodium_100g\tvitamin-d_100g\tenergy_100g\n" +
+		"3017620422003\tNutella\tFerrero,Nutella\t6.3\t0.0428\t\t2252\n" +
+		"0000000000000\tBad\tBrand\tlots\t\t\t\n" +
+		"\tNo code\tBrand\t1\t\t0.0000025\t\n"
+
+	reader, err := NewReader(OpenFoodFactsCSV, strings.NewReader(dump))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	foods, rowErrs := readAll(t, reader)
+
+	assert.Equal(t, len(foods), 2)
+	assert.Equal(t, len(rowErrs), 1)
+
+	assert.Equal(t, foods[0].Row, int64(2))
+	assert.Equal(t, foods[0].Code, "3017620422003")
+	assert.Equal(t, foods[0].Name, "Nutella")
+	assert.Equal(t, foods[0].Brand, "Ferrero,Nutella")
+	assert.Equal(t, foods[0].Amounts["proteins"], 6.3)
+	assert.Equal(t, closeTo(foods[0].Amounts["sodium"], 42.8), true)
+	_, ok := foods[0].Amounts["vitamin_d"]
+	assert.Equal(t, ok, false)
+
+	assert.Equal(t, rowErrs[0].Row, int64(3))
+	assert.StringContains(t, rowErrs[0].Error(), "proteins_100g is not a number")
+
+	assert.Equal(t, foods[1].Row, int64(4))
+	assert.Equal(t, closeTo(foods[1].Amounts["vitamin_d"], 2.5), true)
+}
+
+func TestOpenFoodFactsCSVCommaSeparated(t *testing.T) {
+
+	dump := "code,product_name,brands,fat_100g\n" +
+		"\"12345670\",\"Oats, rolled\",Uncle Tobys,8\n"
+
+	reader, err := NewReader(OpenFoodFactsCSV, strings.NewReader(dump))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	foods, rowErrs := readAll(t, reader)
+
+	assert.Equal(t, len(foods), 1)
+	assert.Equal(t, len(rowErrs), 0)
+	assert.Equal(t, foods[0].Name, "Oats, rolled")
+	assert.Equal(t, foods[0].Amounts["fats"], 8.0)
+}
+
+func TestOpenFoodFactsCSVMissingColumn(t *testing.T) {
+
+	_, err := NewReader(OpenFoodFactsCSV, strings.NewReader("code\tproduct_name\n"))
+	assert.StringContains(t, err.Error(), "no brands column")
+}
+
+func TestOpenFoodFactsJSONL(t *testing.T) {
+
+	dump := `{"code":"3017620422003","product_name":"Nutella","brands":"Ferrero","nutriments":{"proteins_100g":6.3,"alcohol_100g":"10","sugars_100g":null,"energy_100g":2252}}
+
+{"code":"1","product_name":"Bad",
+{"code":"2","product_name":"Soda","brands":"Brand","nutriments":{"carbohydrates_100g":"ten"}}
+`
+
+	reader, err := NewReader(OpenFoodFactsJSONL, strings.NewReader(dump))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	foods, rowErrs := readAll(t, reader)
+
+	assert.Equal(t, len(foods), 1)
+	assert.Equal(t, foods[0].Row, int64(1))
+	assert.Equal(t, foods[0].Amounts["proteins"], 6.3)
+	assert.Equal(t, closeTo(foods[0].Amounts["alcohol"], 7.89), true)
+	_, ok := foods[0].Amounts["sugars"]
+	assert.Equal(t, ok, false)
+
+	assert.Equal(t, len(rowErrs), 2)
+	assert.Equal(t, rowErrs[0].Row, int64(3))
+	assert.Equal(t, rowErrs[1].Row, int64(4))
+	assert.StringContains(t, rowErrs[1].Error(), "carbohydrates_100g is not a number")
+}
+
+func TestFoodDataCentral(t *testing.T) {
+
+	dump := `{"BrandedFoods": [
+		{"description": "PEANUT BUTTER", "brandOwner": "Acme", "gtinUpc": "041220576920", "foodNutrients": [
+			{"nutrient": {"number": "203"}, "amount": 25},
+			{"nutrient": {"number": "307"}, "amount": 400},
+			{"nutrient": {"number": "208"}, "amount": 2450}
+		]},
+		{"description": "BROKEN", "foodNutrients": "none"},
+		{"description": "Apples, raw", "foodNutrients": [{"nutrient": {"number": "205"}, "amount": 13.8}]}
+	]}`
+
+	reader, err := NewReader(FoodDataCentral, strings.NewReader(dump))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	foods, rowErrs := readAll(t, reader)
+
+	assert.Equal(t, len(foods), 2)
+	assert.Equal(t, len(rowErrs), 1)
+
+	assert.Equal(t, foods[0].Row, int64(1))
+	assert.Equal(t, foods[0].Code, "041220576920")
+	assert.Equal(t, foods[0].Brand, "Acme")
+	assert.Equal(t, foods[0].Amounts["proteins"], 25.0)
+	assert.Equal(t, foods[0].Amounts["sodium"], 400.0)
+	assert.Equal(t, len(foods[0].Amounts), 2)
+
+	assert.Equal(t, rowErrs[0].Row, int64(2))
+
+	assert.Equal(t, foods[1].Row, int64(3))
+	assert.Equal(t, foods[1].Brand, fdcGenericBrand)
+	assert.Equal(t, foods[1].Amounts["carbs"], 13.8)
+}
+
+func TestFormatOf(t *testing.T) {
+
+	tests := []struct {
+		path         string
+		expectFormat Format
+		expectErr    error
+	}{
+		{path: "en.openfoodfacts.org.products.csv", expectFormat: OpenFoodFactsCSV},
+		{path: "openfoodfacts-products.JSONL", expectFormat: OpenFoodFactsJSONL},
+		{path: "FoodData_Central_branded_food_json.json", expectFormat: FoodDataCentral},
+		{path: "foods.xml", expectErr: ErrUnknownFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			format, err := FormatOf(tt.path)
+			assert.Equal(t, format, tt.expectFormat)
+			assert.ExpectError(t, err, tt.expectErr)
+		})
+	}
+}
+
+func TestFoodConsumable(t *testing.T) {
+
+	tests := []struct {
+		name          string
+		food          Food
+		expectBarcode string
+		expectBrand   string
+		expectReason  string
+	}{
+		{
+			name: "valid",
+			food: Food{
+				Code:    "3017620422003",
+				Name:    "  Nutella \t hazelnut spread",
+				Brand:   "Ferrero, Nutella",
+				Amounts: map[string]float64{"carbs": 57.5, "fats": 30.9, "proteins": 6.3, "sodium": 42.8},
+			},
+			expectBarcode: "03017620422003",
+			expectBrand:   "Ferrero",
+		},
+		{
+			name: "invalid barcode dropped",
+			food: Food{
+				Code:    "3017620422004",
+				Name:    "Nutella",
+				Brand:   "Ferrero",
+				Amounts: map[string]float64{"fats": 30.9},
+			},
+			expectBarcode: "",
+			expectBrand:   "Ferrero",
+		},
+		{
+			name: "no name",
+			food: Food{
+				Code:    "3017620422003",
+				Brand:   "Ferrero",
+				Amounts: map[string]float64{},
+			},
+			expectReason: "name must be provided",
+		},
+		{
+			name: "negative nutrient",
+			food: Food{
+				Name:    "Salt",
+				Brand:   "Saxa",
+				Amounts: map[string]float64{"sodium": -1},
+			},
+			expectReason: "sodium must be non-negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consumable, err := tt.food.Consumable(3)
+
+			if tt.expectReason != "" {
+				assert.StringContains(t, err.Error(), tt.expectReason)
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.Equal(t, consumable.CreatorID, int64(3))
+			assert.Equal(t, consumable.Barcode, tt.expectBarcode)
+			assert.Equal(t, consumable.BrandName, tt.expectBrand)
+			assert.Equal(t, consumable.Size, 100.0)
+			assert.Equal(t, consumable.Units, "g")
+			assert.Equal(t, consumable.Macros.Fats, tt.food.Amounts["fats"])
+
+			sodium, _ := consumable.Nutrients.Get("sodium")
+			assert.Equal(t, sodium, tt.food.Amounts["sodium"])
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package foodimport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type offField struct {
+	key string
+	// factor converts Open Food Facts' grams per 100 g to the units of the amount
+	factor float64
+}
+
+// alcoholGramsPerPercent converts alcohol, which Open Food Facts records as % vol, to grams per 100 g
+// taking 100 g as 100 ml
+const alcoholGramsPerPercent = 0.789
+
+// offFields maps the per 100 g nutriments of Open Food Facts, all recorded in grams
+var offFields = map[string]offField{
+	"carbohydrates_100g": {"carbs", 1},
+	"fat_100g":           {"fats", 1},
+	"proteins_100g":      {"proteins", 1},
+	"alcohol_100g":       {"alcohol", alcoholGramsPerPercent},
+	"fiber_100g":         {"fibre", 1},
+	"sugars_100g":        {"sugars", 1},
+	"saturated-fat_100g": {"saturated_fat", 1},
+	"trans-fat_100g":     {"trans_fat", 1},
+	"cholesterol_100g":   {"cholesterol", 1e3},
+	"sodium_100g":        {"sodium", 1e3},
+	"potassium_100g":     {"potassium", 1e3},
+	"calcium_100g":       {"calcium", 1e3},
+	"iron_100g":          {"iron", 1e3},
+	"magnesium_100g":     {"magnesium", 1e3},
+	"zinc_100g":          {"zinc", 1e3},
+	"vitamin-a_100g":     {"vitamin_a", 1e6},
+	"vitamin-c_100g":     {"vitamin_c", 1e3},
+	"vitamin-d_100g":     {"vitamin_d", 1e6},
+	"vitamin-b12_100g":   {"vitamin_b12", 1e6},
+	"folates_100g":       {"folate", 1e6},
+}
+
+// setOFFAmount records a nutriment when it is one that is tracked and has a value
+func setOFFAmount(amounts map[string]float64, field string, value string) error {
+	mapped, ok := offFields[field]
+	if !ok || strings.TrimSpace(value) == "" {
+		return nil
+	}
+
+	amount, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return fmt.Errorf("%s is not a number", field)
+	}
+
+	amounts[mapped.key] = amount * mapped.factor
+	return nil
+}
+
+// offCSVReader reads the Open Food Facts CSV export, which is tab separated despite its name. A comma
+// separated file is read too
+type offCSVReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func newOpenFoodFactsCSVReader(r io.Reader) (*offCSVReader, error) {
+	buffered := bufio.NewReaderSize(r, 1<<16)
+
+	comma := ','
+	header, _ := buffered.Peek(1 << 16)
+	if line, _, _ := bytes.Cut(header, []byte("\n")); bytes.ContainsRune(line, '\t') {
+		comma = '\t'
+	}
+
+	reader := csv.NewReader(buffered)
+	reader.Comma = comma
+	reader.LazyQuotes = true
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	names, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range names {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, required := range []string{"code", "product_name", "brands"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("header has no %s column", required)
+		}
+	}
+
+	return &offCSVReader{reader: reader, columns: columns}, nil
+}
+
+func (r *offCSVReader) Read() (*Food, error) {
+	record, err := r.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, &RowError{Row: int64(parseErr.StartLine), Err: parseErr.Err}
+		}
+		return nil, err
+	}
+
+	line, _ := r.reader.FieldPos(0)
+
+	field := func(name string) string {
+		i, ok := r.columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return record[i]
+	}
+
+	food := &Food{
+		Row:     int64(line),
+		Code:    field("code"),
+		Name:    field("product_name"),
+		Brand:   field("brands"),
+		Amounts: map[string]float64{},
+	}
+
+	for name := range offFields {
+		err = setOFFAmount(food.Amounts, name, field(name))
+		if err != nil {
+			return nil, &RowError{Row: food.Row, Err: err}
+		}
+	}
+
+	return food, nil
+}
+
+// maxJSONLLine bounds one product of a JSONL export, products carry every field Open Food Facts has
+const maxJSONLLine = 16 << 20
+
+// offJSONLReader reads the Open Food Facts JSONL export, one product object per line
+type offJSONLReader struct {
+	scanner *bufio.Scanner
+	line    int64
+}
+
+type offProduct struct {
+	Code        string                     `json:"code"`
+	ProductName string                     `json:"product_name"`
+	Brands      string                     `json:"brands"`
+	Nutriments  map[string]json.RawMessage `json:"nutriments"`
+}
+
+func newOpenFoodFactsJSONLReader(r io.Reader) *offJSONLReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 1<<16), maxJSONLLine)
+	return &offJSONLReader{scanner: scanner}
+}
+
+func (r *offJSONLReader) Read() (*Food, error) {
+	for r.scanner.Scan() {
+		r.line++
+
+		line := bytes.TrimSpace(r.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var product offProduct
+		err := json.Unmarshal(line, &product)
+		if err != nil {
+			return nil, &RowError{Row: r.line, Err: err}
+		}
+
+		food := &Food{
+			Row:     r.line,
+			Code:    product.Code,
+			Name:    product.ProductName,
+			Brand:   product.Brands,
+			Amounts: map[string]float64{},
+		}
+
+		for name, raw := range product.Nutriments {
+			// nutriments are numbers, but some products record them as strings or null
+			value := strings.Trim(string(raw), `"`)
+			if value == "null" {
+				continue
+			}
+			err = setOFFAmount(food.Amounts, name, value)
+			if err != nil {
+				return nil, &RowError{Row: r.line, Err: err}
+			}
+		}
+
+		return food, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
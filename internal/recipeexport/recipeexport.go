@@ -0,0 +1,265 @@
+// Package recipeexport renders recipes for use outside the app, as a Markdown or plain-text recipe card
+// or as a schema.org Recipe JSON-LD document.
+package recipeexport
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/tconnellan/macro-tracker-backend/internal/data"
+)
+
+const kjPerKcal = 4.184
+
+// Card is a recipe laid out in step order with each ingredient's amount written out
+type Card struct {
+	Name        string
+	Notes       string
+	Servings    int64
+	Ingredients []string
+	Steps       []string
+	// Nutrition is nil when it cannot be calculated
+	Nutrition *data.RecipeNutrition
+}
+
+// NewCard lays out a recipe, nutrition is nil when the recipe's nutrition cannot be calculated
+func NewCard(fullRecipe *data.FullRecipe, nutrition *data.RecipeNutrition) *Card {
+	card := &Card{
+		Name:        fullRecipe.Recipe.Name,
+		Notes:       fullRecipe.Recipe.Notes,
+		Servings:    fullRecipe.Recipe.Servings,
+		Ingredients: []string{},
+		Steps:       []string{},
+		Nutrition:   nutrition,
+	}
+
+	order := make([]int, len(fullRecipe.RecipeComponents))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return int(fullRecipe.RecipeComponents[a].StepNo - fullRecipe.RecipeComponents[b].StepNo)
+	})
+
+	for _, i := range order {
+		component := fullRecipe.RecipeComponents[i]
+		card.Ingredients = append(card.Ingredients, ingredientLine(fullRecipe, i))
+		if description := strings.TrimSpace(component.StepDescription); description != "" {
+			card.Steps = append(card.Steps, description)
+		}
+	}
+
+	return card
+}
+
+// ingredientLine writes out the amount and name of step i, such as "250 g Minced Beef" or
+// "2 × 1 cup (250 ml) Milk"
+func ingredientLine(fullRecipe *data.FullRecipe, i int) string {
+	component := fullRecipe.RecipeComponents[i]
+	quantity := formatAmount(component.Quantity)
+
+	if component.SubRecipeID != 0 {
+		name := "sub-recipe"
+		if subRecipe := fullRecipe.SubRecipe(component.SubRecipeID); subRecipe != nil {
+			name = subRecipe.Recipe.Name
+		}
+		if component.Units == "" {
+			return fmt.Sprintf("%s of %s", quantity, name)
+		}
+		return fmt.Sprintf("%s %s %s", quantity, component.Units, name)
+	}
+
+	var name string
+	var units data.MeasurementUnit
+	if i < len(fullRecipe.PantryItems) {
+		name = fullRecipe.PantryItems[i].Name
+	}
+	if i < len(fullRecipe.Consumables) {
+		units = fullRecipe.Consumables[i].Units
+	}
+
+	if component.ServingID != 0 {
+		if servingSize := fullRecipe.ServingSize(component.ServingID); servingSize != nil {
+			return fmt.Sprintf("%s × %s (%s %s) %s", quantity, servingSize.Name, formatAmount(servingSize.Amount), servingSize.Units, name)
+		}
+	}
+
+	if component.Units != "" {
+		units = component.Units
+	}
+	return fmt.Sprintf("%s %s %s", quantity, units, name)
+}
+
+// formatAmount rounds to two decimal places and drops trailing zeros
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(math.Round(amount*100)/100, 'f', -1, 64)
+}
+
+// nutritionLines are the lines of the nutrition summary, per serving when the recipe has servings
+func (card *Card) nutritionLines() []string {
+	facts := card.Nutrition.Total
+	if card.Servings > 0 {
+		facts = card.Nutrition.PerServing
+	}
+
+	lines := []string{
+		fmt.Sprintf("Energy: %s kJ (%s kcal)", formatAmount(math.Round(facts.KJ)), formatAmount(math.Round(facts.KJ/kjPerKcal))),
+		fmt.Sprintf("Protein: %s g", formatAmount(facts.Macros.Proteins)),
+		fmt.Sprintf("Fat: %s g", formatAmount(facts.Macros.Fats)),
+		fmt.Sprintf("Carbohydrate: %s g", formatAmount(facts.Macros.Carbs)),
+	}
+	if facts.Macros.Alcohol > 0 {
+		lines = append(lines, fmt.Sprintf("Alcohol: %s g", formatAmount(facts.Macros.Alcohol)))
+	}
+	for i, nutrient := range data.Nutrients {
+		if facts.Nutrients[i] > 0 {
+			lines = append(lines, fmt.Sprintf("%s: %s %s", nutrient.Name, formatAmount(facts.Nutrients[i]), nutrient.Unit))
+		}
+	}
+
+	return lines
+}
+
+func (card *Card) nutritionHeading() string {
+	if card.Servings > 0 {
+		return "Nutrition per serving"
+	}
+	return "Nutrition"
+}
+
+// Markdown renders the card as a Markdown document
+func (card *Card) Markdown() []byte {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n", card.Name)
+	if card.Servings > 0 {
+		fmt.Fprintf(&b, "\nServes %d\n", card.Servings)
+	}
+	if card.Notes != "" {
+		fmt.Fprintf(&b, "\n%s\n", card.Notes)
+	}
+
+	b.WriteString("\n## Ingredients\n\n")
+	for _, ingredient := range card.Ingredients {
+		fmt.Fprintf(&b, "- %s\n", ingredient)
+	}
+
+	if len(card.Steps) > 0 {
+		b.WriteString("\n## Method\n\n")
+		for i, step := range card.Steps {
+			fmt.Fprintf(&b, "%d. %s\n", i+1, step)
+		}
+	}
+
+	if card.Nutrition != nil {
+		fmt.Fprintf(&b, "\n## %s\n\n", card.nutritionHeading())
+		for _, line := range card.nutritionLines() {
+			fmt.Fprintf(&b, "- %s\n", line)
+		}
+	}
+
+	return []byte(b.String())
+}
+
+// Text renders the card as plain text for printing
+func (card *Card) Text() []byte {
+	var b strings.Builder
+
+	b.WriteString(strings.ToUpper(card.Name) + "\n")
+	if card.Servings > 0 {
+		fmt.Fprintf(&b, "Serves %d\n", card.Servings)
+	}
+	if card.Notes != "" {
+		fmt.Fprintf(&b, "\n%s\n", card.Notes)
+	}
+
+	b.WriteString("\nINGREDIENTS\n")
+	for _, ingredient := range card.Ingredients {
+		fmt.Fprintf(&b, "  %s\n", ingredient)
+	}
+
+	if len(card.Steps) > 0 {
+		b.WriteString("\nMETHOD\n")
+		for i, step := range card.Steps {
+			fmt.Fprintf(&b, "  %d. %s\n", i+1, step)
+		}
+	}
+
+	if card.Nutrition != nil {
+		fmt.Fprintf(&b, "\n%s\n", strings.ToUpper(card.nutritionHeading()))
+		for _, line := range card.nutritionLines() {
+			fmt.Fprintf(&b, "  %s\n", line)
+		}
+	}
+
+	return []byte(b.String())
+}
+
+type howToStep struct {
+	Type     string `json:"@type"`
+	Position int    `json:"position"`
+	Text     string `json:"text"`
+}
+
+// jsonLDNutrientProperties maps nutrient keys to the properties of schema.org NutritionInformation
+var jsonLDNutrientProperties = map[string]string{
+	"fibre":         "fiberContent",
+	"sugars":        "sugarContent",
+	"saturated_fat": "saturatedFatContent",
+	"trans_fat":     "transFatContent",
+	"cholesterol":   "cholesterolContent",
+	"sodium":        "sodiumContent",
+}
+
+// JSONLD renders the card as a schema.org Recipe, NutritionInformation is per serving as schema.org
+// defines it, the whole recipe being one serving when it has no servings
+func (card *Card) JSONLD() ([]byte, error) {
+	document := map[string]any{
+		"@context":         "https://schema.org",
+		"@type":            "Recipe",
+		"name":             card.Name,
+		"recipeIngredient": card.Ingredients,
+	}
+
+	if card.Notes != "" {
+		document["description"] = card.Notes
+	}
+	if card.Servings > 0 {
+		document["recipeYield"] = fmt.Sprintf("%d servings", card.Servings)
+	}
+
+	instructions := []howToStep{}
+	for i, step := range card.Steps {
+		instructions = append(instructions, howToStep{Type: "HowToStep", Position: i + 1, Text: step})
+	}
+	document["recipeInstructions"] = instructions
+
+	if card.Nutrition != nil {
+		facts := card.Nutrition.PerServing
+
+		nutrition := map[string]any{
+			"@type":               "NutritionInformation",
+			"calories":            fmt.Sprintf("%s kcal", formatAmount(math.Round(facts.KJ/kjPerKcal))),
+			"proteinContent":      fmt.Sprintf("%s g", formatAmount(facts.Macros.Proteins)),
+			"fatContent":          fmt.Sprintf("%s g", formatAmount(facts.Macros.Fats)),
+			"carbohydrateContent": fmt.Sprintf("%s g", formatAmount(facts.Macros.Carbs)),
+		}
+		if card.Servings > 0 {
+			nutrition["servingSize"] = "1 serving"
+		}
+		for i, nutrient := range data.Nutrients {
+			property, ok := jsonLDNutrientProperties[nutrient.Key]
+			if ok && facts.Nutrients[i] > 0 {
+				nutrition[property] = fmt.Sprintf("%s %s", formatAmount(facts.Nutrients[i]), nutrient.Unit)
+			}
+		}
+
+		document["nutrition"] = nutrition
+	}
+
+	return json.MarshalIndent(document, "", "\t")
+}
@@ -0,0 +1,140 @@
+package recipeexport
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/tconnellan/macro-tracker-backend/internal/assert"
+	"github.com/tconnellan/macro-tracker-backend/internal/data"
+)
+
+func testFullRecipe() *data.FullRecipe {
+	return &data.FullRecipe{
+		Recipe: data.Recipe{ID: 1, Name: "Lasagne", Notes: "a family favourite", Servings: 2},
+		RecipeComponents: []*data.RecipeComponent{
+			{ID: 2, PantryItemID: 2, Quantity: 2, ServingID: 1, StepNo: 2, StepDescription: "pour the milk"},
+			{ID: 1, PantryItemID: 1, Quantity: 500, StepNo: 1, StepDescription: "brown the mince"},
+			{ID: 3, SubRecipeID: 5, Quantity: 0.5, StepNo: 3},
+		},
+		PantryItems: []*data.PantryItem{
+			{ID: 2, Name: "Milk"},
+			{ID: 1, Name: "Minced Beef"},
+			{},
+		},
+		Consumables: []*data.Consumable{
+			{ID: 2, Name: "Milk", Size: 100, Units: "ml", Macros: data.Macronutrients{Carbs: 5, Fats: 3, Proteins: 3}},
+			{ID: 1, Name: "Mince", Size: 100, Units: "g", Macros: data.Macronutrients{Fats: 10, Proteins: 20}},
+			{},
+		},
+		ServingSizes: []*data.ServingSize{
+			{ID: 1, ConsumableID: 2, Name: "1 cup", Amount: 250, Units: "ml"},
+		},
+		SubRecipes: []*data.FullRecipe{
+			{Recipe: data.Recipe{ID: 5, Name: "Bechamel"}},
+		},
+	}
+}
+
+func TestNewCard(t *testing.T) {
+
+	card := NewCard(testFullRecipe(), nil)
+
+	assert.Equal(t, len(card.Ingredients), 3)
+	assert.Equal(t, card.Ingredients[0], "500 g Minced Beef")
+	assert.Equal(t, card.Ingredients[1], "2 × 1 cup (250 ml) Milk")
+	assert.Equal(t, card.Ingredients[2], "0.5 of Bechamel")
+
+	assert.Equal(t, len(card.Steps), 2)
+	assert.Equal(t, card.Steps[0], "brown the mince")
+	assert.Equal(t, card.Steps[1], "pour the milk")
+}
+
+func TestCardFormats(t *testing.T) {
+
+	fullRecipe := testFullRecipe()
+	nutrition := &data.RecipeNutrition{
+		Total:      data.NutritionFacts{Macros: data.Macronutrients{Carbs: 25, Fats: 65, Proteins: 115}, KJ: 4789},
+		PerServing: data.NutritionFacts{Macros: data.Macronutrients{Carbs: 12.5, Fats: 32.5, Proteins: 57.5}, KJ: 2394.5},
+	}
+	nutrition.PerServing.Nutrients.Set("sodium", 120)
+
+	tests := []struct {
+		name      string
+		nutrition *data.RecipeNutrition
+		render    func(*Card) []byte
+		expect    []string
+		expectNot []string
+	}{
+		{
+			name:      "markdown",
+			nutrition: nutrition,
+			render:    (*Card).Markdown,
+			expect:    []string{"# Lasagne\n", "Serves 2", "- 500 g Minced Beef\n", "1. brown the mince\n", "## Nutrition per serving", "- Protein: 57.5 g\n", "- Sodium: 120 mg\n"},
+		},
+		{
+			name:      "markdown without nutrition",
+			nutrition: nil,
+			render:    (*Card).Markdown,
+			expect:    []string{"## Ingredients"},
+			expectNot: []string{"Nutrition"},
+		},
+		{
+			name:      "text",
+			nutrition: nutrition,
+			render:    (*Card).Text,
+			expect:    []string{"LASAGNE\n", "INGREDIENTS\n", "  2 × 1 cup (250 ml) Milk\n", "  2. pour the milk\n", "NUTRITION PER SERVING\n", "  Energy: 2395 kJ (572 kcal)\n"},
+			expectNot: []string{"#"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			rendered := string(tt.render(NewCard(fullRecipe, tt.nutrition)))
+
+			for _, want := range tt.expect {
+				assert.Equal(t, strings.Contains(rendered, want), true)
+			}
+			for _, unwanted := range tt.expectNot {
+				assert.Equal(t, strings.Contains(rendered, unwanted), false)
+			}
+		})
+	}
+
+	t.Run("jsonld", func(t *testing.T) {
+
+		document, err := NewCard(fullRecipe, nutrition).JSONLD()
+		assert.NilError(t, err)
+
+		var recipe struct {
+			Context            string   `json:"@context"`
+			Type               string   `json:"@type"`
+			Name               string   `json:"name"`
+			RecipeYield        string   `json:"recipeYield"`
+			RecipeIngredient   []string `json:"recipeIngredient"`
+			RecipeInstructions []struct {
+				Type     string `json:"@type"`
+				Position int    `json:"position"`
+				Text     string `json:"text"`
+			} `json:"recipeInstructions"`
+			Nutrition map[string]string `json:"nutrition"`
+		}
+		err = json.Unmarshal(document, &recipe)
+		assert.NilError(t, err)
+
+		assert.Equal(t, recipe.Context, "https://schema.org")
+		assert.Equal(t, recipe.Type, "Recipe")
+		assert.Equal(t, recipe.Name, "Lasagne")
+		assert.Equal(t, recipe.RecipeYield, "2 servings")
+		assert.Equal(t, len(recipe.RecipeIngredient), 3)
+		assert.Equal(t, len(recipe.RecipeInstructions), 2)
+		assert.Equal(t, recipe.RecipeInstructions[1].Type, "HowToStep")
+		assert.Equal(t, recipe.RecipeInstructions[1].Position, 2)
+		assert.Equal(t, recipe.Nutrition["@type"], "NutritionInformation")
+		assert.Equal(t, recipe.Nutrition["calories"], "572 kcal")
+		assert.Equal(t, recipe.Nutrition["proteinContent"], "57.5 g")
+		assert.Equal(t, recipe.Nutrition["sodiumContent"], "120 mg")
+		assert.Equal(t, recipe.Nutrition["servingSize"], "1 serving")
+	})
+}
@@ -0,0 +1,217 @@
+package recipeimport
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/tconnellan/macro-tracker-backend/internal/conversion"
+)
+
+// Ingredient is a recipeIngredient line split into an amount and the name of the food
+type Ingredient struct {
+	Text string
+	// Quantity is zero when the line gives no amount, such as "salt to taste"
+	Quantity float64
+	// Units is empty when the amount is in a unit that cannot be recorded, such as a pinch
+	Units conversion.Unit
+	Name  string
+}
+
+type unitConversion struct {
+	unit   conversion.Unit
+	factor float64
+}
+
+// units maps the ways recipes write units to the units amounts are recorded in. Cups and spoons are
+// metric measures
+var units = map[string]unitConversion{
+	"g":           {conversion.Grams, 1},
+	"gr":          {conversion.Grams, 1},
+	"gram":        {conversion.Grams, 1},
+	"grams":       {conversion.Grams, 1},
+	"kg":          {conversion.Grams, 1000},
+	"kilogram":    {conversion.Grams, 1000},
+	"kilograms":   {conversion.Grams, 1000},
+	"mg":          {conversion.Grams, 0.001},
+	"ml":          {conversion.Millilitres, 1},
+	"millilitre":  {conversion.Millilitres, 1},
+	"millilitres": {conversion.Millilitres, 1},
+	"milliliter":  {conversion.Millilitres, 1},
+	"milliliters": {conversion.Millilitres, 1},
+	"cl":          {conversion.Millilitres, 10},
+	"dl":          {conversion.Millilitres, 100},
+	"l":           {conversion.Millilitres, 1000},
+	"litre":       {conversion.Millilitres, 1000},
+	"litres":      {conversion.Millilitres, 1000},
+	"liter":       {conversion.Millilitres, 1000},
+	"liters":      {conversion.Millilitres, 1000},
+	"cup":         {conversion.Millilitres, 250},
+	"cups":        {conversion.Millilitres, 250},
+	"tbsp":        {conversion.Millilitres, 15},
+	"tbs":         {conversion.Millilitres, 15},
+	"tablespoon":  {conversion.Millilitres, 15},
+	"tablespoons": {conversion.Millilitres, 15},
+	"tsp":         {conversion.Millilitres, 5},
+	"teaspoon":    {conversion.Millilitres, 5},
+	"teaspoons":   {conversion.Millilitres, 5},
+	"oz":          {conversion.Ounces, 1},
+	"ounce":       {conversion.Ounces, 1},
+	"ounces":      {conversion.Ounces, 1},
+	"lb":          {conversion.Pounds, 1},
+	"lbs":         {conversion.Pounds, 1},
+	"pound":       {conversion.Pounds, 1},
+	"pounds":      {conversion.Pounds, 1},
+}
+
+// unmeasured are units with no recordable amount, the line keeps its quantity but has no units
+var unmeasured = map[string]bool{
+	"pinch": true, "pinches": true, "dash": true, "dashes": true, "handful": true, "handfuls": true,
+	"bunch": true, "bunches": true, "sprig": true, "sprigs": true, "clove": true, "cloves": true,
+	"can": true, "cans": true, "tin": true, "tins": true, "packet": true, "packets": true,
+}
+
+var vulgarFractions = strings.NewReplacer(
+	"½", " 1/2", "⅓", " 1/3", "⅔", " 2/3", "¼", " 1/4", "¾", " 3/4",
+	"⅕", " 1/5", "⅛", " 1/8", "⅜", " 3/8", "⅝", " 5/8", "⅞", " 7/8", "⁄", "/",
+)
+
+// leadingAmount matches a whole, decimal, fraction or mixed number, optionally the start of a range
+var leadingAmount = regexp.MustCompile(`^(\d+\s+\d+/\d+|\d+/\d+|\d+(?:[.,]\d+)?)(?:\s*(?:-|–|to)\s*(?:\d+\s+\d+/\d+|\d+/\d+|\d+(?:[.,]\d+)?))?`)
+
+var parenthesised = regexp.MustCompile(`\([^)]*\)`)
+
+// ParseIngredient splits a line such as "1 1/2 cups plain flour, sifted" into its amount in recordable
+// units and the name of the food. A range such as "2-3 carrots" takes its lower amount, and an amount
+// without units counts whole items
+func ParseIngredient(line string) Ingredient {
+	ingredient := Ingredient{Text: line}
+
+	rest := strings.TrimSpace(vulgarFractions.Replace(line))
+
+	if match := leadingAmount.FindStringSubmatch(rest); match != nil {
+		ingredient.Quantity = parseAmount(match[1])
+		rest = strings.TrimSpace(rest[len(match[0]):])
+	}
+
+	if ingredient.Quantity > 0 {
+		word, remainder, _ := strings.Cut(rest, " ")
+		key := strings.TrimSuffix(strings.ToLower(word), ".")
+		switch {
+		case units[key].unit != "":
+			ingredient.Quantity *= units[key].factor
+			ingredient.Units = units[key].unit
+			rest = remainder
+		case unmeasured[key]:
+			rest = remainder
+		default:
+			ingredient.Units = conversion.Units
+		}
+	}
+
+	ingredient.Name = ingredientName(rest)
+
+	return ingredient
+}
+
+// Measured reports whether the line gives an amount that can be recorded, an unmeasured line such as
+// "2 cloves garlic" or "salt to taste" needs its amount entered before the recipe is saved
+func (ingredient Ingredient) Measured() bool {
+	return ingredient.Quantity > 0 && ingredient.Units != ""
+}
+
+// ingredientName drops preparation notes after a comma, parenthesised asides and a leading "of"
+func ingredientName(text string) string {
+	name, _, _ := strings.Cut(text, ",")
+	name = parenthesised.ReplaceAllString(name, " ")
+	name = strings.Join(strings.Fields(name), " ")
+	if strings.HasPrefix(strings.ToLower(name), "of ") {
+		name = name[3:]
+	}
+	return name
+}
+
+// parseAmount reads a whole, decimal, fraction or mixed number such as "1 1/2"
+func parseAmount(text string) float64 {
+	var total float64
+	for _, part := range strings.Fields(text) {
+		numerator, denominator, isFraction := strings.Cut(part, "/")
+		if !isFraction {
+			value, err := strconv.ParseFloat(strings.Replace(part, ",", ".", 1), 64)
+			if err != nil {
+				return 0
+			}
+			total += value
+			continue
+		}
+
+		n, errN := strconv.ParseFloat(numerator, 64)
+		d, errD := strconv.ParseFloat(denominator, 64)
+		if errN != nil || errD != nil || d == 0 {
+			return 0
+		}
+		total += n / d
+	}
+
+	return total
+}
+
+// MatchName returns the index of the candidate that best matches an ingredient name, or -1 when none
+// do. An equal name is best, then a candidate containing every word of the name, then a candidate whose
+// words are all in the name. Words are compared ignoring case and plurals
+func MatchName(name string, candidates []string) int {
+	nameWords := matchWords(name)
+	if len(nameWords) == 0 {
+		return -1
+	}
+
+	best, bestScore := -1, 0
+	for i, candidate := range candidates {
+		candidateWords := matchWords(candidate)
+		if len(candidateWords) == 0 {
+			continue
+		}
+
+		score := 0
+		switch {
+		case strings.Join(nameWords, " ") == strings.Join(candidateWords, " "):
+			score = 3
+		case containsWords(candidateWords, nameWords):
+			score = 2
+		case containsWords(nameWords, candidateWords):
+			score = 1
+		}
+
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+
+	return best
+}
+
+func matchWords(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r > 127)
+	})
+	for i, word := range words {
+		switch {
+		case len(word) > 4 && strings.HasSuffix(word, "oes"):
+			words[i] = strings.TrimSuffix(word, "es")
+		case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+			words[i] = strings.TrimSuffix(word, "s")
+		}
+	}
+	return words
+}
+
+// containsWords reports whether every word of want is in words
+func containsWords(words []string, want []string) bool {
+	for _, w := range want {
+		if !slices.Contains(words, w) {
+			return false
+		}
+	}
+	return true
+}
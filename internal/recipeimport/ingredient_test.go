@@ -0,0 +1,149 @@
+package recipeimport
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tconnellan/macro-tracker-backend/internal/assert"
+	"github.com/tconnellan/macro-tracker-backend/internal/conversion"
+)
+
+func TestParseIngredient(t *testing.T) {
+
+	tests := []struct {
+		name           string
+		line           string
+		expectQuantity float64
+		expectUnits    conversion.Unit
+		expectName     string
+		expectMeasured bool
+	}{
+		{
+			name:           "grams",
+			line:           "500g beef mince",
+			expectQuantity: 500,
+			expectUnits:    conversion.Grams,
+			expectName:     "beef mince",
+			expectMeasured: true,
+		},
+		{
+			name:           "kilograms converted to grams",
+			line:           "1.5 kg potatoes, peeled",
+			expectQuantity: 1500,
+			expectUnits:    conversion.Grams,
+			expectName:     "potatoes",
+			expectMeasured: true,
+		},
+		{
+			name:           "mixed number cups",
+			line:           "1 1/2 cups plain flour, sifted",
+			expectQuantity: 375,
+			expectUnits:    conversion.Millilitres,
+			expectName:     "plain flour",
+			expectMeasured: true,
+		},
+		{
+			name:           "vulgar fraction spoon",
+			line:           "½ tsp. salt",
+			expectQuantity: 2.5,
+			expectUnits:    conversion.Millilitres,
+			expectName:     "salt",
+			expectMeasured: true,
+		},
+		{
+			name:           "count without units",
+			line:           "3 eggs",
+			expectQuantity: 3,
+			expectUnits:    conversion.Units,
+			expectName:     "eggs",
+			expectMeasured: true,
+		},
+		{
+			name:           "range takes lower amount",
+			line:           "2-3 carrots (diced)",
+			expectQuantity: 2,
+			expectUnits:    conversion.Units,
+			expectName:     "carrots",
+			expectMeasured: true,
+		},
+		{
+			name:           "unmeasured unit",
+			line:           "2 cloves of garlic",
+			expectQuantity: 2,
+			expectUnits:    "",
+			expectName:     "garlic",
+			expectMeasured: false,
+		},
+		{
+			name:           "no amount",
+			line:           "Salt and pepper to taste",
+			expectQuantity: 0,
+			expectUnits:    "",
+			expectName:     "Salt and pepper to taste",
+			expectMeasured: false,
+		},
+		{
+			name:           "pounds",
+			line:           "2 lbs chicken thighs",
+			expectQuantity: 2,
+			expectUnits:    conversion.Pounds,
+			expectName:     "chicken thighs",
+			expectMeasured: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ingredient := ParseIngredient(tt.line)
+
+			assert.Equal(t, ingredient.Text, tt.line)
+			assert.Equal(t, math.Abs(ingredient.Quantity-tt.expectQuantity) < 1e-9, true)
+			assert.Equal(t, ingredient.Units, tt.expectUnits)
+			assert.Equal(t, ingredient.Name, tt.expectName)
+			assert.Equal(t, ingredient.Measured(), tt.expectMeasured)
+		})
+	}
+}
+
+func TestMatchName(t *testing.T) {
+
+	candidates := []string{"Plain Flour", "Self Raising Flour", "Tomato", "Brown Onion"}
+
+	tests := []struct {
+		name        string
+		ingredient  string
+		expectIndex int
+	}{
+		{
+			name:        "equal ignoring case",
+			ingredient:  "plain flour",
+			expectIndex: 0,
+		},
+		{
+			name:        "equal ignoring plural",
+			ingredient:  "tomatoes",
+			expectIndex: 2,
+		},
+		{
+			name:        "candidate contains name",
+			ingredient:  "onions",
+			expectIndex: 3,
+		},
+		{
+			name:        "name contains candidate",
+			ingredient:  "diced ripe tomatoes",
+			expectIndex: 2,
+		},
+		{
+			name:        "no match",
+			ingredient:  "butter",
+			expectIndex: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, MatchName(tt.ingredient, candidates), tt.expectIndex)
+		})
+	}
+}
@@ -0,0 +1,202 @@
+// Package recipeimport reads recipes published as schema.org Recipe JSON-LD, either as the JSON-LD
+// document itself or embedded in the HTML page of a recipe website.
+package recipeimport
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var ErrNoRecipe = errors.New("no schema.org Recipe found")
+
+// Recipe holds the parts of a schema.org Recipe that can be imported
+type Recipe struct {
+	Name        string
+	Description string
+	// Servings is the first number in recipeYield, zero when not given
+	Servings     int64
+	Ingredients  []string
+	Instructions []string
+}
+
+// ldScripts finds the JSON-LD script blocks of an HTML page
+var ldScripts = regexp.MustCompile(`(?is)<script[^>]*type\s*=\s*["']?application/ld\+json["']?[^>]*>(.*?)</script>`)
+
+// Parse reads the first Recipe in content, a JSON-LD document or an HTML page containing one
+func Parse(content []byte) (*Recipe, error) {
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) == 0 {
+		return nil, ErrNoRecipe
+	}
+
+	if trimmed[0] == '{' || trimmed[0] == '[' {
+		return parseJSONLD(trimmed)
+	}
+
+	for _, match := range ldScripts.FindAllSubmatch(trimmed, -1) {
+		recipe, err := parseJSONLD(bytes.TrimSpace(match[1]))
+		if err == nil {
+			return recipe, nil
+		}
+	}
+
+	return nil, ErrNoRecipe
+}
+
+func parseJSONLD(document []byte) (*Recipe, error) {
+	var node any
+	err := json.Unmarshal(document, &node)
+	if err != nil {
+		return nil, fmt.Errorf("reading JSON-LD: %w", err)
+	}
+
+	found := findRecipe(node)
+	if found == nil {
+		return nil, ErrNoRecipe
+	}
+
+	recipe := &Recipe{
+		Name:         cleanText(stringValue(found["name"])),
+		Description:  cleanText(stringValue(found["description"])),
+		Servings:     parseYield(found["recipeYield"]),
+		Ingredients:  []string{},
+		Instructions: []string{},
+	}
+
+	ingredients := found["recipeIngredient"]
+	if ingredients == nil {
+		// older documents use the superseded ingredients property
+		ingredients = found["ingredients"]
+	}
+	for _, ingredient := range listValue(ingredients) {
+		if line := cleanText(stringValue(ingredient)); line != "" {
+			recipe.Ingredients = append(recipe.Ingredients, line)
+		}
+	}
+
+	recipe.Instructions = appendInstructions(recipe.Instructions, found["recipeInstructions"])
+
+	return recipe, nil
+}
+
+// findRecipe searches a JSON-LD node, its @graph and main entity for an object typed Recipe
+func findRecipe(node any) map[string]any {
+	switch node := node.(type) {
+	case []any:
+		for _, item := range node {
+			if recipe := findRecipe(item); recipe != nil {
+				return recipe
+			}
+		}
+	case map[string]any:
+		if hasType(node, "Recipe") {
+			return node
+		}
+		for _, key := range []string{"@graph", "mainEntity", "mainEntityOfPage"} {
+			if recipe := findRecipe(node[key]); recipe != nil {
+				return recipe
+			}
+		}
+	}
+
+	return nil
+}
+
+// hasType reports whether the object's @type, a string or list of strings, includes typeName
+func hasType(node map[string]any, typeName string) bool {
+	for _, value := range listValue(node["@type"]) {
+		if name, ok := value.(string); ok && (name == typeName || name == "http://schema.org/"+typeName || name == "https://schema.org/"+typeName) {
+			return true
+		}
+	}
+	return false
+}
+
+// appendInstructions flattens recipeInstructions, given as text, a list of text, HowToStep objects or
+// HowToSection objects listing steps
+func appendInstructions(instructions []string, node any) []string {
+	switch node := node.(type) {
+	case string:
+		for _, line := range strings.Split(node, "\n") {
+			if line = cleanText(line); line != "" {
+				instructions = append(instructions, line)
+			}
+		}
+	case []any:
+		for _, item := range node {
+			instructions = appendInstructions(instructions, item)
+		}
+	case map[string]any:
+		if steps, ok := node["itemListElement"]; ok {
+			return appendInstructions(instructions, steps)
+		}
+		text := stringValue(node["text"])
+		if text == "" {
+			text = stringValue(node["name"])
+		}
+		return appendInstructions(instructions, text)
+	}
+
+	return instructions
+}
+
+var firstNumber = regexp.MustCompile(`\d+`)
+
+// parseYield reads the number of servings from recipeYield such as 4, "4" or "Serves 4-6"
+func parseYield(node any) int64 {
+	for _, value := range listValue(node) {
+		switch value := value.(type) {
+		case float64:
+			if value >= 1 {
+				return int64(value)
+			}
+		case string:
+			if match := firstNumber.FindString(value); match != "" {
+				servings, err := strconv.ParseInt(match, 10, 64)
+				if err == nil && servings > 0 {
+					return servings
+				}
+			}
+		}
+	}
+
+	return 0
+}
+
+// listValue treats a single value as a list of one, JSON-LD allows either for most properties
+func listValue(node any) []any {
+	switch node := node.(type) {
+	case nil:
+		return nil
+	case []any:
+		return node
+	default:
+		return []any{node}
+	}
+}
+
+func stringValue(node any) string {
+	switch node := node.(type) {
+	case string:
+		return node
+	case []any:
+		if len(node) > 0 {
+			return stringValue(node[0])
+		}
+	}
+	return ""
+}
+
+var htmlTags = regexp.MustCompile(`<[^>]*>`)
+
+// cleanText removes markup and entities some sites leave in JSON-LD text and collapses whitespace
+func cleanText(text string) string {
+	text = html.UnescapeString(htmlTags.ReplaceAllString(text, " "))
+	return strings.Join(strings.Fields(text), " ")
+}
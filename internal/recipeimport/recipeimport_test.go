@@ -0,0 +1,100 @@
+package recipeimport
+
+import (
+	"testing"
+
+	"github.com/tconnellan/macro-tracker-backend/internal/assert"
+)
+
+func TestParse(t *testing.T) {
+
+	tests := []struct {
+		name               string
+		content            string
+		expectError        error
+		expectName         string
+		expectServings     int64
+		expectIngredients  int
+		expectInstructions []string
+	}{
+		{
+			name: "json-ld document",
+			content: `{
+				"@context": "https://schema.org",
+				"@type": "Recipe",
+				"name": "Pancakes",
+				"recipeYield": "Serves 4-6",
+				"recipeIngredient": ["1 cup plain flour", "2 eggs", "1 cup milk"],
+				"recipeInstructions": "Whisk everything.\nCook in a hot pan."
+			}`,
+			expectName:         "Pancakes",
+			expectServings:     4,
+			expectIngredients:  3,
+			expectInstructions: []string{"Whisk everything.", "Cook in a hot pan."},
+		},
+		{
+			name: "recipe in graph with sections",
+			content: `{
+				"@context": "https://schema.org",
+				"@graph": [
+					{"@type": "WebPage", "name": "Some site"},
+					{
+						"@type": ["Recipe", "NewsArticle"],
+						"name": "Mac &amp; Cheese",
+						"recipeYield": [4, "4 servings"],
+						"recipeIngredient": ["250g macaroni", "200g <b>cheddar</b>"],
+						"recipeInstructions": [
+							{"@type": "HowToSection", "name": "Pasta", "itemListElement": [
+								{"@type": "HowToStep", "text": "Boil the pasta."}
+							]},
+							{"@type": "HowToStep", "text": "Stir through the cheese."}
+						]
+					}
+				]
+			}`,
+			expectName:         "Mac & Cheese",
+			expectServings:     4,
+			expectIngredients:  2,
+			expectInstructions: []string{"Boil the pasta.", "Stir through the cheese."},
+		},
+		{
+			name: "html page",
+			content: `<!doctype html><html><head>
+				<script type="application/ld+json">{"@type": "Organization", "name": "Site"}</script>
+				<script type="application/ld+json">[{"@type": "Recipe", "name": "Soup", "recipeIngredient": ["1 l stock"], "recipeInstructions": ["Heat."]}]</script>
+				</head><body></body></html>`,
+			expectName:         "Soup",
+			expectIngredients:  1,
+			expectInstructions: []string{"Heat."},
+		},
+		{
+			name:        "html without recipe",
+			content:     `<html><body><p>no recipe here</p></body></html>`,
+			expectError: ErrNoRecipe,
+		},
+		{
+			name:        "json-ld without recipe",
+			content:     `{"@type": "Person", "name": "someone"}`,
+			expectError: ErrNoRecipe,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recipe, err := Parse([]byte(tt.content))
+
+			assert.ExpectError(t, err, tt.expectError)
+			if err != nil {
+				return
+			}
+
+			assert.Equal(t, recipe.Name, tt.expectName)
+			assert.Equal(t, recipe.Servings, tt.expectServings)
+			assert.Equal(t, len(recipe.Ingredients), tt.expectIngredients)
+			assert.Equal(t, len(recipe.Instructions), len(tt.expectInstructions))
+			for i, instruction := range tt.expectInstructions {
+				assert.Equal(t, recipe.Instructions[i], instruction)
+			}
+		})
+	}
+}